@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/config"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/backup"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/transfer"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+	"github.com/MaximShildyakov/url-shortener/internal/storage/sqlite"
+)
+
+// deps are what subcommands share with the server.
+type deps struct {
+	cfg            *config.Config
+	storage        *sqlite.Storage
+	aliasGenerator transfer.AliasGenerator
+	aliasChecker   transfer.AliasChecker
+	urlChecker     transfer.URLChecker
+	domainChecker  transfer.DomainChecker
+	backups        *backup.Manager
+	events         *webhook.Dispatcher
+	auditLog       *audit.Log
+}
+
+type command func(log *slog.Logger, d deps, args []string) error
+
+var commands = map[string]command{
+	"export":  runExport,
+	"import":  runImport,
+	"backup":  runBackup,
+	"restore": runRestore,
+}
+
+// errUsage is returned by commands whose usage has already been printed.
+var errUsage = errors.New("invalid usage")
+
+// runCommand runs the subcommand named by args[0] and returns the process
+// exit code.
+func runCommand(log *slog.Logger, d deps, args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(os.Stderr, "unknown command %q, expected one of: %s\n", args[0], strings.Join(names, ", "))
+
+		return 2
+	}
+
+	if err := cmd(log, d, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+
+		log.Error("command failed", slog.String("command", args[0]), sl.Err(err))
+
+		return 1
+	}
+
+	return 0
+}
+
+// currentUser is who commands' changes are attributed to unless -actor
+// says otherwise.
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+
+	return u.Username
+}
+
+// actorAudit attributes the importer's changes to actor.
+type actorAudit struct {
+	auditLog *audit.Log
+	actor    string
+}
+
+func (a actorAudit) Record(e audit.Entry) {
+	a.auditLog.RecordAs(a.actor, e)
+}
+
+// storedEvents publishes events by storing them right away, as commands
+// exit without running the dispatcher. The server sends them.
+type storedEvents struct {
+	events *webhook.Dispatcher
+}
+
+func (e storedEvents) Publish(name string, link storage.Link) {
+	e.events.PublishNow(name, link)
+}
+
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: url-shortener %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// runExport writes every link to a file or stdout.
+func runExport(log *slog.Logger, d deps, args []string) error {
+	fs := newFlagSet("export", "[-format csv|ndjson] [-o file]")
+	format := fs.String("format", transfer.FormatCSV, "output format, csv or ndjson")
+	output := fs.String("o", "-", "output file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+
+		out = file
+	}
+
+	writer, err := transfer.NewWriter(out, *format)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = d.storage.ForEachLink(func(link storage.Link) error {
+		count++
+		return writer.Write(link)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	log.Info("links exported", slog.String("format", *format), slog.Int("count", count))
+
+	return nil
+}
+
+// runImport reads links from a file or stdin.
+func runImport(log *slog.Logger, d deps, args []string) error {
+	fs := newFlagSet("import", "[-format csv|ndjson] [-conflict skip|overwrite|rename] [-owner name] [-actor name] file")
+	format := fs.String("format", "", "input format, csv or ndjson; guessed from the file extension by default")
+	conflict := fs.String("conflict", transfer.ConflictSkip, "what to do with taken aliases: skip, overwrite or rename")
+	owner := fs.String("owner", d.cfg.HTTPServer.User, "owner of links that don't name one")
+	actor := fs.String("actor", currentUser(), "user the changes are attributed to in link history and the audit log")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = transfer.FormatCSV
+		if ext := filepath.Ext(path); ext == ".ndjson" || ext == ".jsonl" {
+			*format = transfer.FormatNDJSON
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+
+		in = file
+	}
+
+	reader, err := transfer.NewReader(in, *format)
+	if err != nil {
+		return err
+	}
+
+	importer := &transfer.Importer{
+		Store:          d.storage,
+		AliasGenerator: d.aliasGenerator,
+		AliasChecker:   d.aliasChecker,
+		URLChecker:     d.urlChecker,
+		DomainChecker:  d.domainChecker,
+		Conflict:       *conflict,
+		Owner:          *owner,
+		Actor:          *actor,
+		MaxAttempts:    d.cfg.Alias.MaxAttempts,
+		Events:         storedEvents{events: d.events},
+		Audit:          actorAudit{auditLog: d.auditLog, actor: *actor},
+	}
+
+	res, err := importer.Import(reader)
+
+	for _, failure := range res.Failures {
+		fmt.Fprintf(os.Stderr, "row %d %s: %s\n", failure.Row, failure.Alias, failure.Error)
+	}
+	fmt.Printf("imported %d, overwritten %d, renamed %d, skipped %d, failed %d\n",
+		res.Imported, res.Overwritten, res.Renamed, res.Skipped, res.Failed)
+
+	if err != nil {
+		return err
+	}
+
+	log.Info("links imported", slog.String("format", *format), slog.Int("imported", res.Imported))
+
+	return nil
+}
+
+// runBackup snapshots the database into the backup directory, or to a
+// given file.
+func runBackup(log *slog.Logger, d deps, args []string) error {
+	fs := newFlagSet("backup", "[-o file]")
+	output := fs.String("o", "", "write the snapshot to this file instead of the backup directory")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	ctx := context.Background()
+
+	path := *output
+	if path == "" {
+		snapshot, err := d.backups.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		path = snapshot.Path
+	} else if err := d.storage.Backup(ctx, path); err != nil {
+		return err
+	}
+
+	fmt.Println(path)
+
+	log.Info("snapshot taken", slog.String("path", path))
+
+	return nil
+}
+
+// runRestore replaces the database with a snapshot, first snapshotting the
+// current contents so the restore can be undone. The restore is recorded in
+// the restored database's audit log.
+func runRestore(log *slog.Logger, d deps, args []string) error {
+	fs := newFlagSet("restore", "[-no-snapshot] [-actor name] file")
+	noSnapshot := fs.Bool("no-snapshot", false, "don't snapshot the current database first")
+	actor := fs.String("actor", currentUser(), "user the restore is attributed to in the audit log")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	ctx := context.Background()
+
+	entry := audit.Entry{
+		Action: audit.ActionDatabaseRestore,
+		After:  audit.Snapshot{Path: fs.Arg(0)},
+	}
+
+	if !*noSnapshot {
+		snapshot, err := d.backups.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("current database saved to %s\n", snapshot.Path)
+
+		entry.Before = audit.Snapshot{Path: snapshot.Path}
+	}
+
+	if err := d.storage.Restore(ctx, fs.Arg(0)); err != nil {
+		return err
+	}
+
+	d.auditLog.RecordAs(*actor, entry)
+
+	fmt.Printf("restored %s\n", fs.Arg(0))
+
+	log.Info("database restored", slog.String("snapshot", fs.Arg(0)))
+
+	return nil
+}
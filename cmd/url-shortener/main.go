@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 
 	"github.com/go-chi/chi/v5"
@@ -12,11 +18,68 @@ import (
 
 
 	"github.com/MaximShildyakov/url-shortener/internal/config"
+	auditExport "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/audit/export"
+	auditList "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/audit/list"
+	adminBackup "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/backup"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/blocklist/reload"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/broken"
+	domainsDelete "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/domains/delete"
+	domainsList "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/domains/list"
+	domainsSave "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/domains/save"
+	domainsUpdate "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/domains/update"
+	adminEvents "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/events"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/export"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/flagged"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/importlinks"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/misses"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/trash"
+	webhooksDeadLetters "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/deadletters"
+	webhooksDelete "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/delete"
+	webhooksDeliveries "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/deliveries"
+	webhooksList "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/list"
+	webhooksReplay "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/replay"
+	webhooksSave "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/save"
+	collectionLinksAdd "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/links/add"
+	collectionLinksList "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/links/list"
+	collectionLinksRemove "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/links/remove"
+	collectionsDelete "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/delete"
+	collectionsList "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/list"
+	collectionsSave "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	collectionsStats "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/stats"
+	collectionsUpdate "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/update"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/preview"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/qr"
 	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/redirect"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/batch"
+	clickEvents "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/events"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/history"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/list"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/restore"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/rollback"
 	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/stats"
+	tagsList "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/tags/list"
+	tagsStats "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/tags/stats"
+	linkUpdate "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/update"
+	variantsResults "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/variants/results"
+	variantsUpdate "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/variants/update"
 	mwLogger "github.com/MaximShildyakov/url-shortener/internal/http-server/middleware/logger"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/alias"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/aliasfilter"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/backup"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/clickstream"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/geo"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/health"
 	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogpretty"
 	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/notfound"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/split"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/threat"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
 	"github.com/MaximShildyakov/url-shortener/internal/storage/sqlite"
 	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/delete"
 )
@@ -31,7 +94,13 @@ func main(){
     
     cfg := config.MustLoad()
 
-	log := setupLogger(cfg.Env)
+	// Subcommands may write their results to stdout, so they log to stderr.
+	logOutput := os.Stdout
+	if len(os.Args) > 1 {
+		logOutput = os.Stderr
+	}
+
+	log := setupLogger(cfg.Env, logOutput)
 
 	log.Info("starting url-shortener",
 		slog.String("env", cfg.Env),
@@ -45,6 +114,87 @@ func main(){
 		os.Exit(1)
 	}
 
+	aliasGenerator, err := setupAliasGenerator(cfg.Alias, storage)
+	if err != nil{
+		log.Error("failed to init alias generator", sl.Err(err))
+		os.Exit(1)
+	}
+
+	aliasFilter, err := aliasfilter.New(cfg.Alias.Reserved, cfg.Alias.BlocklistPath)
+	if err != nil{
+		log.Error("failed to init alias filter", sl.Err(err))
+		os.Exit(1)
+	}
+
+	policyCfg, err := policy.Load(cfg.PolicyPath)
+	if err != nil{
+		log.Error("failed to load destination policy", sl.Err(err))
+		os.Exit(1)
+	}
+
+	destinationPolicy := policy.New(policyCfg)
+	destinationPolicy.AddShortDomains(cfg.HTTPServer.Address)
+	if baseURL, err := url.Parse(cfg.HTTPServer.BaseURL); err == nil && baseURL.Host != "" {
+		// The listen address is often not the host users see.
+		destinationPolicy.AddShortDomains(baseURL.Host)
+	}
+
+	domains, err := domain.New(storage)
+	if err != nil{
+		log.Error("failed to load short domains", sl.Err(err))
+		os.Exit(1)
+	}
+
+	notFound, err := notfound.New(cfg.NotFound.Strategy, cfg.NotFound.TemplateDir, cfg.NotFound.FallbackURL)
+	if err != nil{
+		log.Error("failed to init not found responses", sl.Err(err))
+		os.Exit(1)
+	}
+
+	threatList, err := threat.New(cfg.Threat.DomainLists, cfg.Threat.HashPrefixLists)
+	if err != nil{
+		log.Error("failed to load threat lists", sl.Err(err))
+		os.Exit(1)
+	}
+
+	go watchThreatList(log, threatList, cfg.Threat.ReloadInterval)
+
+	geoDB, err := geo.New(cfg.GeoIP.DBPath)
+	if err != nil{
+		log.Error("failed to open geoip database", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if cfg.GeoIP.DBPath != "" {
+		go watchGeoDB(log, geoDB, cfg.GeoIP.ReloadInterval)
+	}
+
+	variantPicker, err := split.New(cfg.Split.Sticky, cfg.Split.CookieMaxAge)
+	if err != nil{
+		log.Error("failed to init a/b splits", sl.Err(err))
+		os.Exit(1)
+	}
+
+	backups := backup.New(storage, cfg.Backup.Dir, cfg.Backup.Keep)
+
+	events := webhook.New(log, storage, webhook.Options{
+		QueueSize:    cfg.Webhooks.QueueSize,
+		PollInterval: cfg.Webhooks.PollInterval,
+		Concurrency:  cfg.Webhooks.Concurrency,
+		Timeout:      cfg.Webhooks.Timeout,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		BaseDelay:    cfg.Webhooks.BaseDelay,
+		MaxDelay:     cfg.Webhooks.MaxDelay,
+	})
+
+	auditLog := audit.New(log, storage)
+
+	clicks := clickstream.New(cfg.ClickStream.BufferSize, cfg.ClickStream.ClientBuffer)
+	streamOpts := clickEvents.Options{
+		Heartbeat:    cfg.ClickStream.Heartbeat,
+		WriteTimeout: cfg.ClickStream.WriteTimeout,
+	}
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
@@ -53,16 +203,129 @@ func main(){
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
+	saveOpts := save.Options{
+		MaxAttempts:  cfg.Alias.MaxAttempts,
+		Dedupe:       cfg.Dedupe.Enabled,
+		KeepFragment: cfg.Dedupe.KeepFragment,
+	}
+	urlChecker := policy.Chain{destinationPolicy, domains, threatList}
+
+	accessChecker := access.New(storage, cfg.HTTPServer.User)
+
 	router.Route("/url", func(r chi.Router){
+		r.Use(middleware.BasicAuth("url-shortener", cfg.HTTPServer.Accounts()))
+
+		r.Post("/", save.New(log, storage, aliasGenerator, aliasFilter, urlChecker, domains, events, auditLog, saveOpts))
+		r.Get("/", list.New(log, storage, accessChecker))
+		r.Get("/tags", tagsList.New(log, storage, accessChecker))
+		r.Get("/tags/{tag}/stats", tagsStats.New(log, storage, accessChecker))
+		r.Post("/batch", batch.New(log, storage, aliasGenerator, aliasFilter, urlChecker, domains, events, auditLog, saveOpts, cfg.Batch.MaxItems))
+		r.Patch("/{alias}", linkUpdate.New(log, storage, accessChecker, events, auditLog))
+		r.Delete("/{alias}", delete.New(log, storage, accessChecker, events, auditLog))
+		r.Post("/{alias}/restore", restore.New(log, storage, accessChecker, events, auditLog))
+		r.Get("/{alias}/history", history.New(log, storage, accessChecker))
+		r.Post("/{alias}/rollback/{revision}", rollback.New(log, storage, accessChecker, urlChecker, events, auditLog))
+		r.Get("/{alias}/stats", stats.New(log, storage, accessChecker))
+		r.Get("/{alias}/events", clickEvents.New(log, storage, accessChecker, clicks, streamOpts))
+		r.Get("/{alias}/variants", variantsResults.New(log, storage, accessChecker))
+		r.Put("/{alias}/variants", variantsUpdate.New(log, storage, accessChecker, urlChecker, events, auditLog))
+	})
+
+	router.Route("/collections", func(r chi.Router){
+		r.Use(middleware.BasicAuth("url-shortener", cfg.HTTPServer.Accounts()))
+
+		r.Get("/", collectionsList.New(log, storage, accessChecker))
+		r.Post("/", collectionsSave.New(log, storage, auditLog))
+		r.Patch("/{id}", collectionsUpdate.New(log, storage, accessChecker, auditLog))
+		r.Delete("/{id}", collectionsDelete.New(log, storage, accessChecker, auditLog))
+		r.Get("/{id}/stats", collectionsStats.New(log, storage, accessChecker))
+		r.Get("/{id}/links", collectionLinksList.New(log, storage, accessChecker))
+		r.Put("/{id}/links/{alias}", collectionLinksAdd.New(log, storage, accessChecker, accessChecker, auditLog))
+		r.Delete("/{id}/links/{alias}", collectionLinksRemove.New(log, storage, accessChecker, auditLog))
+	})
+
+	router.Route("/admin", func(r chi.Router){
 		r.Use(middleware.BasicAuth("url-shortener", map[string]string{
 			cfg.HTTPServer.User: cfg.HTTPServer.Password,
 		}))
 
-		r.Post("/", save.New(log, storage))
-		r.Delete("/{alias}", delete.New(log, storage))
+		r.Post("/blocklist/reload", reload.New(log, aliasFilter))
+		r.Get("/flagged", flagged.New(log, storage, threatList))
+		r.Get("/export", export.New(log, storage))
+		r.Get("/misses", misses.New(log, storage))
+		r.Get("/broken", broken.New(log, storage))
+		r.Get("/trash", trash.New(log, storage, cfg.Trash.Retention))
+		r.Get("/events", adminEvents.New(log, clicks, streamOpts))
+		r.Post("/import", importlinks.New(log, storage, aliasGenerator, aliasFilter, urlChecker, domains, events, auditLog, cfg.Alias.MaxAttempts))
+		r.Post("/backup", adminBackup.New(log, backups))
+
+		r.Get("/domains", domainsList.New(log, storage))
+		r.Post("/domains", domainsSave.New(log, storage, domains, auditLog))
+		r.Put("/domains/{host}", domainsUpdate.New(log, storage, domains, auditLog))
+		r.Delete("/domains/{host}", domainsDelete.New(log, storage, domains, auditLog))
+
+		r.Get("/webhooks", webhooksList.New(log, storage))
+		r.Post("/webhooks", webhooksSave.New(log, storage, auditLog))
+		r.Delete("/webhooks/{id}", webhooksDelete.New(log, storage, auditLog))
+		r.Get("/webhooks/deliveries", webhooksDeliveries.New(log, storage))
+		r.Get("/webhooks/dead-letters", webhooksDeadLetters.New(log, storage))
+		r.Post("/webhooks/dead-letters/{id}/replay", webhooksReplay.New(log, storage, auditLog))
+
+		r.Get("/audit", auditList.New(log, storage))
+		r.Get("/audit/export", auditExport.New(log, storage))
 	})
 
-	router.Get("/{alias}", redirect.New(log, storage))
+	redirectHandler := redirect.New(log, storage, threatList, storage, domains, storage, notFound, variantPicker, geoDB, events, clicks)
+	router.Get("/{alias}", redirectHandler)
+	router.Get("/{alias}/b/{button}", redirectHandler)
+	router.Get("/{alias}+", preview.New(log, storage, urlChecker, domains))
+	router.Get("/{alias}/qr", qr.New(log, storage, domains, cfg.HTTPServer.BaseURL))
+
+	// Aliases must never shadow our own routes.
+	routes, err := topLevelRoutes(router)
+	if err != nil{
+		log.Error("failed to collect routes", sl.Err(err))
+		os.Exit(1)
+	}
+	aliasFilter.Reserve(routes...)
+
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(log, deps{
+			cfg:            cfg,
+			storage:        storage,
+			aliasGenerator: aliasGenerator,
+			aliasChecker:   aliasFilter,
+			urlChecker:     urlChecker,
+			domainChecker:  domains,
+			backups:        backups,
+			events:         events,
+			auditLog:       auditLog,
+		}, os.Args[1:]))
+	}
+
+	if cfg.Backup.Interval > 0 {
+		go scheduleBackups(log, backups, cfg.Backup.Interval)
+	}
+
+	go events.Run(context.Background())
+
+	if cfg.Trash.Retention > 0 {
+		go schedulePurge(log, storage, events, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	}
+
+	if cfg.Health.Enabled {
+		checker := health.New(log, storage, health.Options{
+			Interval:    cfg.Health.Interval,
+			BatchSize:   cfg.Health.BatchSize,
+			Concurrency: cfg.Health.Concurrency,
+			HostDelay:   cfg.Health.HostDelay,
+			Timeout:     cfg.Health.Timeout,
+			WebhookURL:  cfg.Health.WebhookURL,
+			UserAgent:   cfg.Health.UserAgent,
+		})
+
+		go scheduleHealthChecks(log, checker, cfg.Health.Interval)
+	}
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
@@ -84,19 +347,19 @@ func main(){
 
 }
 
-func setupLogger(env string) *slog.Logger{
+func setupLogger(env string, out io.Writer) *slog.Logger{
 	var log *slog.Logger
 
 	switch env{
 	case envLocal:
-		log = setupPrettySlog()
+		log = setupPrettySlog(out)
 	case envDev:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}),
 		)
 	case envProd:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelInfo}),
 		)
 	
 	}
@@ -104,14 +367,142 @@ func setupLogger(env string) *slog.Logger{
 	return log
 }
 
-func setupPrettySlog() *slog.Logger {
+// watchThreatList reloads the threat lists whenever their files change.
+func watchThreatList(log *slog.Logger, list *threat.List, interval time.Duration) {
+	log = log.With(slog.String("component", "threat"))
+
+	for range time.Tick(interval) {
+		changed, err := list.ReloadIfChanged()
+		if err != nil {
+			log.Error("failed to reload threat lists", sl.Err(err))
+			continue
+		}
+		if changed {
+			log.Info("threat lists reloaded")
+		}
+	}
+}
+
+// watchGeoDB reloads the GeoIP database when the file changes.
+func watchGeoDB(log *slog.Logger, db *geo.DB, interval time.Duration) {
+	log = log.With(slog.String("component", "geoip"))
+
+	for range time.Tick(interval) {
+		changed, err := db.ReloadIfChanged()
+		if err != nil {
+			log.Error("failed to reload geoip database", sl.Err(err))
+			continue
+		}
+		if changed {
+			log.Info("geoip database reloaded")
+		}
+	}
+}
+
+// scheduleBackups takes a database snapshot every interval.
+func scheduleBackups(log *slog.Logger, backups *backup.Manager, interval time.Duration) {
+	log = log.With(slog.String("component", "backup"))
+
+	for range time.Tick(interval) {
+		snapshot, err := backups.Snapshot(context.Background())
+		if err != nil {
+			log.Error("failed to take scheduled snapshot", sl.Err(err))
+			continue
+		}
+
+		log.Info("scheduled snapshot taken", slog.String("path", snapshot.Path))
+	}
+}
+
+// schedulePurge removes the links deleted more than retention ago every
+// interval, the first time right away, and announces them as expired.
+func schedulePurge(log *slog.Logger, storage *sqlite.Storage, events *webhook.Dispatcher, retention time.Duration, interval time.Duration) {
+	log = log.With(slog.String("component", "trash"))
+
+	for {
+		purged, err := storage.PurgeDeleted(time.Now().Add(-retention))
+		if err != nil {
+			log.Error("failed to purge deleted links", sl.Err(err))
+		} else if len(purged) > 0 {
+			log.Info("deleted links purged", slog.Int("purged", len(purged)))
+		}
+
+		// A purge may free many aliases at once; the queue could drop
+		// some of their events.
+		for _, link := range purged {
+			events.PublishNow(webhook.EventExpired, link)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// scheduleHealthChecks checks the links that are due every interval, the
+// first time right away.
+func scheduleHealthChecks(log *slog.Logger, checker *health.Checker, interval time.Duration) {
+	log = log.With(slog.String("component", "health"))
+
+	for {
+		checked, err := checker.Run(context.Background())
+		if err != nil {
+			log.Error("failed to run health checks", sl.Err(err))
+		} else if checked > 0 {
+			log.Info("health checks done", slog.Int("checked", checked))
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// topLevelRoutes returns the static first path segments of all routes.
+func topLevelRoutes(router chi.Routes) ([]string, error) {
+	seen := make(map[string]struct{})
+
+	err := chi.Walk(router, func(_ string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if segment != "" && !strings.ContainsAny(segment, "{*") {
+			seen[segment] = struct{}{}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]string, 0, len(seen))
+	for segment := range seen {
+		routes = append(routes, segment)
+	}
+
+	return routes, nil
+}
+
+func setupAliasGenerator(cfg config.Alias, seq alias.Sequencer) (save.AliasGenerator, error) {
+	switch cfg.Strategy {
+	case alias.StrategyRandom:
+		return alias.NewRandom(cfg.Length, cfg.Alphabet)
+	case alias.StrategySequence:
+		return alias.NewSequence(seq, cfg.Length)
+	case alias.StrategyNanoID:
+		return alias.NewNanoID(cfg.Length)
+	case alias.StrategyHashids:
+		return alias.NewHashids(seq, cfg.Salt, cfg.Length)
+	case alias.StrategyWords:
+		return alias.NewWords(cfg.Length)
+	}
+
+	return nil, fmt.Errorf("%w: %q", alias.ErrUnknownStrategy, cfg.Strategy)
+}
+
+func setupPrettySlog(out io.Writer) *slog.Logger {
 	opts := slogpretty.PrettyHandlerOptions{
 		SlogOpts: &slog.HandlerOptions{
 			Level: slog.LevelDebug,
 		},
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
+	handler := opts.NewPrettyHandler(out)
 
 	return slog.New(handler)
 }
\ No newline at end of file
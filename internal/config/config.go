@@ -11,7 +11,20 @@ import (
 type Config struct {
 	Env         string `yaml:"env" env-default:"local"`
 	StoragePath string `yaml:"storage_path" env-required:"true"`
+	PolicyPath  string `yaml:"policy_path"`
 	HTTPServer  `yaml:"http_server"`
+	Alias       Alias       `yaml:"alias"`
+	Dedupe      Dedupe      `yaml:"dedupe"`
+	Threat      Threat      `yaml:"threat"`
+	Batch       Batch       `yaml:"batch"`
+	Backup      Backup      `yaml:"backup"`
+	NotFound    NotFound    `yaml:"not_found"`
+	Split       Split       `yaml:"split"`
+	GeoIP       GeoIP       `yaml:"geoip"`
+	Health      Health      `yaml:"health"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
+	ClickStream ClickStream `yaml:"click_stream"`
+	Trash       Trash       `yaml:"trash"`
 }
 
 type HTTPServer struct {
@@ -20,6 +33,167 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 	User        string        `yaml:"user" env-required:"true"`
 	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// Users are further accounts, by name and password. They may manage
+	// their own links and those shared with them through collections;
+	// User is the admin and may do anything.
+	Users map[string]string `yaml:"users"`
+
+	// BaseURL is the public address short URLs start with, such as
+	// https://sho.rt. When empty it is taken from each request.
+	BaseURL string `yaml:"base_url"`
+}
+
+// Accounts returns the password of every user by name, the admin's
+// included.
+func (s HTTPServer) Accounts() map[string]string {
+	accounts := make(map[string]string, len(s.Users)+1)
+	for user, password := range s.Users {
+		accounts[user] = password
+	}
+	accounts[s.User] = s.Password
+
+	return accounts
+}
+
+// Alias configures how aliases are generated for links saved without one.
+type Alias struct {
+	// Strategy is one of random, sequence, nanoid, hashids or words.
+	Strategy string `yaml:"strategy" env-default:"random"`
+	// Length is the alias length, the minimum length for sequence and
+	// hashids, or the number of words for words.
+	Length int `yaml:"length" env-default:"6"`
+	// Alphabet overrides the characters used by the random strategy,
+	// e.g. to leave out look-alikes such as 0/O and l/1.
+	Alphabet    string `yaml:"alphabet"`
+	MaxAttempts int    `yaml:"max_attempts" env-default:"5"`
+	Salt        string `yaml:"salt" env:"ALIAS_SALT"`
+	// Reserved words can't be used as aliases, in addition to every
+	// top-level route of the server.
+	Reserved []string `yaml:"reserved"`
+	// BlocklistPath is a file of regular expressions for forbidden aliases.
+	BlocklistPath string `yaml:"blocklist_path"`
+}
+
+// Dedupe configures detection of links to the same destination.
+type Dedupe struct {
+	// Enabled is the default for requests that don't choose themselves.
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// KeepFragment treats URLs differing only in the fragment as distinct.
+	KeepFragment bool `yaml:"keep_fragment" env-default:"false"`
+}
+
+// Threat lists the local phishing/malware lists checked on save and redirect.
+type Threat struct {
+	// DomainLists are files of flagged domains, one per line.
+	DomainLists []string `yaml:"domain_lists"`
+	// HashPrefixLists are files of Safe Browsing style SHA-256 prefixes.
+	HashPrefixLists []string      `yaml:"hash_prefix_lists"`
+	ReloadInterval  time.Duration `yaml:"reload_interval" env-default:"1m"`
+}
+
+// Batch configures bulk link creation.
+type Batch struct {
+	// MaxItems caps the number of links in one request.
+	MaxItems int `yaml:"max_items" env-default:"1000"`
+}
+
+// Backup configures database snapshots.
+type Backup struct {
+	Dir string `yaml:"dir" env-default:"./storage/backups"`
+	// Interval between scheduled snapshots; zero disables them.
+	Interval time.Duration `yaml:"interval" env-default:"0"`
+	// Keep is how many snapshots are retained, zero keeping all of them.
+	Keep int `yaml:"keep" env-default:"7"`
+}
+
+// NotFound configures the answer to requests for unknown aliases. Clients
+// asking for JSON only always get a JSON error, and domains with a fallback
+// URL always redirect to it.
+type NotFound struct {
+	// Strategy is page, redirect or json.
+	Strategy string `yaml:"strategy" env-default:"page"`
+	// TemplateDir may hold 404.html to replace the built-in page, and
+	// <host>.html pages for individual short domains.
+	TemplateDir string `yaml:"template_dir"`
+	// FallbackURL is where the redirect strategy sends misses.
+	FallbackURL string `yaml:"fallback_url"`
+}
+
+// Split configures A/B splits between weighted destinations.
+type Split struct {
+	// Sticky is cookie or ip: how visitors are kept on their variant.
+	Sticky string `yaml:"sticky" env-default:"cookie"`
+	// CookieMaxAge is how long the variant cookie lasts.
+	CookieMaxAge time.Duration `yaml:"cookie_max_age" env-default:"720h"`
+}
+
+// GeoIP configures locating visitors by IP for click stats and targeting.
+type GeoIP struct {
+	// DBPath is a MaxMind-format (MMDB) Country or City database, such as
+	// GeoLite2-City.mmdb. Visitors aren't located when it is empty.
+	DBPath string `yaml:"db_path"`
+	// ReloadInterval is how often the file is checked for changes.
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"1m"`
+}
+
+// Health configures the background checks of link destinations.
+type Health struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Interval is how often checks run and how long a result stays fresh.
+	Interval time.Duration `yaml:"interval" env-default:"1h"`
+	// BatchSize caps the links checked in one run.
+	BatchSize int `yaml:"batch_size" env-default:"500"`
+	// Concurrency caps the hosts checked at the same time.
+	Concurrency int `yaml:"concurrency" env-default:"8"`
+	// HostDelay is the pause between two requests to the same host.
+	HostDelay time.Duration `yaml:"host_delay" env-default:"1s"`
+	Timeout   time.Duration `yaml:"timeout" env-default:"10s"`
+	// WebhookURL, if set, is POSTed to when a link breaks.
+	WebhookURL string `yaml:"webhook_url"`
+	UserAgent  string `yaml:"user_agent" env-default:"url-shortener-health/1.0"`
+}
+
+// Webhooks configures the delivery of link lifecycle events to the
+// subscribed webhooks.
+type Webhooks struct {
+	// QueueSize is how many events may wait to be stored; more are dropped
+	// rather than slowing requests down.
+	QueueSize int `yaml:"queue_size" env-default:"1000"`
+	// PollInterval is how often due retries are looked for.
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	Concurrency  int           `yaml:"concurrency" env-default:"4"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	// MaxAttempts is how many times a delivery is tried before it goes to
+	// the dead letters.
+	MaxAttempts int `yaml:"max_attempts" env-default:"8"`
+	// BaseDelay is the wait after the first failure, doubling up to
+	// MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay" env-default:"30s"`
+	MaxDelay  time.Duration `yaml:"max_delay" env-default:"6h"`
+}
+
+// ClickStream configures the live click streams.
+type ClickStream struct {
+	// BufferSize is how many of the latest clicks are kept for clients
+	// resuming with Last-Event-ID.
+	BufferSize int `yaml:"buffer_size" env-default:"1000"`
+	// ClientBuffer is how many clicks may wait for a client; clients
+	// falling further behind are disconnected.
+	ClientBuffer int `yaml:"client_buffer" env-default:"64"`
+	// Heartbeat is how often idle streams get a comment to keep proxies
+	// from closing them.
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"`
+	// WriteTimeout bounds each write to a client.
+	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"10s"`
+}
+
+// Trash configures how long deleted links can be restored.
+type Trash struct {
+	// Retention is how long a deleted link keeps its alias before it is
+	// purged; zero keeps deleted links until they are restored.
+	Retention time.Duration `yaml:"retention" env-default:"720h"`
+	// PurgeInterval is how often expired links are purged.
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
 }
 
 func MustLoad() *Config{
@@ -41,4 +215,3 @@ func MustLoad() *Config{
 	return &cfg
 
 }
-
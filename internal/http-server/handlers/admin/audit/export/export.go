@@ -0,0 +1,63 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/audit/list"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/transfer"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type AuditIterator interface {
+	ForEachAudit(filter storage.AuditFilter, visit func(e storage.AuditEntry) error) error
+}
+
+// New returns a handler streaming the audit log as NDJSON, oldest first,
+// one entry per line in the form the list endpoint uses. It takes the
+// filters of list.ParseFilter.
+func New(log *slog.Logger, auditIterator AuditIterator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.audit.export.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		filter, errResp, ok := list.ParseFilter(r)
+		if !ok {
+			render.JSON(w, r, errResp)
+
+			return
+		}
+
+		filename := fmt.Sprintf("audit-%s.ndjson", time.Now().UTC().Format("20060102-150405"))
+		w.Header().Set("Content-Type", transfer.ContentType(transfer.FormatNDJSON))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+		enc := json.NewEncoder(w)
+
+		count := 0
+		err := auditIterator.ForEachAudit(filter, func(e storage.AuditEntry) error {
+			count++
+			return enc.Encode(list.NewEntry(e))
+		})
+		if err != nil {
+			// The response is already under way, so the client only sees a
+			// truncated file.
+			log.Error("failed to export audit log", slog.Int("written", count), sl.Err(err))
+
+			return
+		}
+
+		log.Info("audit log exported", slog.Int("count", count))
+	}
+}
@@ -0,0 +1,162 @@
+package list
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Entry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Domain    string          `json:"domain,omitempty"`
+	Alias     string          `json:"alias,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	ClientIP  string          `json:"client_ip,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NewEntry returns the JSON form of e.
+func NewEntry(e storage.AuditEntry) Entry {
+	return Entry{
+		ID:        e.ID,
+		Actor:     e.Actor,
+		Action:    e.Action,
+		Domain:    e.Domain,
+		Alias:     e.Alias,
+		Before:    e.Before,
+		After:     e.After,
+		RequestID: e.RequestID,
+		ClientIP:  e.ClientIP,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+type Response struct {
+	resp.Response
+	Entries []Entry `json:"entries"`
+	// NextBefore is the ?before for the next page, if there may be one.
+	NextBefore int64 `json:"next_before,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditLister
+type AuditLister interface {
+	ListAudit(filter storage.AuditFilter, limit int) ([]storage.AuditEntry, error)
+}
+
+// New returns a handler listing audit log entries, newest first. See
+// ParseFilter for the filters; ?limit caps a page at up to 1000 entries,
+// 100 by default, and ?before takes the next_before of the previous page.
+func New(log *slog.Logger, auditLister AuditLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.audit.list.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		filter, errResp, ok := ParseFilter(r)
+		if !ok {
+			render.JSON(w, r, errResp)
+
+			return
+		}
+
+		query := r.URL.Query()
+
+		limit := defaultLimit
+		if raw := query.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		if raw := query.Get("before"); raw != "" {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || id < 1 {
+				render.JSON(w, r, resp.Error("invalid before"))
+
+				return
+			}
+			filter.BeforeID = id
+		}
+
+		list, err := auditLister.ListAudit(filter, limit)
+		if err != nil {
+			log.Error("failed to list audit log", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		res := Response{Response: resp.OK(), Entries: make([]Entry, 0, len(list))}
+		for _, e := range list {
+			res.Entries = append(res.Entries, NewEntry(e))
+		}
+		if len(list) == limit {
+			res.NextBefore = list[len(list)-1].ID
+		}
+
+		render.JSON(w, r, res)
+	}
+}
+
+// ParseFilter reads the audit log filters from the query: ?actor, ?action,
+// ?domain and ?alias, and ?since and ?until as RFC 3339 times. ?alias
+// selects a link on ?domain, the default domain if none is given. If the
+// query is rejected it returns the response to send and false.
+func ParseFilter(r *http.Request) (storage.AuditFilter, resp.Response, bool) {
+	query := r.URL.Query()
+
+	filter := storage.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Domain: domain.Normalize(query.Get("domain")),
+		Alias:  query.Get("alias"),
+	}
+
+	for _, bound := range []struct {
+		name string
+		to   *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		raw := query.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, resp.Error(bound.name + " must be an RFC 3339 time"), false
+		}
+		*bound.to = t
+	}
+
+	return filter, resp.Response{}, true
+}
@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/backup"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+)
+
+type Response struct {
+	resp.Response
+	Snapshot backup.Snapshot `json:"snapshot"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Snapshotter
+type Snapshotter interface {
+	Snapshot(ctx context.Context) (backup.Snapshot, error)
+}
+
+// New returns a handler taking a database snapshot on demand.
+func New(log *slog.Logger, snapshotter Snapshotter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.backup.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		snapshot, err := snapshotter.Snapshot(r.Context())
+		if err != nil {
+			log.Error("failed to take snapshot", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to take snapshot"))
+
+			return
+		}
+
+		log.Info("snapshot taken", slog.String("path", snapshot.Path), slog.Int64("size", snapshot.Size))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Snapshot: snapshot,
+		})
+	}
+}
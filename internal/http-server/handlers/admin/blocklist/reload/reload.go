@@ -0,0 +1,51 @@
+package reload
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+)
+
+type Response struct {
+	resp.Response
+	Patterns int `json:"patterns"`
+}
+
+// Reloader re-reads a blocklist and reports how many patterns it holds.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Reloader
+type Reloader interface {
+	Reload() (int, error)
+}
+
+func New(log *slog.Logger, reloader Reloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.blocklist.reload.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		n, err := reloader.Reload()
+		if err != nil {
+			log.Error("failed to reload blocklist", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to reload blocklist"))
+
+			return
+		}
+
+		log.Info("blocklist reloaded", slog.Int("patterns", n))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Patterns: n,
+		})
+	}
+}
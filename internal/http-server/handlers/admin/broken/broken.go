@@ -0,0 +1,94 @@
+package broken
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Link struct {
+	Domain     string    `json:"domain,omitempty"`
+	Alias      string    `json:"alias"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Failures   int       `json:"failures"`
+}
+
+type Response struct {
+	resp.Response
+	Links []Link `json:"links"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=BrokenLister
+type BrokenLister interface {
+	ListBrokenLinks(limit int) ([]storage.Health, error)
+}
+
+// New returns a handler listing links whose destination failed its last
+// health check, those failing the longest first. ?limit caps the list at
+// up to 1000 entries, 100 by default.
+func New(log *slog.Logger, brokenLister BrokenLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.broken.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		limit := defaultLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		list, err := brokenLister.ListBrokenLinks(limit)
+		if err != nil {
+			log.Error("failed to list broken links", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		links := make([]Link, 0, len(list))
+		for _, h := range list {
+			links = append(links, Link{
+				Domain:     h.Domain,
+				Alias:      h.Alias,
+				URL:        h.URL,
+				StatusCode: h.StatusCode,
+				Error:      h.Error,
+				LatencyMs:  h.Latency.Milliseconds(),
+				CheckedAt:  h.CheckedAt,
+				Failures:   h.Failures,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Links:    links,
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package delete
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/domains/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type DomainDeleter interface {
+	GetDomain(host string) (storage.Domain, error)
+	DeleteDomain(host string) error
+}
+
+// New returns a handler unregistering the domain named by the host path
+// parameter. Domains that still have links are kept.
+func New(log *slog.Logger, domainDeleter DomainDeleter, reloader save.Reloader, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.domains.delete.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		host := domain.Normalize(save.HostParam(r))
+
+		before, err := domainDeleter.GetDomain(host)
+		if err == nil {
+			err = domainDeleter.DeleteDomain(host)
+		}
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			log.Info("domain not found", slog.String("host", host))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrDomainInUse) {
+			log.Info("domain still has links", slog.String("host", host))
+
+			render.JSON(w, r, resp.Error("domain still has links"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete domain", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("domain deleted", slog.String("host", host))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionDomainDelete,
+			Domain: host,
+			Before: audit.DomainOf(before),
+		})
+
+		if err := reloader.Reload(); err != nil {
+			log.Error("failed to reload domains", sl.Err(err))
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,71 @@
+package list
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Domain struct {
+	Host         string     `json:"host"`
+	FallbackURL  string     `json:"fallback_url,omitempty"`
+	RedirectCode int        `json:"redirect_code"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Domains []Domain `json:"domains"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DomainLister
+type DomainLister interface {
+	ListDomains() ([]storage.Domain, error)
+}
+
+// New returns a handler listing the registered short domains.
+func New(log *slog.Logger, domainLister DomainLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.domains.list.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		list, err := domainLister.ListDomains()
+		if err != nil {
+			log.Error("failed to list domains", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		domains := make([]Domain, 0, len(list))
+		for _, d := range list {
+			domain := Domain{
+				Host:         d.Host,
+				FallbackURL:  d.FallbackURL,
+				RedirectCode: d.RedirectCode,
+			}
+			if !d.CreatedAt.IsZero() {
+				domain.CreatedAt = &d.CreatedAt
+			}
+
+			domains = append(domains, domain)
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Domains:  domains,
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package save
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Request holds a domain's defaults. Update requests take the host from
+// the path and ignore Host.
+type Request struct {
+	Host string `json:"host,omitempty"`
+	// FallbackURL is where unknown aliases on the domain redirect to.
+	FallbackURL string `json:"fallback_url,omitempty" validate:"omitempty,url"`
+	// RedirectCode defaults to 302.
+	RedirectCode int `json:"redirect_code,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DomainSaver
+type DomainSaver interface {
+	SaveDomain(d storage.Domain) error
+}
+
+// Reloader refreshes the domains used for routing after a change.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Reloader
+type Reloader interface {
+	Reload() error
+}
+
+// AuditRecorder appends changes to the audit log, attributed to the
+// request's user.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditRecorder
+type AuditRecorder interface {
+	Record(r *http.Request, e audit.Entry)
+}
+
+// New returns a handler registering a short domain.
+func New(log *slog.Logger, domainSaver DomainSaver, reloader Reloader, auditRecorder AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.domains.save.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		d, errResp, ok := NewDomain(req.Host, req)
+		if !ok {
+			render.JSON(w, r, errResp)
+
+			return
+		}
+
+		err := domainSaver.SaveDomain(d)
+		if errors.Is(err, storage.ErrDomainExists) {
+			log.Info("domain already exists", slog.String("host", d.Host))
+
+			render.JSON(w, r, resp.Error("domain already exists"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to add domain", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to add domain"))
+
+			return
+		}
+
+		log.Info("domain added", slog.String("host", d.Host))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionDomainCreate,
+			Domain: d.Host,
+			After:  audit.DomainOf(d),
+		})
+
+		if err := reloader.Reload(); err != nil {
+			log.Error("failed to reload domains", sl.Err(err))
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// HostParam returns the host path parameter. middleware.URLFormat takes
+// everything after the last dot of the path for a format, so it is put
+// back here.
+func HostParam(r *http.Request) string {
+	host := chi.URLParam(r, "host")
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+		host += "." + format
+	}
+
+	return host
+}
+
+// NewDomain validates req and builds the domain for host. If req is
+// rejected it returns the response to send and false.
+func NewDomain(host string, req Request) (storage.Domain, resp.Response, bool) {
+	host = domain.Normalize(host)
+	if err := validator.New().Var(host, "required,hostname_rfc1123"); err != nil {
+		return storage.Domain{}, resp.Error("host must be a domain name"), false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			return storage.Domain{}, resp.ValidationError(validateErr), false
+		}
+
+		return storage.Domain{}, resp.Error("invalid request"), false
+	}
+
+	if req.RedirectCode == 0 {
+		req.RedirectCode = http.StatusFound
+	}
+	if !domain.ValidRedirectCode(req.RedirectCode) {
+		return storage.Domain{}, resp.Error(domain.ErrInvalidRedirectCode.Error()), false
+	}
+
+	return storage.Domain{
+		Host:         host,
+		FallbackURL:  req.FallbackURL,
+		RedirectCode: req.RedirectCode,
+	}, resp.Response{}, true
+}
@@ -0,0 +1,84 @@
+package update
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/domains/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type DomainUpdater interface {
+	GetDomain(host string) (storage.Domain, error)
+	UpdateDomain(d storage.Domain) error
+}
+
+// New returns a handler replacing the defaults of the domain named by the
+// host path parameter.
+func New(log *slog.Logger, domainUpdater DomainUpdater, reloader save.Reloader, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.domains.update.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req save.Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		d, errResp, ok := save.NewDomain(save.HostParam(r), req)
+		if !ok {
+			render.JSON(w, r, errResp)
+
+			return
+		}
+
+		before, err := domainUpdater.GetDomain(d.Host)
+		if err == nil {
+			err = domainUpdater.UpdateDomain(d)
+		}
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			log.Info("domain not found", slog.String("host", d.Host))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to update domain", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("domain updated", slog.String("host", d.Host))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionDomainUpdate,
+			Domain: d.Host,
+			Before: audit.DomainOf(before),
+			After:  audit.DomainOf(d),
+		})
+
+		if err := reloader.Reload(); err != nil {
+			log.Error("failed to reload domains", sl.Err(err))
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,26 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/events"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/clickstream"
+)
+
+// New returns a handler streaming every click, on any link and domain, as
+// server-sent events. It resumes and keeps alive like the per-link stream.
+func New(log *slog.Logger, subscriber events.Subscriber, opts events.Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.events.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		events.Stream(log, w, r, subscriber, clickstream.Topic{}, opts)
+	}
+}
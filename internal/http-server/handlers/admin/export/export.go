@@ -0,0 +1,72 @@
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/transfer"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// LinkLister iterates over all stored links.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkLister
+type LinkLister interface {
+	ForEachLink(visit func(link storage.Link) error) error
+}
+
+// New returns a handler streaming every link as CSV, or as NDJSON with
+// ?format=ndjson.
+func New(log *slog.Logger, linkLister LinkLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.export.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = transfer.FormatCSV
+		}
+
+		writer, err := transfer.NewWriter(w, format)
+		if err != nil {
+			log.Info("unknown export format", slog.String("format", format))
+
+			render.JSON(w, r, resp.Error("format must be csv or ndjson"))
+
+			return
+		}
+
+		filename := fmt.Sprintf("links-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+		w.Header().Set("Content-Type", transfer.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+		count := 0
+		err = linkLister.ForEachLink(func(link storage.Link) error {
+			count++
+			return writer.Write(link)
+		})
+		if err == nil {
+			err = writer.Flush()
+		}
+		if err != nil {
+			// The response is already under way, so the client only sees a
+			// truncated file.
+			log.Error("failed to export links", slog.Int("written", count), sl.Err(err))
+
+			return
+		}
+
+		log.Info("links exported", slog.String("format", format), slog.Int("count", count))
+	}
+}
@@ -0,0 +1,118 @@
+package flagged
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Link struct {
+	Domain string `json:"domain,omitempty"`
+	Alias  string `json:"alias"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+type Response struct {
+	resp.Response
+	Links []Link `json:"links"`
+}
+
+// LinkLister iterates over all stored links.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkLister
+type LinkLister interface {
+	ForEachLink(visit func(link storage.Link) error) error
+}
+
+// ThreatChecker flags unsafe destinations.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ThreatChecker
+type ThreatChecker interface {
+	CheckURL(rawURL string) error
+}
+
+// New returns a handler listing links whose destinations are currently
+// flagged by threatChecker. Links are listed once for every flagged
+// destination, rules, variants and page buttons included.
+func New(log *slog.Logger, linkLister LinkLister, threatChecker ThreatChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.flagged.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		links := []Link{}
+
+		err := linkLister.ForEachLink(func(link storage.Link) error {
+			for _, url := range destinations(link) {
+				err := threatChecker.CheckURL(url)
+				if err == nil {
+					continue
+				}
+
+				var violation *policy.Violation
+				if !errors.As(err, &violation) {
+					return err
+				}
+
+				links = append(links, Link{
+					Domain: link.Domain,
+					Alias:  link.Alias,
+					URL:    url,
+					Reason: violation.Message,
+				})
+			}
+
+			return nil
+		})
+		if err != nil {
+			log.Error("failed to list flagged links", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("flagged links listed", slog.Int("count", len(links)))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Links:    links,
+		})
+	}
+}
+
+// destinations returns the URLs link can send visitors to: its own URL and
+// those of its targeting rules and A/B variants, or the button URLs of a
+// page.
+func destinations(link storage.Link) []string {
+	if link.Page != nil {
+		urls := make([]string, 0, len(link.Page.Buttons))
+		for _, b := range link.Page.Buttons {
+			urls = append(urls, b.URL)
+		}
+
+		return urls
+	}
+
+	urls := []string{link.URL}
+	for _, rule := range link.Rules {
+		urls = append(urls, rule.URL)
+	}
+	for _, v := range link.Variants {
+		urls = append(urls, v.URL)
+	}
+
+	return urls
+}
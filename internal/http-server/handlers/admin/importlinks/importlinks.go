@@ -0,0 +1,128 @@
+package importlinks
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/transfer"
+)
+
+type Response struct {
+	resp.Response
+	transfer.Result
+}
+
+// AuditRecorder appends changes to the audit log, attributed to the
+// request's user.
+type AuditRecorder interface {
+	Record(r *http.Request, e audit.Entry)
+}
+
+// requestAudit attributes the importer's changes to r.
+type requestAudit struct {
+	auditRecorder AuditRecorder
+	r             *http.Request
+}
+
+func (a requestAudit) Record(e audit.Entry) {
+	a.auditRecorder.Record(a.r, e)
+}
+
+// New returns a handler importing links from the request body.
+//
+// The body is CSV, including Bitly-style exports, or NDJSON with
+// ?format=ndjson or an NDJSON Content-Type. ?conflict chooses what happens
+// to links whose alias is taken: skip (the default), overwrite or rename.
+// Links without an owner are given to the authenticated user.
+func New(
+	log *slog.Logger,
+	store transfer.Store,
+	aliasGenerator transfer.AliasGenerator,
+	aliasChecker transfer.AliasChecker,
+	urlChecker transfer.URLChecker,
+	domainChecker transfer.DomainChecker,
+	eventPublisher transfer.EventPublisher,
+	auditRecorder AuditRecorder,
+	maxAttempts int,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.importlinks.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = transfer.FormatCSV
+			if strings.Contains(r.Header.Get("Content-Type"), "ndjson") {
+				format = transfer.FormatNDJSON
+			}
+		}
+
+		conflict := r.URL.Query().Get("conflict")
+		if conflict == "" {
+			conflict = transfer.ConflictSkip
+		}
+		if !transfer.ValidConflict(conflict) {
+			render.JSON(w, r, resp.Error("conflict must be skip, overwrite or rename"))
+
+			return
+		}
+
+		reader, err := transfer.NewReader(r.Body, format)
+		if err != nil {
+			render.JSON(w, r, resp.Error("format must be csv or ndjson"))
+
+			return
+		}
+
+		owner, _, _ := r.BasicAuth()
+
+		importer := &transfer.Importer{
+			Store:          store,
+			AliasGenerator: aliasGenerator,
+			AliasChecker:   aliasChecker,
+			URLChecker:     urlChecker,
+			DomainChecker:  domainChecker,
+			Conflict:       conflict,
+			Owner:          owner,
+			Actor:          owner,
+			MaxAttempts:    maxAttempts,
+			Events:         eventPublisher,
+			Audit:          requestAudit{auditRecorder: auditRecorder, r: r},
+		}
+
+		res, err := importer.Import(reader)
+		if err != nil {
+			log.Error("failed to import links", sl.Err(err))
+
+			render.JSON(w, r, Response{
+				Response: resp.Error("import stopped, earlier rows were imported"),
+				Result:   res,
+			})
+
+			return
+		}
+
+		log.Info("links imported",
+			slog.String("format", format),
+			slog.String("conflict", conflict),
+			slog.Int("imported", res.Imported),
+			slog.Int("failed", res.Failed),
+		)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Result:   res,
+		})
+	}
+}
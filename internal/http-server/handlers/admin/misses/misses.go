@@ -0,0 +1,88 @@
+package misses
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Miss struct {
+	Domain    string    `json:"domain,omitempty"`
+	Alias     string    `json:"alias"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type Response struct {
+	resp.Response
+	Misses []Miss `json:"misses"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=MissLister
+type MissLister interface {
+	ListMisses(limit int) ([]storage.Miss, error)
+}
+
+// New returns a handler listing the most requested aliases that don't
+// exist, which usually are mistyped or broken printed links. ?limit caps
+// the list at up to 1000 entries, 100 by default.
+func New(log *slog.Logger, missLister MissLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.misses.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		limit := defaultLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		list, err := missLister.ListMisses(limit)
+		if err != nil {
+			log.Error("failed to list misses", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		misses := make([]Miss, 0, len(list))
+		for _, m := range list {
+			misses = append(misses, Miss{
+				Domain:    m.Domain,
+				Alias:     m.Alias,
+				Count:     m.Count,
+				FirstSeen: m.FirstSeen,
+				LastSeen:  m.LastSeen,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Misses:   misses,
+		})
+	}
+}
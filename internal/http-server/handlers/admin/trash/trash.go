@@ -0,0 +1,99 @@
+package trash
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Link struct {
+	Domain    string    `json:"domain,omitempty"`
+	Alias     string    `json:"alias"`
+	URL       string    `json:"url"`
+	Owner     string    `json:"owner,omitempty"`
+	Clicks    int64     `json:"clicks"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// PurgeAt is when the link goes for good, unset if the trash is kept
+	// forever.
+	PurgeAt *time.Time `json:"purge_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Links []Link `json:"links"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TrashLister
+type TrashLister interface {
+	ListDeletedLinks(limit int) ([]storage.Link, error)
+}
+
+// New returns a handler listing the links in the trash, the most recently
+// deleted first, with when they will be purged given retention. ?limit
+// caps the list at up to 1000 entries, 100 by default.
+func New(log *slog.Logger, trashLister TrashLister, retention time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.trash.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		limit := defaultLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		list, err := trashLister.ListDeletedLinks(limit)
+		if err != nil {
+			log.Error("failed to list trash", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		links := make([]Link, 0, len(list))
+		for _, l := range list {
+			link := Link{
+				Domain:    l.Domain,
+				Alias:     l.Alias,
+				URL:       l.URL,
+				Owner:     l.Owner,
+				Clicks:    l.Clicks,
+				DeletedAt: l.DeletedAt,
+			}
+			if retention > 0 {
+				purgeAt := l.DeletedAt.Add(retention)
+				link.PurgeAt = &purgeAt
+			}
+
+			links = append(links, link)
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Links:    links,
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package deadletters
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type DeadLetter struct {
+	ID         int64           `json:"id"`
+	DeliveryID int64           `json:"delivery_id"`
+	WebhookID  int64           `json:"webhook_id"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"`
+	Error      string          `json:"error"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	DeadLetters []DeadLetter `json:"dead_letters"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DeadLetterLister
+type DeadLetterLister interface {
+	ListDeadLetters(limit int) ([]storage.DeadLetter, error)
+}
+
+// New returns a handler listing the deliveries that ran out of attempts,
+// newest first. ?limit caps the list at up to 1000 entries, 100 by
+// default.
+func New(log *slog.Logger, deadLetterLister DeadLetterLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.webhooks.deadletters.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		limit := defaultLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		list, err := deadLetterLister.ListDeadLetters(limit)
+		if err != nil {
+			log.Error("failed to list dead letters", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		deadLetters := make([]DeadLetter, 0, len(list))
+		for _, dl := range list {
+			deadLetters = append(deadLetters, DeadLetter{
+				ID:         dl.ID,
+				DeliveryID: dl.DeliveryID,
+				WebhookID:  dl.WebhookID,
+				Event:      dl.Event,
+				Payload:    dl.Payload,
+				Attempts:   dl.Attempts,
+				Error:      dl.Error,
+				CreatedAt:  dl.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response:    resp.OK(),
+			DeadLetters: deadLetters,
+		})
+	}
+}
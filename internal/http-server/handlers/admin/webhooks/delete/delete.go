@@ -0,0 +1,69 @@
+package delete
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type WebhookDeleter interface {
+	GetWebhook(id int64) (storage.Webhook, error)
+	DeleteWebhook(id int64) error
+}
+
+// New returns a handler removing the webhook named by the id path
+// parameter, together with its deliveries.
+func New(log *slog.Logger, webhookDeleter WebhookDeleter, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.webhooks.delete.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		if !ok {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		before, err := webhookDeleter.GetWebhook(id)
+		if err == nil {
+			err = webhookDeleter.DeleteWebhook(id)
+		}
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("id", id))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete webhook", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("webhook deleted", slog.Int64("id", id))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionWebhookDelete,
+			Before: audit.WebhookOf(before),
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
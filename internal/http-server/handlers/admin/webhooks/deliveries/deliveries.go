@@ -0,0 +1,158 @@
+package deliveries
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Attempt struct {
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type Delivery struct {
+	ID            int64      `json:"id"`
+	WebhookID     int64      `json:"webhook_id"`
+	Event         string     `json:"event"`
+	Status        string     `json:"status"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	Attempts      []Attempt  `json:"attempts"`
+}
+
+type Response struct {
+	resp.Response
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DeliveryLister
+type DeliveryLister interface {
+	ListDeliveries(webhookID int64, status string, limit int) ([]storage.Delivery, error)
+	ListDeliveryAttempts(deliveryIDs ...int64) ([]storage.DeliveryAttempt, error)
+}
+
+// New returns a handler listing webhook deliveries, newest first, with
+// every attempt made for them. ?webhook_id and ?status (pending, delivered
+// or dead) filter the list; ?limit caps it at up to 1000 entries, 100 by
+// default.
+func New(log *slog.Logger, deliveryLister DeliveryLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.webhooks.deliveries.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		limit := defaultLimit
+		if raw := query.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		var webhookID int64
+		if raw := query.Get("webhook_id"); raw != "" {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || id < 1 {
+				render.JSON(w, r, resp.Error("invalid webhook_id"))
+
+				return
+			}
+			webhookID = id
+		}
+
+		status := query.Get("status")
+		switch status {
+		case "", storage.DeliveryPending, storage.DeliveryDelivered, storage.DeliveryDead:
+		default:
+			render.JSON(w, r, resp.Error("status must be pending, delivered or dead"))
+
+			return
+		}
+
+		list, err := deliveryLister.ListDeliveries(webhookID, status, limit)
+		if err != nil {
+			log.Error("failed to list deliveries", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		ids := make([]int64, 0, len(list))
+		for _, d := range list {
+			ids = append(ids, d.ID)
+		}
+
+		attempts, err := deliveryLister.ListDeliveryAttempts(ids...)
+		if err != nil {
+			log.Error("failed to list delivery attempts", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		byDelivery := make(map[int64][]Attempt, len(list))
+		for _, a := range attempts {
+			byDelivery[a.DeliveryID] = append(byDelivery[a.DeliveryID], Attempt{
+				StatusCode:  a.StatusCode,
+				Error:       a.Error,
+				DurationMs:  a.Duration.Milliseconds(),
+				AttemptedAt: a.AttemptedAt,
+			})
+		}
+
+		deliveries := make([]Delivery, 0, len(list))
+		for _, d := range list {
+			delivery := Delivery{
+				ID:        d.ID,
+				WebhookID: d.WebhookID,
+				Event:     d.Event,
+				Status:    d.Status,
+				CreatedAt: d.CreatedAt,
+				Attempts:  byDelivery[d.ID],
+			}
+			if delivery.Attempts == nil {
+				delivery.Attempts = []Attempt{}
+			}
+			if d.Status == storage.DeliveryPending {
+				delivery.NextAttemptAt = &d.NextAttemptAt
+			}
+			if !d.DeliveredAt.IsZero() {
+				delivery.DeliveredAt = &d.DeliveredAt
+			}
+
+			deliveries = append(deliveries, delivery)
+		}
+
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Deliveries: deliveries,
+		})
+	}
+}
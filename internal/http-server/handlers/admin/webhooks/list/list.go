@@ -0,0 +1,68 @@
+package list
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Webhook is a subscription, without its secret.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=WebhookLister
+type WebhookLister interface {
+	ListWebhooks() ([]storage.Webhook, error)
+}
+
+// New returns a handler listing the webhook subscriptions.
+func New(log *slog.Logger, webhookLister WebhookLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.webhooks.list.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		list, err := webhookLister.ListWebhooks()
+		if err != nil {
+			log.Error("failed to list webhooks", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		webhooks := make([]Webhook, 0, len(list))
+		for _, wh := range list {
+			webhooks = append(webhooks, Webhook{
+				ID:        wh.ID,
+				URL:       wh.URL,
+				Events:    wh.Events,
+				CreatedAt: wh.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Webhooks: webhooks,
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package replay
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type DeadLetterReplayer interface {
+	ReplayDeadLetter(id int64, at time.Time) error
+}
+
+// New returns a handler queueing the dead letter named by the id path
+// parameter for delivery again, with a fresh set of attempts.
+func New(log *slog.Logger, replayer DeadLetterReplayer, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.webhooks.replay.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		if !ok {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		err := replayer.ReplayDeadLetter(id, time.Now())
+		if errors.Is(err, storage.ErrDeliveryNotFound) {
+			log.Info("dead letter not found", slog.Int64("id", id))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to replay dead letter", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("dead letter replayed", slog.Int64("id", id))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionDeadLetterReplay,
+			After:  map[string]int64{"dead_letter_id": id},
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
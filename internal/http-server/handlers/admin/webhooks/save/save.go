@@ -0,0 +1,146 @@
+package save
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Request struct {
+	URL string `json:"url" validate:"required,url"`
+	// Events are the events to send, such as link.created.
+	Events []string `json:"events"`
+	// Secret signs the deliveries; one is generated if empty.
+	Secret string `json:"secret,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	ID int64 `json:"id,omitempty"`
+	// Secret is only ever shown here.
+	Secret string `json:"secret,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=WebhookSaver
+type WebhookSaver interface {
+	SaveWebhook(w storage.Webhook) (int64, error)
+}
+
+// AuditRecorder appends changes to the audit log, attributed to the
+// request's user.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditRecorder
+type AuditRecorder interface {
+	Record(r *http.Request, e audit.Entry)
+}
+
+// New returns a handler subscribing a URL to link lifecycle events.
+func New(log *slog.Logger, webhookSaver WebhookSaver, auditRecorder AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.admin.webhooks.save.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		if err := webhook.Validate(req.Events); err != nil {
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		secret := req.Secret
+		if secret == "" {
+			var err error
+			if secret, err = newSecret(); err != nil {
+				log.Error("failed to generate secret", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("internal error"))
+
+				return
+			}
+		}
+
+		hook := storage.Webhook{
+			URL:    req.URL,
+			Secret: secret,
+			Events: req.Events,
+		}
+
+		id, err := webhookSaver.SaveWebhook(hook)
+		if err != nil {
+			log.Error("failed to add webhook", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to add webhook"))
+
+			return
+		}
+
+		log.Info("webhook added", slog.Int64("id", id), slog.String("url", req.URL))
+
+		hook.ID = id
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionWebhookCreate,
+			After:  audit.WebhookOf(hook),
+		})
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			ID:       id,
+			Secret:   secret,
+		})
+	}
+}
+
+// IDParam returns the id path parameter, or false if it isn't a number.
+func IDParam(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+
+	return id, err == nil && id > 0
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
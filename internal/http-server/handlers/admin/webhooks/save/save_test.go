@@ -0,0 +1,95 @@
+package save_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/save"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/admin/webhooks/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestSaveHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		saved     bool
+		secret    string
+		respError string
+	}{
+		{
+			name:   "Given secret",
+			body:   `{"url": "https://cms.example/hooks", "events": ["link.created", "link.deleted"], "secret": "s3cret"}`,
+			saved:  true,
+			secret: "s3cret",
+		},
+		{
+			name:  "Generated secret",
+			body:  `{"url": "https://cms.example/hooks", "events": ["link.first_click"]}`,
+			saved: true,
+		},
+		{
+			name:      "No events",
+			body:      `{"url": "https://cms.example/hooks"}`,
+			respError: "webhook needs at least one event",
+		},
+		{
+			name:      "Unknown event",
+			body:      `{"url": "https://cms.example/hooks", "events": ["link.renamed"]}`,
+			respError: `unknown event: "link.renamed"`,
+		},
+		{
+			name:      "Invalid URL",
+			body:      `{"url": "not a url", "events": ["link.created"]}`,
+			respError: "field URL is not a valid URL",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			webhookSaverMock := mocks.NewWebhookSaver(t)
+			if tc.saved {
+				webhookSaverMock.On("SaveWebhook", mock.MatchedBy(func(w storage.Webhook) bool {
+					return w.URL == "https://cms.example/hooks" && w.Secret != "" && (tc.secret == "" || w.Secret == tc.secret)
+				})).Return(int64(3), nil).Once()
+			}
+
+			auditRecorderMock := mocks.NewAuditRecorder(t)
+			if tc.saved {
+				// The secret stays out of the audit log.
+				auditRecorderMock.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Entry) bool {
+					after, ok := e.After.(audit.Webhook)
+					return e.Action == audit.ActionWebhookCreate && ok && after.ID == 3 && after.URL == "https://cms.example/hooks"
+				})).Once()
+			}
+
+			rr := httptest.NewRecorder()
+			save.New(slogdiscard.NewDiscardLogger(), webhookSaverMock, auditRecorderMock).
+				ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/webhooks", strings.NewReader(tc.body)))
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			if !tc.saved {
+				return
+			}
+
+			assert.Equal(t, int64(3), resp.ID)
+			if tc.secret != "" {
+				assert.Equal(t, tc.secret, resp.Secret)
+			} else {
+				assert.Len(t, resp.Secret, 64)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package delete
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CollectionDeleter
+type CollectionDeleter interface {
+	GetCollection(id int64) (storage.Collection, error)
+	DeleteCollection(id int64) error
+}
+
+// New returns a handler removing a collection and its grants. The links in
+// it stay. Only its owner and the admin may.
+func New(log *slog.Logger, collectionDeleter CollectionDeleter, accessChecker save.AccessChecker, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.delete.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		if !ok {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		if !save.Authorize(log, w, r, accessChecker, id, storage.RoleOwner) {
+			return
+		}
+
+		// The collection is read first so the audit log keeps what was
+		// deleted.
+		c, err := collectionDeleter.GetCollection(id)
+		if err == nil {
+			err = collectionDeleter.DeleteCollection(id)
+		}
+		if errors.Is(err, storage.ErrCollectionNotFound) {
+			log.Info("collection not found", slog.Int64("id", id))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete collection", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("collection deleted", slog.Int64("id", id))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionCollectionDelete,
+			Before: audit.CollectionOf(c),
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,90 @@
+package add
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	linkSave "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkAdder
+type LinkAdder interface {
+	AddToCollection(id int64, domain string, alias string) error
+}
+
+// New returns a handler putting a link in a collection, sharing it with
+// everyone the collection is shared with. The user needs edit rights on
+// the collection and must own the link, unless they are the admin.
+// ?domain selects the short domain the alias is on; the default domain
+// otherwise.
+func New(
+	log *slog.Logger,
+	linkAdder LinkAdder,
+	accessChecker save.AccessChecker,
+	linkAccessChecker linkSave.AccessChecker,
+	auditRecorder save.AuditRecorder,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.links.add.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		alias := chi.URLParam(r, "alias")
+		if !ok || alias == "" {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+
+		if !save.Authorize(log, w, r, accessChecker, id, storage.RoleEdit) {
+			return
+		}
+		if !linkSave.Authorize(log, w, r, linkAccessChecker, host, alias, storage.RoleOwner) {
+			return
+		}
+
+		err := linkAdder.AddToCollection(id, host, alias)
+		if errors.Is(err, storage.ErrCollectionNotFound) || errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("collection or url not found", slog.Int64("id", id), slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to add url to collection", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("url added to collection", slog.Int64("id", id), slog.String("alias", alias))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionCollectionAdd,
+			Domain: host,
+			Alias:  alias,
+			After:  audit.Membership{CollectionID: id},
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
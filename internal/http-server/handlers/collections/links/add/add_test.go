@@ -0,0 +1,116 @@
+package add_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/links/add"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/links/add/mocks"
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save/mocks"
+	linkSaveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestAddHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		path          string
+		collectionErr error
+		linkErr       error
+		addErr        error
+		respError     string
+	}{
+		{
+			name: "Added",
+			path: "/collections/7/links/promo",
+		},
+		{
+			name:      "Bad id",
+			path:      "/collections/seven/links/promo",
+			respError: "invalid request",
+		},
+		{
+			name:          "Collection not shared for editing",
+			path:          "/collections/7/links/promo",
+			collectionErr: access.ErrDenied,
+			respError:     "access denied",
+		},
+		{
+			name:          "Unknown collection",
+			path:          "/collections/7/links/promo",
+			collectionErr: storage.ErrCollectionNotFound,
+			respError:     "not found",
+		},
+		{
+			name:      "Link owned by someone else",
+			path:      "/collections/7/links/promo",
+			linkErr:   access.ErrDenied,
+			respError: "access denied",
+		},
+		{
+			name:      "Link deleted meanwhile",
+			path:      "/collections/7/links/promo",
+			addErr:    storage.ErrURLNotFound,
+			respError: "not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			valid := tc.respError != "invalid request"
+
+			accessCheckerMock := saveMocks.NewAccessChecker(t)
+			linkAccessCheckerMock := linkSaveMocks.NewAccessChecker(t)
+			linkAdderMock := mocks.NewLinkAdder(t)
+			auditRecorderMock := saveMocks.NewAuditRecorder(t)
+
+			if valid {
+				accessCheckerMock.On("CheckCollection", "bob", int64(7), storage.RoleEdit).Return(tc.collectionErr).Once()
+			}
+			if valid && tc.collectionErr == nil {
+				linkAccessCheckerMock.On("CheckLink", "bob", "go.example", "promo", storage.RoleOwner).Return(tc.linkErr).Once()
+			}
+			if valid && tc.collectionErr == nil && tc.linkErr == nil {
+				linkAdderMock.On("AddToCollection", int64(7), "go.example", "promo").Return(tc.addErr).Once()
+			}
+			if tc.respError == "" {
+				auditRecorderMock.On("Record", mock.Anything, audit.Entry{
+					Action: audit.ActionCollectionAdd,
+					Domain: "go.example",
+					Alias:  "promo",
+					After:  audit.Membership{CollectionID: 7},
+				}).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/collections/{id}/links/{alias}", add.New(
+				slogdiscard.NewDiscardLogger(),
+				linkAdderMock,
+				accessCheckerMock,
+				linkAccessCheckerMock,
+				auditRecorderMock,
+			))
+
+			req := httptest.NewRequest(http.MethodPut, tc.path+"?domain=go.example", nil)
+			req.SetBasicAuth("bob", "secret")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var res resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			assert.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package list
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	linkList "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/list"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// New returns a handler listing the live links in a collection, the newest
+// first, to those it is shared with. ?limit caps a page at up to 1000
+// links, 100 by default, and ?before takes the next_before of the previous
+// page.
+func New(log *slog.Logger, linkLister linkList.LinkLister, accessChecker save.AccessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.links.list.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		if !ok {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		query := r.URL.Query()
+		filter := storage.LinkFilter{Collection: id}
+
+		limit := defaultLimit
+		if raw := query.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		if raw := query.Get("before"); raw != "" {
+			before, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || before < 1 {
+				render.JSON(w, r, resp.Error("invalid before"))
+
+				return
+			}
+			filter.BeforeID = before
+		}
+
+		if !save.Authorize(log, w, r, accessChecker, id, storage.RoleRead) {
+			return
+		}
+
+		list, err := linkLister.ListLinks(filter, limit)
+		if err != nil {
+			log.Error("failed to list links", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		res := linkList.Response{Response: resp.OK(), Links: make([]linkList.Link, 0, len(list))}
+		for _, link := range list {
+			res.Links = append(res.Links, linkList.NewLink(link))
+		}
+		if len(list) == limit {
+			res.NextBefore = list[len(list)-1].ID
+		}
+
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,79 @@
+package remove
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkRemover
+type LinkRemover interface {
+	RemoveFromCollection(id int64, domain string, alias string) error
+}
+
+// New returns a handler taking a link out of a collection, which stops
+// sharing it. The link itself stays. The user needs edit rights on the
+// collection. ?domain selects the short domain the alias is on; the
+// default domain otherwise.
+func New(log *slog.Logger, linkRemover LinkRemover, accessChecker save.AccessChecker, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.links.remove.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		alias := chi.URLParam(r, "alias")
+		if !ok || alias == "" {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+
+		if !save.Authorize(log, w, r, accessChecker, id, storage.RoleEdit) {
+			return
+		}
+
+		err := linkRemover.RemoveFromCollection(id, host, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not in collection", slog.Int64("id", id), slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to remove url from collection", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("url removed from collection", slog.Int64("id", id), slog.String("alias", alias))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionCollectionRemove,
+			Domain: host,
+			Alias:  alias,
+			Before: audit.Membership{CollectionID: id},
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,63 @@
+package list
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Collections []save.Collection `json:"collections"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CollectionLister
+type CollectionLister interface {
+	ListCollections(user string) ([]storage.Collection, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AdminChecker
+type AdminChecker interface {
+	IsAdmin(user string) bool
+}
+
+// New returns a handler listing the collections the request's user owns
+// or was granted rights on, ordered by name. The admin sees them all.
+func New(log *slog.Logger, collectionLister CollectionLister, adminChecker AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.list.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, _, _ := r.BasicAuth()
+		if adminChecker.IsAdmin(user) {
+			user = ""
+		}
+
+		list, err := collectionLister.ListCollections(user)
+		if err != nil {
+			log.Error("failed to list collections", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		res := Response{Response: resp.OK(), Collections: make([]save.Collection, 0, len(list))}
+		for _, c := range list {
+			res.Collections = append(res.Collections, save.NewCollection(c))
+		}
+
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,201 @@
+package save
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Grant gives User read or edit rights on a collection.
+type Grant struct {
+	User string `json:"user"`
+	Role string `json:"role"`
+}
+
+type Request struct {
+	Name   string  `json:"name"`
+	Grants []Grant `json:"grants,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	ID int64 `json:"id,omitempty"`
+}
+
+// Collection is the JSON form of a collection.
+type Collection struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner"`
+	Grants    []Grant   `json:"grants"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewCollection returns the JSON form of c.
+func NewCollection(c storage.Collection) Collection {
+	res := Collection{
+		ID:        c.ID,
+		Name:      c.Name,
+		Owner:     c.Owner,
+		Grants:    make([]Grant, 0, len(c.Grants)),
+		CreatedAt: c.CreatedAt,
+	}
+	for _, g := range c.Grants {
+		res.Grants = append(res.Grants, Grant{User: g.User, Role: g.Role})
+	}
+
+	return res
+}
+
+// Grants checks grants and returns them for storage.
+func Grants(grants []Grant) ([]storage.Grant, error) {
+	list := make([]storage.Grant, 0, len(grants))
+	for _, g := range grants {
+		list = append(list, storage.Grant{User: g.User, Role: g.Role})
+	}
+
+	if err := access.ValidateGrants(list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CollectionSaver
+type CollectionSaver interface {
+	SaveCollection(c storage.Collection) (int64, error)
+}
+
+// AuditRecorder appends changes to the audit log, attributed to the
+// request's user.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditRecorder
+type AuditRecorder interface {
+	Record(r *http.Request, e audit.Entry)
+}
+
+// AccessChecker returns access.ErrDenied unless user has at least role on
+// the collection with id.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AccessChecker
+type AccessChecker interface {
+	CheckCollection(user string, id int64, role string) error
+}
+
+// New returns a handler creating a collection owned by the request's user.
+// Grants share it with other users, who may read or edit its links.
+func New(log *slog.Logger, collectionSaver CollectionSaver, auditRecorder AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.save.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		name, err := access.Name(req.Name)
+		if err != nil {
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		grants, err := Grants(req.Grants)
+		if err != nil {
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		owner, _, _ := r.BasicAuth()
+		c := storage.Collection{Name: name, Owner: owner, Grants: grants}
+
+		id, err := collectionSaver.SaveCollection(c)
+		if errors.Is(err, storage.ErrCollectionExists) {
+			log.Info("collection already exists", slog.String("name", name))
+
+			render.JSON(w, r, resp.Error("collection already exists"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to add collection", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to add collection"))
+
+			return
+		}
+
+		log.Info("collection added", slog.Int64("id", id), slog.String("name", name))
+
+		c.ID = id
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionCollectionCreate,
+			After:  audit.CollectionOf(c),
+		})
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			ID:       id,
+		})
+	}
+}
+
+// IDParam returns the id path parameter, or false if it isn't a number.
+func IDParam(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+
+	return id, err == nil && id > 0
+}
+
+// Authorize checks that the user of r has at least role on the collection
+// with id. If not, it answers r itself and returns false.
+func Authorize(log *slog.Logger, w http.ResponseWriter, r *http.Request, accessChecker AccessChecker, id int64, role string) bool {
+	user, _, _ := r.BasicAuth()
+
+	err := accessChecker.CheckCollection(user, id, role)
+	if errors.Is(err, access.ErrDenied) {
+		log.Info("access denied", slog.String("user", user), slog.Int64("id", id))
+
+		render.JSON(w, r, resp.Error("access denied"))
+
+		return false
+	}
+	if errors.Is(err, storage.ErrCollectionNotFound) {
+		log.Info("collection not found", slog.Int64("id", id))
+
+		render.JSON(w, r, resp.Error("not found"))
+
+		return false
+	}
+	if err != nil {
+		log.Error("failed to check access", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("internal error"))
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	linkStats "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/stats"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	ID         int64                     `json:"id"`
+	Links      int64                     `json:"links"`
+	Clicks     int64                     `json:"clicks"`
+	ByLocation []linkStats.LocationStats `json:"by_location"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CollectionStatsGetter
+type CollectionStatsGetter interface {
+	GetCollectionStats(id int64) (storage.CollectionStats, error)
+	GetCollectionGeoClicks(id int64) ([]storage.GeoClicks, error)
+}
+
+// New returns a handler adding up the clicks on the links in a collection,
+// in total and by the visitor's location, for those it is shared with.
+// Links in the trash are left out.
+func New(log *slog.Logger, collectionStatsGetter CollectionStatsGetter, accessChecker save.AccessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.stats.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		if !ok {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		if !save.Authorize(log, w, r, accessChecker, id, storage.RoleRead) {
+			return
+		}
+
+		st, err := collectionStatsGetter.GetCollectionStats(id)
+		if err != nil {
+			log.Error("failed to get collection stats", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		geoClicks, err := collectionStatsGetter.GetCollectionGeoClicks(id)
+		if err != nil {
+			log.Error("failed to get geo clicks", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		byLocation := make([]linkStats.LocationStats, 0, len(geoClicks))
+		for _, c := range geoClicks {
+			byLocation = append(byLocation, linkStats.LocationStats{Country: c.Country, Region: c.Region, Clicks: c.Clicks})
+		}
+
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			ID:         id,
+			Links:      st.Links,
+			Clicks:     st.Clicks,
+			ByLocation: byLocation,
+		})
+	}
+}
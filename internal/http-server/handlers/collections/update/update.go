@@ -0,0 +1,128 @@
+package update
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Request renames a collection or replaces its grants. Fields left out
+// keep their value.
+type Request struct {
+	Name   *string       `json:"name"`
+	Grants *[]save.Grant `json:"grants"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CollectionUpdater
+type CollectionUpdater interface {
+	GetCollection(id int64) (storage.Collection, error)
+	UpdateCollection(c storage.Collection) error
+}
+
+// New returns a handler renaming a collection and changing whom it is
+// shared with. Only its owner and the admin may.
+func New(log *slog.Logger, collectionUpdater CollectionUpdater, accessChecker save.AccessChecker, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.collections.update.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := save.IDParam(r)
+		if !ok {
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if !save.Authorize(log, w, r, accessChecker, id, storage.RoleOwner) {
+			return
+		}
+
+		before, err := collectionUpdater.GetCollection(id)
+		if errors.Is(err, storage.ErrCollectionNotFound) {
+			log.Info("collection not found", slog.Int64("id", id))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get collection", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		c := before
+		if req.Name != nil {
+			if c.Name, err = access.Name(*req.Name); err != nil {
+				render.JSON(w, r, resp.Error(err.Error()))
+
+				return
+			}
+		}
+		if req.Grants != nil {
+			if c.Grants, err = save.Grants(*req.Grants); err != nil {
+				render.JSON(w, r, resp.Error(err.Error()))
+
+				return
+			}
+		}
+
+		err = collectionUpdater.UpdateCollection(c)
+		if errors.Is(err, storage.ErrCollectionNotFound) {
+			log.Info("collection not found", slog.Int64("id", id))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrCollectionExists) {
+			log.Info("collection already exists", slog.String("name", c.Name))
+
+			render.JSON(w, r, resp.Error("collection already exists"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to update collection", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("collection updated", slog.Int64("id", id))
+
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionCollectionUpdate,
+			Before: audit.CollectionOf(before),
+			After:  audit.CollectionOf(c),
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,122 @@
+package update_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/update"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/collections/update/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	current := storage.Collection{
+		ID:     7,
+		Name:   "Q3 launch",
+		Owner:  "alice",
+		Grants: []storage.Grant{{User: "bob", Role: storage.RoleRead}},
+	}
+
+	cases := []struct {
+		name      string
+		body      string
+		accessErr error
+		updateErr error
+		want      *storage.Collection
+		respError string
+	}{
+		{
+			name: "Grants replaced, name kept",
+			body: `{"grants": [{"user": "bob", "role": "edit"}, {"user": "carol", "role": "read"}]}`,
+			want: &storage.Collection{
+				ID:     7,
+				Name:   "Q3 launch",
+				Owner:  "alice",
+				Grants: []storage.Grant{{User: "bob", Role: storage.RoleEdit}, {User: "carol", Role: storage.RoleRead}},
+			},
+		},
+		{
+			name: "Renamed",
+			body: `{"name": " Q3 launch (EU) "}`,
+			want: &storage.Collection{
+				ID:     7,
+				Name:   "Q3 launch (EU)",
+				Owner:  "alice",
+				Grants: []storage.Grant{{User: "bob", Role: storage.RoleRead}},
+			},
+		},
+		{
+			name:      "Name taken",
+			body:      `{"name": "Q4 launch"}`,
+			updateErr: storage.ErrCollectionExists,
+			want: &storage.Collection{
+				ID:     7,
+				Name:   "Q4 launch",
+				Owner:  "alice",
+				Grants: []storage.Grant{{User: "bob", Role: storage.RoleRead}},
+			},
+			respError: "collection already exists",
+		},
+		{
+			name:      "Owner role can't be granted",
+			body:      `{"grants": [{"user": "bob", "role": "owner"}]}`,
+			respError: access.ErrInvalidRole.Error(),
+		},
+		{
+			name:      "Only shared for editing",
+			body:      `{"name": "Mine now"}`,
+			accessErr: access.ErrDenied,
+			respError: "access denied",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			accessCheckerMock := saveMocks.NewAccessChecker(t)
+			accessCheckerMock.On("CheckCollection", "alice", int64(7), storage.RoleOwner).Return(tc.accessErr).Once()
+
+			collectionUpdaterMock := mocks.NewCollectionUpdater(t)
+			if tc.accessErr == nil {
+				collectionUpdaterMock.On("GetCollection", int64(7)).Return(current, nil).Once()
+			}
+
+			auditRecorderMock := saveMocks.NewAuditRecorder(t)
+			if tc.want != nil {
+				collectionUpdaterMock.On("UpdateCollection", *tc.want).Return(tc.updateErr).Once()
+			}
+			if tc.respError == "" {
+				auditRecorderMock.On("Record", mock.Anything, audit.Entry{
+					Action: audit.ActionCollectionUpdate,
+					Before: audit.CollectionOf(current),
+					After:  audit.CollectionOf(*tc.want),
+				}).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/collections/{id}", update.New(slogdiscard.NewDiscardLogger(), collectionUpdaterMock, accessCheckerMock, auditRecorderMock))
+
+			req := httptest.NewRequest(http.MethodPatch, "/collections/7", strings.NewReader(tc.body))
+			req.SetBasicAuth("alice", "secret")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var res resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			assert.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
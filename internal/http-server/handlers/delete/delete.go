@@ -9,8 +9,12 @@ import (
 	"github.com/go-chi/render"
 	"golang.org/x/exp/slog"
 
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
 	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
 	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
 	"github.com/MaximShildyakov/url-shortener/internal/storage"
 )
 
@@ -18,10 +22,32 @@ import (
 //
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
 type URLDeleter interface {
-	DeleteURL(alias string) error
+	GetLink(domain string, alias string) (storage.Link, error)
+	DeleteURL(domain string, alias string, actor string) error
 }
 
-func New(log *slog.Logger, urlDeleter URLDeleter) http.HandlerFunc {
+// EventPublisher announces link lifecycle events to webhooks. Publish must
+// not block.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=EventPublisher
+type EventPublisher interface {
+	Publish(event string, link storage.Link)
+}
+
+// AuditRecorder appends changes to the audit log, attributed to the
+// request's user.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditRecorder
+type AuditRecorder interface {
+	Record(r *http.Request, e audit.Entry)
+}
+
+// New returns a handler moving the link behind an alias to the trash, where
+// it keeps its alias until it is restored or purged. ?domain selects the
+// short domain the alias is on; the default domain otherwise.
+//
+// Users other than the admin need edit rights on the link.
+func New(log *slog.Logger, urlDeleter URLDeleter, accessChecker save.AccessChecker, eventPublisher EventPublisher, auditRecorder AuditRecorder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "handlers.url.delete.New"
 
@@ -39,7 +65,18 @@ func New(log *slog.Logger, urlDeleter URLDeleter) http.HandlerFunc {
 			return
 		}
 
-		err := urlDeleter.DeleteURL(alias)
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+		actor, _, _ := r.BasicAuth()
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleEdit) {
+			return
+		}
+
+		// The link is read first so the audit log keeps what was deleted.
+		link, err := urlDeleter.GetLink(host, alias)
+		if err == nil {
+			err = urlDeleter.DeleteURL(host, alias, actor)
+		}
 		if errors.Is(err, storage.ErrURLNotFound) {
 			log.Info("url not found", "alias", alias)
 
@@ -57,6 +94,14 @@ func New(log *slog.Logger, urlDeleter URLDeleter) http.HandlerFunc {
 
 		log.Info("url deleted", slog.String("url", alias))
 
+		eventPublisher.Publish(webhook.EventDeleted, storage.Link{Domain: host, Alias: alias})
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionLinkDelete,
+			Domain: host,
+			Alias:  alias,
+			Before: audit.LinkOf(link),
+		})
+
 		render.JSON(w, r, resp.OK())
 		
 	}
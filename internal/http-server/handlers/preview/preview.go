@@ -0,0 +1,211 @@
+package preview
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Flag is a safety concern about one of the link's destinations.
+type Flag struct {
+	// Field names the destination like save does, e.g. "URL" or
+	// "Rules[0].URL".
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Button is a button on a page link.
+type Button struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Preview describes a link without following it. Page links have buttons
+// instead of a URL.
+type Preview struct {
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url"`
+	Buttons   []Button   `json:"buttons,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Clicks    int64      `json:"clicks"`
+	Safe      bool       `json:"safe"`
+	Flags     []Flag     `json:"flags,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Preview
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkGetter
+type LinkGetter interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+}
+
+// DomainResolver maps the request host to the short domain serving it.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DomainResolver
+type DomainResolver interface {
+	Resolve(host string) storage.Domain
+}
+
+// URLChecker vets destinations, returning a *policy.Violation for ones that
+// shouldn't be visited.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLChecker
+type URLChecker interface {
+	CheckURL(rawURL string) error
+}
+
+//go:embed preview.html
+var previewHTML string
+
+var page = template.Must(template.New("preview").Parse(previewHTML))
+
+// New returns a handler describing the link behind an alias instead of
+// redirecting. It serves /{alias}+ as an HTML page, and as JSON for
+// /{alias}+.json or requests that accept only JSON. Every destination the
+// link may send visitors to is checked, targeting rules, variants and page
+// buttons included.
+func New(log *slog.Logger, linkGetter LinkGetter, urlChecker URLChecker, domainResolver DomainResolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.preview.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		link, err := linkGetter.GetLink(domainResolver.Resolve(r.Host).Host, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get link", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		preview := Preview{
+			Alias:  link.Alias,
+			URL:    link.URL,
+			Clicks: link.Clicks,
+			Safe:   true,
+		}
+		if !link.CreatedAt.IsZero() {
+			preview.CreatedAt = &link.CreatedAt
+		}
+		if link.Page != nil {
+			for _, b := range link.Page.Buttons {
+				preview.Buttons = append(preview.Buttons, Button{Label: b.Label, URL: b.URL})
+			}
+		}
+
+		for _, d := range destinations(link) {
+			err := urlChecker.CheckURL(d.url)
+			if err == nil {
+				continue
+			}
+
+			var violation *policy.Violation
+			if !errors.As(err, &violation) {
+				log.Error("failed to check url", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("internal error"))
+
+				return
+			}
+
+			preview.Safe = false
+			preview.Flags = append(preview.Flags, Flag{Field: d.field, Rule: violation.Rule, Message: violation.Message})
+		}
+
+		w.Header().Set("Cache-Control", "no-cache")
+
+		if wantsJSON(r) {
+			render.JSON(w, r, Response{
+				Response: resp.OK(),
+				Preview:  preview,
+			})
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+
+		if err := page.Execute(w, preview); err != nil {
+			log.Error("failed to render preview", sl.Err(err))
+		}
+	}
+}
+
+// destination is a URL a link may send visitors to, in field.
+type destination struct {
+	field string
+	url   string
+}
+
+// destinations lists the buttons of page links, and the URL, rule and
+// variant destinations of the others.
+func destinations(link storage.Link) []destination {
+	var list []destination
+
+	if link.Page != nil {
+		for i, b := range link.Page.Buttons {
+			list = append(list, destination{fmt.Sprintf("Page.Buttons[%d].URL", i), b.URL})
+		}
+
+		return list
+	}
+
+	list = append(list, destination{"URL", link.URL})
+	for i, rule := range link.Rules {
+		list = append(list, destination{fmt.Sprintf("Rules[%d].URL", i), rule.URL})
+	}
+	for i, v := range link.Variants {
+		list = append(list, destination{fmt.Sprintf("Variants[%d].URL", i), v.URL})
+	}
+
+	return list
+}
+
+func wantsJSON(r *http.Request) bool {
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+		return format == "json"
+	}
+
+	accept := r.Header.Get("Accept")
+
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
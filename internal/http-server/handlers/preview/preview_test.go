@@ -0,0 +1,179 @@
+package preview_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/preview"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/preview/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func defaultDomain(t *testing.T) *mocks.DomainResolver {
+	domainResolverMock := mocks.NewDomainResolver(t)
+	domainResolverMock.On("Resolve", mock.AnythingOfType("string")).Return(storage.Domain{})
+
+	return domainResolverMock
+}
+
+func TestPreviewHandler(t *testing.T) {
+	created := time.Date(2024, 3, 9, 10, 0, 0, 0, time.UTC)
+	link := storage.Link{Alias: "abc", URL: "https://example.com/page", Clicks: 42, CreatedAt: created}
+	flagged := &policy.Violation{Rule: policy.RuleThreat, Message: "destination is flagged as malware"}
+
+	cases := []struct {
+		name     string
+		path     string
+		accept   string
+		checkErr error
+		json     bool
+		contains []string
+	}{
+		{
+			name:     "HTML",
+			path:     "/abc+",
+			contains: []string{"https://example.com/page", "9 March 2024", "<dd>42</dd>", "No problems found.", `href="/abc"`},
+		},
+		{
+			name:     "HTML with flags",
+			path:     "/abc+",
+			checkErr: flagged,
+			contains: []string{"destination is flagged as malware"},
+		},
+		{
+			name: "JSON by extension",
+			path: "/abc+.json",
+			json: true,
+		},
+		{
+			name:   "JSON by Accept",
+			path:   "/abc+",
+			accept: "application/json",
+			json:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			linkGetterMock := mocks.NewLinkGetter(t)
+			linkGetterMock.On("GetLink", "", "abc").Return(link, nil).Once()
+
+			urlCheckerMock := mocks.NewURLChecker(t)
+			urlCheckerMock.On("CheckURL", link.URL).Return(tc.checkErr).Once()
+
+			r := chi.NewRouter()
+			r.Use(middleware.URLFormat)
+			r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {
+				t.Error("preview must not redirect")
+			})
+			r.Get("/{alias}+", preview.New(slogdiscard.NewDiscardLogger(), linkGetterMock, urlCheckerMock, defaultDomain(t)))
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.Empty(t, rr.Header().Get("Location"))
+
+			if !tc.json {
+				assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+				for _, s := range tc.contains {
+					assert.Contains(t, rr.Body.String(), s)
+				}
+
+				return
+			}
+
+			var resp preview.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, "OK", resp.Status)
+			assert.Equal(t, "https://example.com/page", resp.URL)
+			assert.Equal(t, int64(42), resp.Clicks)
+			assert.True(t, resp.Safe)
+			require.NotNil(t, resp.CreatedAt)
+			assert.True(t, created.Equal(*resp.CreatedAt))
+		})
+	}
+}
+
+func getPreview(t *testing.T, link storage.Link, urlChecker *mocks.URLChecker) preview.Response {
+	t.Helper()
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", link.Alias).Return(link, nil).Once()
+
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Get("/{alias}+", preview.New(slogdiscard.NewDiscardLogger(), linkGetterMock, urlChecker, defaultDomain(t)))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+link.Alias+"+.json", nil))
+
+	var resp preview.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "OK", resp.Status)
+
+	return resp
+}
+
+func TestPreviewHandler_PageLink(t *testing.T) {
+	link := storage.Link{Alias: "me", Page: &storage.Page{
+		Title: "Me",
+		Buttons: []storage.Button{
+			{Label: "Blog", URL: "https://blog.example"},
+			{Label: "Shop", URL: "https://shop.example"},
+		},
+	}}
+
+	// The empty URL of a page link is not a destination.
+	urlCheckerMock := mocks.NewURLChecker(t)
+	urlCheckerMock.On("CheckURL", "https://blog.example").Return(nil).Once()
+	urlCheckerMock.On("CheckURL", "https://shop.example").Return(nil).Once()
+
+	resp := getPreview(t, link, urlCheckerMock)
+
+	assert.True(t, resp.Safe)
+	assert.Empty(t, resp.Flags)
+	assert.Equal(t, []preview.Button{
+		{Label: "Blog", URL: "https://blog.example"},
+		{Label: "Shop", URL: "https://shop.example"},
+	}, resp.Buttons)
+}
+
+func TestPreviewHandler_Targets(t *testing.T) {
+	link := storage.Link{
+		Alias:    "promo",
+		URL:      "https://example.com/promo",
+		Rules:    []storage.Rule{{Name: "ios", URL: "https://apps.example/promo"}},
+		Variants: []storage.Variant{{Name: "b", URL: "https://bad.example/b", Weight: 1}},
+	}
+
+	urlCheckerMock := mocks.NewURLChecker(t)
+	urlCheckerMock.On("CheckURL", "https://example.com/promo").Return(nil).Once()
+	urlCheckerMock.On("CheckURL", "https://apps.example/promo").Return(nil).Once()
+	urlCheckerMock.On("CheckURL", "https://bad.example/b").
+		Return(&policy.Violation{Rule: policy.RuleThreat, Message: "destination is flagged as malware"}).Once()
+
+	resp := getPreview(t, link, urlCheckerMock)
+
+	assert.False(t, resp.Safe)
+	assert.Equal(t, []preview.Flag{
+		{Field: "Variants[0].URL", Rule: policy.RuleThreat, Message: "destination is flagged as malware"},
+	}, resp.Flags)
+}
@@ -9,23 +9,23 @@ type URLGetter struct {
 	mock.Mock
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *URLGetter) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// GetURL provides a mock function with given fields: domain, alias
+func (_m *URLGetter) GetURL(domain string, alias string) (string, error) {
+	ret := _m.Called(domain, alias)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string) (string, error)); ok {
+		return rf(domain, alias)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(domain, alias)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(domain, alias)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -46,4 +46,4 @@ func NewURLGetter(t mockConstructorTestingTNewURLGetter) *URLGetter {
 	t.Cleanup(func() { mock.AssertExpectations(t) })
 
 	return mock
-}
\ No newline at end of file
+}
@@ -0,0 +1,200 @@
+package qr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/qr"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// URLGetter is an interface for getting url by domain and alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+type URLGetter interface {
+	GetURL(domain string, alias string) (string, error)
+}
+
+// DomainResolver maps the request host to the short domain serving it.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DomainResolver
+type DomainResolver interface {
+	Resolve(host string) storage.Domain
+}
+
+// New returns a handler drawing a QR code of the short URL for an alias.
+//
+// The code is a PNG unless ?format=svg is given or the path ends in .svg.
+// ?size (pixels), ?margin (modules), ?level (L, M, Q or H) and ?fg and ?bg
+// (hex colors) adjust the drawing. baseURL is the public address short URLs
+// on the default domain start with; when empty it is taken from the
+// request. Links on other short domains use that domain with the scheme of
+// baseURL.
+func New(log *slog.Logger, urlGetter URLGetter, domainResolver DomainResolver, baseURL string) http.HandlerFunc {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.qr.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		opts, err := parseOptions(r)
+		if err != nil {
+			log.Info("invalid qr options", sl.Err(err))
+
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		domain := domainResolver.Resolve(r.Host)
+
+		_, err = urlGetter.GetURL(domain.Host, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		shortURL := shortURL(r, baseURL, domain.Host, alias)
+
+		// The image depends only on the short URL and the options.
+		sum := sha256.Sum256([]byte(shortURL + "|" + opts.Key()))
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+		// The alias may be deleted or taken over later, so clients revalidate
+		// every time and only skip the download on a matching ETag.
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := qr.Encode(&buf, shortURL, opts); err != nil {
+			log.Error("failed to encode qr code", sl.Err(err))
+
+			w.Header().Del("ETag")
+			w.Header().Del("Cache-Control")
+			render.JSON(w, r, resp.Error("failed to draw qr code"))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", opts.ContentType())
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			log.Error("failed to write qr code", sl.Err(err))
+		}
+	}
+}
+
+func parseOptions(r *http.Request) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+	query := r.URL.Query()
+
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+		opts.Format = format
+	}
+	if format := query.Get("format"); format != "" {
+		opts.Format = strings.ToLower(format)
+	}
+
+	var err error
+	if size := query.Get("size"); size != "" {
+		if opts.Size, err = strconv.Atoi(size); err != nil {
+			return qr.Options{}, qr.ErrInvalidSize
+		}
+	}
+	if margin := query.Get("margin"); margin != "" {
+		if opts.Margin, err = strconv.Atoi(margin); err != nil {
+			return qr.Options{}, qr.ErrInvalidMargin
+		}
+	}
+	if level := query.Get("level"); level != "" {
+		opts.Level = strings.ToUpper(level)
+	}
+	if fg := query.Get("fg"); fg != "" {
+		if opts.Foreground, err = qr.ParseColor(fg); err != nil {
+			return qr.Options{}, err
+		}
+	}
+	if bg := query.Get("bg"); bg != "" {
+		if opts.Background, err = qr.ParseColor(bg); err != nil {
+			return qr.Options{}, err
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return qr.Options{}, err
+	}
+
+	return opts, nil
+}
+
+func shortURL(r *http.Request, baseURL string, domain string, alias string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	switch {
+	case domain != "":
+		if s, _, ok := strings.Cut(baseURL, "://"); ok {
+			scheme = s
+		}
+		baseURL = scheme + "://" + domain
+	case baseURL == "":
+		baseURL = scheme + "://" + r.Host
+	}
+
+	return baseURL + "/" + alias
+}
+
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
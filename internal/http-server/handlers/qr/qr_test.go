@@ -0,0 +1,151 @@
+package qr_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/qr"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/qr/mocks"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func newRouter(t *testing.T, urlGetter *mocks.URLGetter) http.Handler {
+	domainResolverMock := mocks.NewDomainResolver(t)
+	domainResolverMock.On("Resolve", "go.example").Return(storage.Domain{Host: "go.example"}).Maybe()
+	domainResolverMock.On("Resolve", mock.AnythingOfType("string")).Return(storage.Domain{}).Maybe()
+
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Get("/{alias}/qr", qr.New(slogdiscard.NewDiscardLogger(), urlGetter, domainResolverMock, "https://sho.rt/"))
+
+	return r
+}
+
+func TestQRHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		getErr      error
+		contentType string
+		respError   string
+	}{
+		{
+			name:        "PNG",
+			path:        "/abc/qr?size=128&level=h&fg=%23123456",
+			contentType: "image/png",
+		},
+		{
+			name:        "SVG by extension",
+			path:        "/abc/qr.svg",
+			contentType: "image/svg+xml",
+		},
+		{
+			name:        "SVG by parameter",
+			path:        "/abc/qr?format=svg&margin=0",
+			contentType: "image/svg+xml",
+		},
+		{
+			name:      "Not found",
+			path:      "/abc/qr",
+			getErr:    storage.ErrURLNotFound,
+			respError: "not found",
+		},
+		{
+			name:      "Invalid size",
+			path:      "/abc/qr?size=huge",
+			respError: "size must be between 64 and 2048",
+		},
+		{
+			name:      "Low contrast",
+			path:      "/abc/qr?fg=fff",
+			respError: "foreground and background colors are too similar to scan",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			if tc.contentType != "" || tc.getErr != nil {
+				urlGetterMock.On("GetURL", "", "abc").Return("https://example.com", tc.getErr).Once()
+			}
+
+			rr := httptest.NewRecorder()
+			newRouter(t, urlGetterMock).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			if tc.respError != "" {
+				var body resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				assert.Equal(t, tc.respError, body.Error)
+
+				return
+			}
+
+			assert.Equal(t, tc.contentType, rr.Header().Get("Content-Type"))
+			assert.NotEmpty(t, rr.Header().Get("ETag"))
+			assert.NotZero(t, rr.Body.Len())
+		})
+	}
+}
+
+func TestQRHandler_ETag(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", "abc").Return("https://example.com", nil)
+
+	router := newRouter(t, urlGetterMock)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc/qr.svg", nil))
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+	assert.Contains(t, rr.Body.String(), "<svg")
+
+	req := httptest.NewRequest(http.MethodGet, "/abc/qr?format=svg", nil)
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Zero(t, rr.Body.Len())
+
+	req = httptest.NewRequest(http.MethodGet, "/abc/qr?format=svg&size=512", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "<svg"))
+}
+
+func TestQRHandler_Domain(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", "abc").Return("https://example.com", nil).Once()
+	urlGetterMock.On("GetURL", "go.example", "abc").Return("https://example.org", nil).Once()
+
+	router := newRouter(t, urlGetterMock)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc/qr.svg", nil))
+	defaultETag := rr.Header().Get("ETag")
+
+	req := httptest.NewRequest(http.MethodGet, "/abc/qr.svg", nil)
+	req.Host = "go.example"
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// The same alias on another domain is a different short URL.
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, defaultETag, rr.Header().Get("ETag"))
+}
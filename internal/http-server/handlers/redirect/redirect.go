@@ -1,8 +1,13 @@
 package redirect
 
 import (
+	_ "embed"
 	"errors"
+	"html/template"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -10,18 +15,123 @@ import (
 	"golang.org/x/exp/slog"
 
 	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/clickstream"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/geo"
 	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/page"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/split"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/targeting"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
 	"github.com/MaximShildyakov/url-shortener/internal/storage"
 )
 
-// URLGetter is an interface for getting url by alias.
+// LinkGetter is an interface for getting a link by domain and alias.
 //
-//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
-type URLGetter interface {
-	GetURL(alias string) (string, error)
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkGetter
+type LinkGetter interface {
+	GetLink(domain string, alias string) (storage.Link, error)
 }
 
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+// ThreatChecker flags destinations that became unsafe after the link was
+// created.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ThreatChecker
+type ThreatChecker interface {
+	CheckURL(rawURL string) error
+}
+
+// ClickRecorder counts redirects, by the targeting rule and A/B variant
+// that chose the destination or by the page button clicked.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickRecorder
+type ClickRecorder interface {
+	RecordClick(domain string, alias string, click storage.Click) error
+}
+
+// DomainResolver maps the request host to the short domain serving it.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DomainResolver
+type DomainResolver interface {
+	Resolve(host string) storage.Domain
+}
+
+// MissRecorder counts requests for aliases that don't exist.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=MissRecorder
+type MissRecorder interface {
+	RecordMiss(domain string, alias string) error
+}
+
+// NotFoundResponder answers requests for aliases that don't exist.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=NotFoundResponder
+type NotFoundResponder interface {
+	Respond(w http.ResponseWriter, r *http.Request, domain storage.Domain, alias string) error
+}
+
+// VariantPicker assigns visitors to one of a link's A/B variants.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=VariantPicker
+type VariantPicker interface {
+	Pick(w http.ResponseWriter, r *http.Request, domain string, alias string, variants []storage.Variant) int
+}
+
+// GeoLocator maps client IPs to locations.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=GeoLocator
+type GeoLocator interface {
+	Lookup(remoteAddr string) geo.Location
+}
+
+// EventPublisher announces link lifecycle events to webhooks. Publish must
+// not block.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=EventPublisher
+type EventPublisher interface {
+	Publish(event string, link storage.Link)
+}
+
+// ClickPublisher streams clicks to live subscribers. Publish must not block.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickPublisher
+type ClickPublisher interface {
+	Publish(click clickstream.Click)
+}
+
+//go:embed interstitial.html
+var interstitialHTML string
+
+var interstitial = template.Must(template.New("interstitial").Parse(interstitialHTML))
+
+//go:embed page.html
+var pageHTML string
+
+var pageTemplate = template.Must(template.New("page").Parse(pageHTML))
+
+// New returns a handler redirecting to the URL behind an alias on the
+// domain of the request host. The first of the link's targeting rules the
+// visitor matches picks the destination; visitors no rule matches are split
+// between the link's variants, if it has any. The visitor is located by the
+// client IP, which middleware.RealIP takes from proxy headers. Flagged destinations get a warning
+// page instead of a silent redirect. Unknown aliases are counted and answered by
+// notFound. Every redirect is published to live click streams.
+//
+// Links that are pages are rendered instead of redirecting. Their buttons
+// link to /{alias}/b/{button}, served by the same handler, which redirects
+// to the button's URL and counts the click under the button.
+func New(
+	log *slog.Logger,
+	linkGetter LinkGetter,
+	threatChecker ThreatChecker,
+	clickRecorder ClickRecorder,
+	domainResolver DomainResolver,
+	missRecorder MissRecorder,
+	notFound NotFoundResponder,
+	variantPicker VariantPicker,
+	geoLocator GeoLocator,
+	eventPublisher EventPublisher,
+	clickPublisher ClickPublisher,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
@@ -39,11 +149,19 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 			return
 		}
 
-		resURL, err := urlGetter.GetURL(alias)
+		domain := domainResolver.Resolve(r.Host)
+
+		link, err := linkGetter.GetLink(domain.Host, alias)
 		if errors.Is(err, storage.ErrURLNotFound) {
-			log.Info("url not found", "alias", alias)
+			log.Info("url not found", "alias", alias, "domain", domain.Host)
 
-			render.JSON(w, r, resp.Error("not found"))
+			if err := missRecorder.RecordMiss(domain.Host, alias); err != nil {
+				log.Error("failed to record miss", sl.Err(err))
+			}
+
+			if err := notFound.Respond(w, r, domain, alias); err != nil {
+				log.Error("failed to render not found page", sl.Err(err))
+			}
 
 			return
 		}
@@ -55,9 +173,141 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 			return
 		}
 
-		log.Info("got url", slog.String("url", resURL))
+		button := chi.URLParam(r, "button")
+		if link.Page != nil && button == "" {
+			log.Info("showing page", "alias", alias)
+
+			renderPage(w, log, alias, *link.Page)
+
+			return
+		}
+
+		loc := geoLocator.Lookup(r.RemoteAddr)
+
+		visitor := targeting.VisitorFromRequest(r, time.Now())
+		visitor.Country, visitor.Region = loc.Country, loc.Region
+
+		resURL, click := link.URL, storage.Click{
+			Rule:    storage.DefaultRule,
+			Country: loc.Country,
+			Region:  loc.Region,
+		}
+		if button != "" {
+			i := -1
+			if link.Page != nil {
+				i = page.Find(*link.Page, button)
+			}
+			if i < 0 {
+				log.Info("button not found", "alias", alias, "button", button)
+
+				if err := notFound.Respond(w, r, domain, alias); err != nil {
+					log.Error("failed to render not found page", sl.Err(err))
+				}
+
+				return
+			}
+
+			resURL, click.Rule, click.Button = link.Page.Buttons[i].URL, "", button
+		} else if i := targeting.Match(link.Rules, visitor); i >= 0 {
+			resURL, click.Rule = link.Rules[i].URL, targeting.RuleName(i, link.Rules[i])
+		} else if len(link.Variants) > 0 {
+			if i := variantPicker.Pick(w, r, domain.Host, alias, link.Variants); i >= 0 {
+				resURL, click.Variant = link.Variants[i].URL, split.VariantName(i, link.Variants[i])
+			}
+		}
+
+		log.Info("got url",
+			slog.String("url", resURL),
+			slog.String("rule", click.Rule),
+			slog.String("variant", click.Variant),
+			slog.String("button", click.Button),
+			slog.String("country", click.Country),
+		)
+
+		if err := threatChecker.CheckURL(resURL); err != nil {
+			log.Warn("destination is flagged", slog.String("url", resURL), sl.Err(err))
+
+			renderInterstitial(w, log, alias, resURL, err.Error())
+
+			return
+		}
+
+		if err := clickRecorder.RecordClick(domain.Host, alias, click); err != nil {
+			// Losing a click is better than failing the redirect.
+			log.Error("failed to record click", sl.Err(err))
+		} else if link.Clicks == 0 {
+			eventPublisher.Publish(webhook.EventFirstClick, link)
+		}
+
+		clickPublisher.Publish(clickstream.Click{
+			Domain:  domain.Host,
+			Alias:   alias,
+			URL:     resURL,
+			Rule:    click.Rule,
+			Variant: click.Variant,
+			Button:  click.Button,
+			Country: click.Country,
+			Region:  click.Region,
+			Time:    time.Now().UTC(),
+		})
 
 		// redirect to found url
-		http.Redirect(w, r, resURL, http.StatusFound)
+		http.Redirect(w, r, resURL, domain.RedirectCode)
 	}
-}
\ No newline at end of file
+}
+
+func renderInterstitial(w http.ResponseWriter, log *slog.Logger, alias string, url string, reason string) {
+	reason = strings.TrimPrefix(reason, "destination ")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	err := interstitial.Execute(w, struct {
+		Alias  string
+		URL    string
+		Reason string
+	}{
+		Alias:  alias,
+		URL:    url,
+		Reason: reason,
+	})
+	if err != nil {
+		log.Error("failed to render interstitial", sl.Err(err))
+	}
+}
+
+// renderPage shows p, the page at alias, with every button linking to its
+// tracked redirect.
+func renderPage(w http.ResponseWriter, log *slog.Logger, alias string, p storage.Page) {
+	type button struct {
+		Label string
+		Href  string
+	}
+
+	buttons := make([]button, 0, len(p.Buttons))
+	for i, b := range p.Buttons {
+		buttons = append(buttons, button{
+			Label: b.Label,
+			Href:  "/" + url.PathEscape(alias) + "/b/" + url.PathEscape(page.ButtonName(i, b)),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	err := pageTemplate.Execute(w, struct {
+		Title     string
+		AvatarURL string
+		Theme     string
+		Buttons   []button
+	}{
+		Title:     p.Title,
+		AvatarURL: p.AvatarURL,
+		Theme:     page.Theme(p),
+		Buttons:   buttons,
+	})
+	if err != nil {
+		log.Error("failed to render page", sl.Err(err))
+	}
+}
@@ -1,19 +1,60 @@
 package redirect_test
 
 import (
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/redirect"
 	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/redirect/mocks"
 	"github.com/MaximShildyakov/url-shortener/internal/lib/api"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/clickstream"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/geo"
 	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/notfound"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
 )
 
+func defaultDomain(t *testing.T) *mocks.DomainResolver {
+	domainResolverMock := mocks.NewDomainResolver(t)
+	domainResolverMock.On("Resolve", mock.AnythingOfType("string")).
+		Return(storage.Domain{RedirectCode: http.StatusFound})
+
+	return domainResolverMock
+}
+
+// noGeo locates no visitor.
+func noGeo(t *testing.T) *mocks.GeoLocator {
+	geoLocatorMock := mocks.NewGeoLocator(t)
+	geoLocatorMock.On("Lookup", mock.AnythingOfType("string")).Return(geo.Location{}).Maybe()
+
+	return geoLocatorMock
+}
+
+// anyEvents accepts any event.
+func anyEvents(t *testing.T) *mocks.EventPublisher {
+	eventPublisherMock := mocks.NewEventPublisher(t)
+	eventPublisherMock.On("Publish", mock.Anything, mock.Anything).Maybe()
+
+	return eventPublisherMock
+}
+
+// anyClicks accepts any streamed click.
+func anyClicks(t *testing.T) *mocks.ClickPublisher {
+	clickPublisherMock := mocks.NewClickPublisher(t)
+	clickPublisherMock.On("Publish", mock.Anything).Maybe()
+
+	return clickPublisherMock
+}
+
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -31,15 +72,21 @@ func TestSaveHandler(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			urlGetterMock := mocks.NewURLGetter(t)
+			linkGetterMock := mocks.NewLinkGetter(t)
+			threatCheckerMock := mocks.NewThreatChecker(t)
+			clickRecorderMock := mocks.NewClickRecorder(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlGetterMock.On("GetURL", tc.alias).
-					Return(tc.url, tc.mockError).Once()
+				linkGetterMock.On("GetLink", "", tc.alias).
+					Return(storage.Link{Alias: tc.alias, URL: tc.url}, tc.mockError).Once()
+				threatCheckerMock.On("CheckURL", tc.url).
+					Return(nil).Once()
+				clickRecorderMock.On("RecordClick", "", tc.alias, storage.Click{Rule: storage.DefaultRule}).
+					Return(nil).Once()
 			}
 
 			r := chi.NewRouter()
-			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, clickRecorderMock, defaultDomain(t), mocks.NewMissRecorder(t), mocks.NewNotFoundResponder(t), mocks.NewVariantPicker(t), noGeo(t), anyEvents(t), anyClicks(t)))
 
 			ts := httptest.NewServer(r)
 			defer ts.Close()
@@ -51,4 +98,371 @@ func TestSaveHandler(t *testing.T) {
 			assert.Equal(t, tc.url, redirectedToURL)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestRedirectHandler_FlaggedDestination(t *testing.T) {
+	const (
+		alias = "flagged"
+		url   = "https://phish.example/login"
+	)
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", alias).Return(storage.Link{Alias: alias, URL: url}, nil).Once()
+
+	threatCheckerMock := mocks.NewThreatChecker(t)
+	threatCheckerMock.On("CheckURL", url).
+		Return(&policy.Violation{
+			Rule:    policy.RuleThreat,
+			Message: "destination is flagged as social_engineering",
+		}).
+		Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, mocks.NewClickRecorder(t), defaultDomain(t), mocks.NewMissRecorder(t), mocks.NewNotFoundResponder(t), mocks.NewVariantPicker(t), noGeo(t), anyEvents(t), anyClicks(t)))
+
+	req := httptest.NewRequest(http.MethodGet, "/"+alias, nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Location"))
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+
+	body := rr.Body.String()
+	assert.Contains(t, body, "This link may be harmful")
+	assert.Contains(t, body, "is flagged as social_engineering")
+	assert.Contains(t, body, `href="https://phish.example/login"`)
+}
+
+func TestRedirectHandler_Domains(t *testing.T) {
+	domainResolverMock := mocks.NewDomainResolver(t)
+	domainResolverMock.On("Resolve", "go.example:8080").
+		Return(storage.Domain{Host: "go.example", RedirectCode: http.StatusMovedPermanently})
+	domainResolverMock.On("Resolve", "promo.example").
+		Return(storage.Domain{Host: "promo.example", FallbackURL: "https://example.com/", RedirectCode: http.StatusFound})
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "go.example", "docs").
+		Return(storage.Link{Domain: "go.example", Alias: "docs", URL: "https://docs.example/"}, nil).
+		Once()
+	linkGetterMock.On("GetLink", "promo.example", "docs").Return(storage.Link{}, storage.ErrURLNotFound).Once()
+
+	threatCheckerMock := mocks.NewThreatChecker(t)
+	threatCheckerMock.On("CheckURL", "https://docs.example/").Return(nil).Once()
+
+	clickRecorderMock := mocks.NewClickRecorder(t)
+	clickRecorderMock.On("RecordClick", "go.example", "docs", storage.Click{Rule: storage.DefaultRule}).Return(nil).Once()
+
+	missRecorderMock := mocks.NewMissRecorder(t)
+	missRecorderMock.On("RecordMiss", "promo.example", "docs").Return(nil).Once()
+
+	notFound, err := notfound.New(notfound.StrategyPage, "", "")
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, clickRecorderMock, domainResolverMock, missRecorderMock, notFound, mocks.NewVariantPicker(t), noGeo(t), anyEvents(t), anyClicks(t)))
+
+	cases := []struct {
+		host     string
+		code     int
+		location string
+	}{
+		{host: "go.example:8080", code: http.StatusMovedPermanently, location: "https://docs.example/"},
+		{host: "promo.example", code: http.StatusFound, location: "https://example.com/"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.Host = tc.host
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.code, rr.Code, tc.host)
+		assert.Equal(t, tc.location, rr.Header().Get("Location"), tc.host)
+	}
+}
+
+func TestRedirectHandler_NotFound(t *testing.T) {
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", "missing").Return(storage.Link{}, storage.ErrURLNotFound).Once()
+
+	missRecorderMock := mocks.NewMissRecorder(t)
+	missRecorderMock.On("RecordMiss", "", "missing").Return(nil).Once()
+
+	notFoundMock := mocks.NewNotFoundResponder(t)
+	notFoundMock.On("Respond", mock.Anything, mock.Anything, storage.Domain{RedirectCode: http.StatusFound}, "missing").
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(http.ResponseWriter).WriteHeader(http.StatusNotFound)
+		}).
+		Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(
+		slogdiscard.NewDiscardLogger(),
+		linkGetterMock,
+		mocks.NewThreatChecker(t),
+		mocks.NewClickRecorder(t),
+		defaultDomain(t),
+		missRecorderMock,
+		notFoundMock,
+		mocks.NewVariantPicker(t),
+		mocks.NewGeoLocator(t),
+		mocks.NewEventPublisher(t),
+		mocks.NewClickPublisher(t),
+	))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRedirectHandler_Rules(t *testing.T) {
+	link := storage.Link{
+		Alias: "app",
+		URL:   "https://example.com/app",
+		Rules: []storage.Rule{
+			{Name: "ios", Platforms: []string{"ios"}, URL: "https://apps.apple.com/app"},
+			{Platforms: []string{"mobile"}, Languages: []string{"pt"}, URL: "https://example.com/pt/app"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		ua       string
+		language string
+		location string
+		rule     string
+	}{
+		{
+			name:     "First matching rule",
+			ua:       "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			language: "pt-BR",
+			location: "https://apps.apple.com/app",
+			rule:     "ios",
+		},
+		{
+			name:     "Unnamed rule",
+			ua:       "Mozilla/5.0 (Linux; Android 14; Pixel 8)",
+			language: "pt-BR,en;q=0.5",
+			location: "https://example.com/pt/app",
+			rule:     "rule-2",
+		},
+		{
+			name:     "Default",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			language: "pt-BR",
+			location: "https://example.com/app",
+			rule:     storage.DefaultRule,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			linkGetterMock := mocks.NewLinkGetter(t)
+			linkGetterMock.On("GetLink", "", link.Alias).Return(link, nil).Once()
+
+			threatCheckerMock := mocks.NewThreatChecker(t)
+			threatCheckerMock.On("CheckURL", tc.location).Return(nil).Once()
+
+			clickRecorderMock := mocks.NewClickRecorder(t)
+			clickRecorderMock.On("RecordClick", "", link.Alias, storage.Click{Rule: tc.rule}).Return(nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, clickRecorderMock, defaultDomain(t), mocks.NewMissRecorder(t), mocks.NewNotFoundResponder(t), mocks.NewVariantPicker(t), noGeo(t), anyEvents(t), anyClicks(t)))
+
+			req := httptest.NewRequest(http.MethodGet, "/"+link.Alias, nil)
+			req.Header.Set("User-Agent", tc.ua)
+			req.Header.Set("Accept-Language", tc.language)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.location, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandler_Variants(t *testing.T) {
+	link := storage.Link{
+		Alias: "promo",
+		URL:   "https://example.com/",
+		Rules: []storage.Rule{{Name: "ios", Platforms: []string{"ios"}, URL: "https://apps.apple.com/app"}},
+		Variants: []storage.Variant{
+			{Name: "a", URL: "https://example.com/a", Weight: 1},
+			{URL: "https://example.com/b", Weight: 1},
+		},
+	}
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", link.Alias).Return(link, nil).Twice()
+
+	threatCheckerMock := mocks.NewThreatChecker(t)
+	threatCheckerMock.On("CheckURL", mock.AnythingOfType("string")).Return(nil).Twice()
+
+	variantPickerMock := mocks.NewVariantPicker(t)
+	variantPickerMock.On("Pick", mock.Anything, mock.Anything, "", link.Alias, link.Variants).Return(1).Once()
+
+	clickRecorderMock := mocks.NewClickRecorder(t)
+	clickRecorderMock.On("RecordClick", "", link.Alias, storage.Click{Rule: storage.DefaultRule, Variant: "variant-2"}).Return(nil).Once()
+	clickRecorderMock.On("RecordClick", "", link.Alias, storage.Click{Rule: "ios"}).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, clickRecorderMock, defaultDomain(t), mocks.NewMissRecorder(t), mocks.NewNotFoundResponder(t), variantPickerMock, noGeo(t), anyEvents(t), anyClicks(t)))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/promo", nil))
+	assert.Equal(t, "https://example.com/b", rr.Header().Get("Location"))
+
+	// Targeting rules take precedence over the split.
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)")
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, "https://apps.apple.com/app", rr.Header().Get("Location"))
+}
+
+func TestRedirectHandler_Geo(t *testing.T) {
+	link := storage.Link{
+		Alias: "shop",
+		URL:   "https://example.com/",
+		Rules: []storage.Rule{{Name: "dach", Countries: []string{"DE", "AT", "CH"}, URL: "https://example.de/"}},
+	}
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", link.Alias).Return(link, nil).Once()
+
+	threatCheckerMock := mocks.NewThreatChecker(t)
+	threatCheckerMock.On("CheckURL", "https://example.de/").Return(nil).Once()
+
+	geoLocatorMock := mocks.NewGeoLocator(t)
+	geoLocatorMock.On("Lookup", "198.51.100.7:41000").Return(geo.Location{Country: "DE", Region: "DE-BE"}).Once()
+
+	clickRecorderMock := mocks.NewClickRecorder(t)
+	clickRecorderMock.On("RecordClick", "", link.Alias, storage.Click{Rule: "dach", Country: "DE", Region: "DE-BE"}).
+		Return(nil).
+		Once()
+
+	clickPublisherMock := mocks.NewClickPublisher(t)
+	clickPublisherMock.On("Publish", mock.MatchedBy(func(c clickstream.Click) bool {
+		return c.Alias == link.Alias && c.URL == "https://example.de/" && c.Rule == "dach" && c.Country == "DE" && !c.Time.IsZero()
+	})).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, clickRecorderMock, defaultDomain(t), mocks.NewMissRecorder(t), mocks.NewNotFoundResponder(t), mocks.NewVariantPicker(t), geoLocatorMock, anyEvents(t), clickPublisherMock))
+
+	req := httptest.NewRequest(http.MethodGet, "/shop", nil)
+	req.RemoteAddr = "198.51.100.7:41000"
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, "https://example.de/", rr.Header().Get("Location"))
+}
+
+func TestRedirectHandler_Page(t *testing.T) {
+	link := storage.Link{
+		Alias: "jane",
+		Page: &storage.Page{
+			Title:     "Jane <3",
+			AvatarURL: "https://example.com/jane.png",
+			Theme:     storage.ThemeDark,
+			Buttons: []storage.Button{
+				{Label: "Blog", URL: "https://example.com/blog"},
+				{Name: "shop", Label: "Shop", URL: "https://shop.example.com/"},
+			},
+		},
+	}
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", link.Alias).Return(link, nil).Times(3)
+
+	threatCheckerMock := mocks.NewThreatChecker(t)
+	threatCheckerMock.On("CheckURL", "https://shop.example.com/").Return(nil).Once()
+
+	clickRecorderMock := mocks.NewClickRecorder(t)
+	clickRecorderMock.On("RecordClick", "", link.Alias, storage.Click{Button: "shop"}).Return(nil).Once()
+
+	clickPublisherMock := mocks.NewClickPublisher(t)
+	clickPublisherMock.On("Publish", mock.MatchedBy(func(c clickstream.Click) bool {
+		return c.Alias == link.Alias && c.URL == "https://shop.example.com/" && c.Button == "shop" && c.Rule == ""
+	})).Once()
+
+	notFoundMock := mocks.NewNotFoundResponder(t)
+	notFoundMock.On("Respond", mock.Anything, mock.Anything, mock.Anything, link.Alias).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(http.ResponseWriter).WriteHeader(http.StatusNotFound)
+		}).
+		Once()
+
+	handler := redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, clickRecorderMock, defaultDomain(t), mocks.NewMissRecorder(t), notFoundMock, mocks.NewVariantPicker(t), noGeo(t), anyEvents(t), clickPublisherMock)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", handler)
+	r.Get("/{alias}/b/{button}", handler)
+
+	// The alias shows the page, its buttons linking to tracked redirects.
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/jane", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	body := rr.Body.String()
+	assert.Contains(t, body, `<body class="dark">`)
+	assert.Contains(t, body, "<h1>Jane &lt;3</h1>")
+	assert.Contains(t, body, `src="https://example.com/jane.png"`)
+	assert.Contains(t, body, `href="/jane/b/button-1"`)
+	assert.Contains(t, body, `href="/jane/b/shop"`)
+	assert.Less(t, strings.Index(body, "Blog"), strings.Index(body, "Shop"), "buttons keep their order")
+
+	// A button redirects and is counted under its name.
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/jane/b/shop", nil))
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "https://shop.example.com/", rr.Header().Get("Location"))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/jane/b/gone", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRedirectHandler_FirstClick(t *testing.T) {
+	cases := []struct {
+		name   string
+		clicks int64
+		event  bool
+	}{
+		{name: "First click", clicks: 0, event: true},
+		{name: "Later click", clicks: 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			link := storage.Link{ID: 7, Alias: "new", URL: "https://example.com/", Clicks: tc.clicks}
+
+			linkGetterMock := mocks.NewLinkGetter(t)
+			linkGetterMock.On("GetLink", "", link.Alias).Return(link, nil).Once()
+
+			threatCheckerMock := mocks.NewThreatChecker(t)
+			threatCheckerMock.On("CheckURL", link.URL).Return(nil).Once()
+
+			clickRecorderMock := mocks.NewClickRecorder(t)
+			clickRecorderMock.On("RecordClick", "", link.Alias, mock.AnythingOfType("storage.Click")).Return(nil).Once()
+
+			eventPublisherMock := mocks.NewEventPublisher(t)
+			if tc.event {
+				eventPublisherMock.On("Publish", webhook.EventFirstClick, link).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), linkGetterMock, threatCheckerMock, clickRecorderMock, defaultDomain(t), mocks.NewMissRecorder(t), mocks.NewNotFoundResponder(t), mocks.NewVariantPicker(t), noGeo(t), eventPublisherMock, anyClicks(t)))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/new", nil))
+
+			assert.Equal(t, link.URL, rr.Header().Get("Location"))
+		})
+	}
+}
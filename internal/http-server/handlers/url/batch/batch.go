@@ -0,0 +1,419 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Item is the outcome for the request item at Index.
+type Item struct {
+	Index    int               `json:"index"`
+	Alias    string            `json:"alias,omitempty"`
+	Existing bool              `json:"existing,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Errors   []resp.FieldError `json:"errors,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Saved  int    `json:"saved"`
+	Failed int    `json:"failed"`
+	Items  []Item `json:"items,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
+type URLSaver interface {
+	SaveURLs(links []storage.Link, atomic bool) ([]int64, []error, error)
+	GetAliasByNormalizedURL(owner string, domain string, normalizedURL string) (string, error)
+}
+
+var errAborted = errors.New("not saved: another item failed")
+
+// New returns a handler saving many links in one transaction.
+//
+// The body is a JSON array of save.Request, or one request per line when the
+// Content-Type is application/x-ndjson. Every item is validated like a single
+// save. With ?atomic=true nothing is stored unless every item succeeds;
+// otherwise valid items are stored and failures are reported per item.
+func New(
+	log *slog.Logger,
+	urlSaver URLSaver,
+	aliasGenerator save.AliasGenerator,
+	aliasChecker save.AliasChecker,
+	urlChecker save.URLChecker,
+	domainChecker save.DomainChecker,
+	eventPublisher save.EventPublisher,
+	auditRecorder save.AuditRecorder,
+	opts save.Options,
+	maxItems int,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.batch.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		atomic, _ := strconv.ParseBool(r.URL.Query().Get("atomic"))
+
+		reqs, err := decode(r, maxItems)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		log.Info("batch decoded", slog.Int("items", len(reqs)), slog.Bool("atomic", atomic))
+
+		owner, _, _ := r.BasicAuth()
+
+		b := &batch{
+			urlSaver:       urlSaver,
+			aliasGenerator: aliasGenerator,
+			aliasChecker:   aliasChecker,
+			maxAttempts:    max(opts.MaxAttempts, 1),
+			owner:          owner,
+			items:          make([]Item, len(reqs)),
+			links:          make([]storage.Link, len(reqs)),
+			generated:      make([]bool, len(reqs)),
+			dupOf:          make([]int, len(reqs)),
+		}
+
+		for i, req := range reqs {
+			b.items[i].Index = i
+			b.dupOf[i] = -1
+
+			link, err := save.Prepare(req, owner, aliasChecker, urlChecker, domainChecker, opts)
+			if err != nil {
+				if !b.reject(i, err) {
+					log.Error("failed to validate item", slog.Int("index", i), sl.Err(err))
+
+					render.JSON(w, r, resp.Error("failed to add urls"))
+
+					return
+				}
+
+				continue
+			}
+
+			b.links[i] = link
+			b.generated[i] = link.Alias == ""
+		}
+
+		if atomic && b.failed() > 0 {
+			log.Info("batch rejected", slog.Int("failed", b.failed()))
+
+			b.abort()
+			render.JSON(w, r, b.response(resp.Error("batch rejected, nothing was saved")))
+
+			return
+		}
+
+		if err := b.save(atomic); err != nil {
+			log.Error("failed to save batch", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to add urls"))
+
+			return
+		}
+
+		if atomic && b.failed() > 0 {
+			log.Info("batch rolled back", slog.Int("failed", b.failed()))
+
+			b.abort()
+			render.JSON(w, r, b.response(resp.Error("batch rejected, nothing was saved")))
+
+			return
+		}
+
+		log.Info("batch saved", slog.Int("saved", len(reqs)-b.failed()), slog.Int("failed", b.failed()))
+
+		for i, item := range b.items {
+			if item.Error == "" && !item.Existing && b.dupOf[i] < 0 {
+				eventPublisher.Publish(webhook.EventCreated, b.links[i])
+				auditRecorder.Record(r, audit.Entry{
+					Action: audit.ActionLinkCreate,
+					Domain: b.links[i].Domain,
+					Alias:  b.links[i].Alias,
+					After:  audit.LinkOf(b.links[i]),
+				})
+			}
+		}
+
+		render.JSON(w, r, b.response(resp.OK()))
+	}
+}
+
+type batch struct {
+	urlSaver       URLSaver
+	aliasGenerator save.AliasGenerator
+	aliasChecker   save.AliasChecker
+	maxAttempts    int
+	owner          string
+
+	items     []Item
+	links     []storage.Link
+	generated []bool
+	// dupOf[i] is the earlier item with the same destination on the same
+	// domain, or -1.
+	dupOf []int
+}
+
+// reject records a validation failure for item i. It reports false for
+// errors that aren't rejections.
+func (b *batch) reject(i int, err error) bool {
+	var reqErr *save.RequestError
+	if !errors.As(err, &reqErr) {
+		return false
+	}
+
+	b.items[i].Error = reqErr.Response.Error
+	b.items[i].Errors = reqErr.Response.Errors
+
+	return true
+}
+
+func (b *batch) fail(i int, err error) {
+	b.items[i].Error = err.Error()
+}
+
+func (b *batch) failed() int {
+	n := 0
+	for _, item := range b.items {
+		if item.Error != "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+// abort marks every item that didn't fail itself as not saved.
+func (b *batch) abort() {
+	for i := range b.items {
+		if b.items[i].Error == "" {
+			b.items[i] = Item{Index: i, Error: errAborted.Error()}
+		}
+	}
+}
+
+func (b *batch) response(r resp.Response) Response {
+	failed := b.failed()
+
+	return Response{
+		Response: r,
+		Saved:    len(b.items) - failed,
+		Failed:   failed,
+		Items:    b.items,
+	}
+}
+
+// save stores every valid item. Items with generated aliases that collide
+// get a new alias and are tried again, up to maxAttempts times.
+func (b *batch) save(atomic bool) error {
+	const fn = "handlers.url.batch.save"
+
+	pending, err := b.resolveDuplicates()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	regen := make([]int, 0, len(pending))
+	for _, i := range pending {
+		if b.generated[i] {
+			regen = append(regen, i)
+		}
+	}
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		for _, i := range regen {
+			if err := b.generate(i); err != nil {
+				return fmt.Errorf("%s: %w", fn, err)
+			}
+		}
+
+		links := make([]storage.Link, len(pending))
+		for j, i := range pending {
+			links[j] = b.links[i]
+		}
+
+		_, errs, err := b.urlSaver.SaveURLs(links, atomic)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+
+		var collided []int
+		for j, i := range pending {
+			err := errs[j]
+
+			switch {
+			case err == nil:
+				b.items[i].Alias = b.links[i].Alias
+			case errors.Is(err, storage.ErrAliasExists) && b.generated[i] && attempt < b.maxAttempts:
+				collided = append(collided, i)
+			case errors.Is(err, storage.ErrURLExists) && b.links[i].NormalizedURL != "" && !atomic:
+				// Saved concurrently by another request.
+				if err := b.useExisting(i); err != nil {
+					return fmt.Errorf("%s: %w", fn, err)
+				}
+			case errors.Is(err, storage.ErrAliasExists):
+				b.fail(i, errors.New("alias already exists"))
+			case errors.Is(err, storage.ErrURLExists):
+				b.fail(i, errors.New("url already exists"))
+			default:
+				b.fail(i, err)
+			}
+		}
+
+		regen = collided
+		switch {
+		case !atomic:
+			pending = collided
+		case b.failed() > 0 || len(collided) == 0:
+			pending = nil
+		default:
+			// The transaction was rolled back, so everything goes again.
+			for _, i := range pending {
+				b.items[i].Alias = ""
+			}
+		}
+	}
+
+	for i, j := range b.dupOf {
+		if j < 0 {
+			continue
+		}
+
+		b.items[i].Alias = b.items[j].Alias
+		b.items[i].Error = b.items[j].Error
+		b.items[i].Existing = b.items[j].Error == ""
+	}
+
+	return nil
+}
+
+// resolveDuplicates answers deduplicated items whose destination is already
+// shortened, or repeated earlier in the batch, and returns the items left
+// to insert.
+func (b *batch) resolveDuplicates() ([]int, error) {
+	var pending []int
+	// Keyed by domain and normalized URL; a space can't appear in either.
+	first := make(map[string]int)
+
+	for i := range b.items {
+		if b.items[i].Error != "" {
+			continue
+		}
+
+		normalized := b.links[i].NormalizedURL
+		if normalized == "" {
+			pending = append(pending, i)
+			continue
+		}
+
+		existing, err := b.urlSaver.GetAliasByNormalizedURL(b.owner, b.links[i].Domain, normalized)
+		if err == nil {
+			b.items[i].Alias = existing
+			b.items[i].Existing = true
+			continue
+		}
+		if !errors.Is(err, storage.ErrURLNotFound) {
+			return nil, err
+		}
+
+		key := b.links[i].Domain + " " + normalized
+		if j, ok := first[key]; ok {
+			// Filled in once item j is stored.
+			b.dupOf[i] = j
+			continue
+		}
+
+		first[key] = i
+		pending = append(pending, i)
+	}
+
+	return pending, nil
+}
+
+func (b *batch) useExisting(i int) error {
+	existing, err := b.urlSaver.GetAliasByNormalizedURL(b.owner, b.links[i].Domain, b.links[i].NormalizedURL)
+	if err != nil {
+		return err
+	}
+
+	b.items[i].Alias = existing
+	b.items[i].Existing = true
+
+	return nil
+}
+
+func (b *batch) generate(i int) error {
+	for attempt := 1; attempt <= b.maxAttempts; attempt++ {
+		alias, err := b.aliasGenerator.Generate()
+		if err != nil {
+			return err
+		}
+
+		if b.aliasChecker.CheckAlias(alias) == nil {
+			b.links[i].Alias = alias
+			return nil
+		}
+	}
+
+	return errors.New("failed to generate an allowed alias")
+}
+
+func decode(r *http.Request, maxItems int) ([]save.Request, error) {
+	var reqs []save.Request
+
+	if strings.Contains(r.Header.Get("Content-Type"), "ndjson") {
+		dec := json.NewDecoder(r.Body)
+		for {
+			var req save.Request
+			err := dec.Decode(&req)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode item %d", len(reqs))
+			}
+
+			reqs = append(reqs, req)
+			if len(reqs) > maxItems {
+				return nil, fmt.Errorf("batch is limited to %d items", maxItems)
+			}
+		}
+	} else {
+		if err := render.DecodeJSON(r.Body, &reqs); err != nil {
+			return nil, errors.New("failed to decode request")
+		}
+		if len(reqs) > maxItems {
+			return nil, fmt.Errorf("batch is limited to %d items", maxItems)
+		}
+	}
+
+	if len(reqs) == 0 {
+		return nil, errors.New("batch is empty")
+	}
+
+	return reqs, nil
+}
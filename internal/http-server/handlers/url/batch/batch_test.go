@@ -0,0 +1,268 @@
+package batch_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/batch"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/batch/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+var invalidURL = []resp.FieldError{{Field: "URL", Rule: "url", Message: "field URL is not a valid URL"}}
+
+func TestBatchHandler(t *testing.T) {
+	const body = `[
+		{"url": "https://google.com", "alias": "google"},
+		{"url": "not a url", "alias": "broken"},
+		{"url": "https://example.com", "alias": "taken"}
+	]`
+
+	cases := []struct {
+		name        string
+		atomic      bool
+		contentType string
+		body        string
+		saveErrs    []error
+		respError   string
+		saved       int
+		items       []batch.Item
+	}{
+		{
+			name:     "Partial success",
+			body:     body,
+			saveErrs: []error{nil, storage.ErrAliasExists},
+			saved:    1,
+			items: []batch.Item{
+				{Index: 0, Alias: "google"},
+				{Index: 1, Error: "field URL is not a valid URL", Errors: invalidURL},
+				{Index: 2, Error: "alias already exists"},
+			},
+		},
+		{
+			name:      "Atomic rejects invalid item",
+			atomic:    true,
+			body:      body,
+			respError: "batch rejected, nothing was saved",
+			items: []batch.Item{
+				{Index: 0, Error: "not saved: another item failed"},
+				{Index: 1, Error: "field URL is not a valid URL", Errors: invalidURL},
+				{Index: 2, Error: "not saved: another item failed"},
+			},
+		},
+		{
+			name:        "NDJSON",
+			contentType: "application/x-ndjson",
+			body: `{"url": "https://google.com", "alias": "google"}
+{"url": "https://example.com", "alias": "example"}
+`,
+			saveErrs: []error{nil, nil},
+			saved:    2,
+			items: []batch.Item{
+				{Index: 0, Alias: "google"},
+				{Index: 1, Alias: "example"},
+			},
+		},
+		{
+			name:      "Empty batch",
+			body:      `[]`,
+			respError: "batch is empty",
+		},
+		{
+			name:      "Too many items",
+			body:      `[{"url": "https://a.com"}, {"url": "https://b.com"}, {"url": "https://c.com"}, {"url": "https://d.com"}]`,
+			respError: "batch is limited to 3 items",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			aliasCheckerMock := saveMocks.NewAliasChecker(t)
+			aliasCheckerMock.On("CheckAlias", mock.AnythingOfType("string")).Return(nil).Maybe()
+
+			if tc.saveErrs != nil {
+				urlSaverMock.On("SaveURLs", mock.AnythingOfType("[]storage.Link"), tc.atomic).
+					Return(make([]int64, len(tc.saveErrs)), tc.saveErrs, nil).
+					Once()
+			}
+
+			handler := batch.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				saveMocks.NewAliasGenerator(t),
+				aliasCheckerMock,
+				allowAll(t),
+				saveMocks.NewDomainChecker(t),
+				anyEvents(t),
+				anyAudit(t),
+				save.Options{MaxAttempts: 3},
+				3,
+			)
+
+			resp := serve(t, handler, tc.atomic, tc.contentType, tc.body)
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.saved, resp.Saved)
+			require.Equal(t, tc.items, resp.Items)
+		})
+	}
+}
+
+func TestBatchHandler_GeneratedAliasCollision(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	aliasGeneratorMock := saveMocks.NewAliasGenerator(t)
+	aliasCheckerMock := saveMocks.NewAliasChecker(t)
+	aliasCheckerMock.On("CheckAlias", mock.AnythingOfType("string")).Return(nil).Maybe()
+
+	aliasGeneratorMock.On("Generate").Return("alias1", nil).Once()
+	aliasGeneratorMock.On("Generate").Return("alias2", nil).Once()
+	aliasGeneratorMock.On("Generate").Return("alias3", nil).Once()
+
+	urlSaverMock.On("SaveURLs", []storage.Link{
+		{URL: "https://google.com", Alias: "alias1", Owner: "user"},
+		{URL: "https://example.com", Alias: "alias2", Owner: "user"},
+	}, false).
+		Return([]int64{1, 0}, []error{nil, storage.ErrAliasExists}, nil).
+		Once()
+	urlSaverMock.On("SaveURLs", []storage.Link{
+		{URL: "https://example.com", Alias: "alias3", Owner: "user"},
+	}, false).
+		Return([]int64{2}, []error{nil}, nil).
+		Once()
+
+	eventPublisherMock := saveMocks.NewEventPublisher(t)
+	for _, alias := range []string{"alias1", "alias3"} {
+		eventPublisherMock.On("Publish", webhook.EventCreated, mock.MatchedBy(func(link storage.Link) bool {
+			return link.Alias == alias
+		})).Once()
+	}
+
+	handler := batch.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		aliasGeneratorMock,
+		aliasCheckerMock,
+		allowAll(t),
+		saveMocks.NewDomainChecker(t),
+		eventPublisherMock,
+		anyAudit(t),
+		save.Options{MaxAttempts: 3},
+		10,
+	)
+
+	resp := serve(t, handler, false, "", `[{"url": "https://google.com"}, {"url": "https://example.com"}]`)
+
+	require.Empty(t, resp.Error)
+	require.Equal(t, 2, resp.Saved)
+	require.Equal(t, []batch.Item{
+		{Index: 0, Alias: "alias1"},
+		{Index: 1, Alias: "alias3"},
+	}, resp.Items)
+}
+
+func TestBatchHandler_Dedupe(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	aliasCheckerMock := saveMocks.NewAliasChecker(t)
+	aliasCheckerMock.On("CheckAlias", mock.AnythingOfType("string")).Return(nil).Maybe()
+
+	urlSaverMock.On("GetAliasByNormalizedURL", "user", "", "https://example.com/").
+		Return("", storage.ErrURLNotFound).
+		Twice()
+	urlSaverMock.On("SaveURLs", mock.MatchedBy(func(links []storage.Link) bool {
+		return len(links) == 1 && links[0].Alias == "example"
+	}), false).
+		Return([]int64{1}, []error{nil}, nil).
+		Once()
+
+	// The repeated destination isn't a new link.
+	eventPublisherMock := saveMocks.NewEventPublisher(t)
+	eventPublisherMock.On("Publish", webhook.EventCreated, mock.MatchedBy(func(link storage.Link) bool {
+		return link.Alias == "example"
+	})).Once()
+
+	handler := batch.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		saveMocks.NewAliasGenerator(t),
+		aliasCheckerMock,
+		allowAll(t),
+		saveMocks.NewDomainChecker(t),
+		eventPublisherMock,
+		anyAudit(t),
+		save.Options{MaxAttempts: 3, Dedupe: true},
+		10,
+	)
+
+	resp := serve(t, handler, false, "", `[
+		{"url": "https://example.com", "alias": "example"},
+		{"url": "HTTPS://EXAMPLE.COM/", "alias": "again"}
+	]`)
+
+	require.Empty(t, resp.Error)
+	require.Equal(t, []batch.Item{
+		{Index: 0, Alias: "example"},
+		{Index: 1, Alias: "example", Existing: true},
+	}, resp.Items)
+}
+
+func allowAll(t *testing.T) *saveMocks.URLChecker {
+	urlCheckerMock := saveMocks.NewURLChecker(t)
+	urlCheckerMock.On("CheckURL", mock.AnythingOfType("string")).Return(nil).Maybe()
+
+	return urlCheckerMock
+}
+
+func anyAudit(t *testing.T) *saveMocks.AuditRecorder {
+	auditRecorderMock := saveMocks.NewAuditRecorder(t)
+	auditRecorderMock.On("Record", mock.Anything, mock.Anything).Maybe()
+
+	return auditRecorderMock
+}
+
+func anyEvents(t *testing.T) *saveMocks.EventPublisher {
+	eventPublisherMock := saveMocks.NewEventPublisher(t)
+	eventPublisherMock.On("Publish", mock.Anything, mock.Anything).Maybe()
+
+	return eventPublisherMock
+}
+
+func serve(t *testing.T, handler http.HandlerFunc, atomic bool, contentType string, body string) batch.Response {
+	target := "/url/batch"
+	if atomic {
+		target += "?atomic=true"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	require.NoError(t, err)
+	req.SetBasicAuth("user", "pass")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp batch.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	return resp
+}
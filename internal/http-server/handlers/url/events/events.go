@@ -0,0 +1,211 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/clickstream"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkGetter
+type LinkGetter interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+}
+
+// Subscriber hands out click subscriptions.
+type Subscriber interface {
+	Subscribe(topic clickstream.Topic, lastID uint64) (*clickstream.Subscription, []clickstream.Click, bool)
+}
+
+// Options tune the streams.
+type Options struct {
+	// Heartbeat is how often idle streams get a comment.
+	Heartbeat time.Duration
+	// WriteTimeout bounds each write to the client.
+	WriteTimeout time.Duration
+}
+
+// New returns a handler streaming the clicks on a link as server-sent
+// events. ?domain selects the short domain the alias is on; the default
+// domain otherwise.
+//
+// Users other than the admin need read rights on the link.
+func New(log *slog.Logger, linkGetter LinkGetter, accessChecker save.AccessChecker, subscriber Subscriber, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.events.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleRead) {
+			return
+		}
+
+		if _, err := linkGetter.GetLink(host, alias); err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Info("url not found", slog.String("alias", alias))
+
+				render.JSON(w, r, resp.Error("not found"))
+
+				return
+			}
+
+			log.Error("failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		Stream(log, w, r, subscriber, clickstream.Topic{Domain: host, Alias: alias}, opts)
+	}
+}
+
+// Stream sends the clicks of topic to the client as server-sent events
+// until the client goes away or falls too far behind. Each click is an
+// event of type "click" with the click's ID, so clients reconnecting with
+// Last-Event-ID, or ?last_event_id, get the clicks they missed while the
+// server still has them. Idle streams get a comment every opts.Heartbeat.
+func Stream(log *slog.Logger, w http.ResponseWriter, r *http.Request, subscriber Subscriber, topic clickstream.Topic, opts Options) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	// IDs that don't parse are treated like no ID at all.
+	lastID, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	sub, backlog, missed := subscriber.Subscribe(topic, lastID)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+
+	// send writes and flushes one event. The server's write timeout is
+	// too short for a stream, so each write gets its own deadline instead.
+	send := func(write func(io.Writer) error) error {
+		// Writers without deadlines, such as recorders in tests, just go on.
+		_ = rc.SetWriteDeadline(time.Now().Add(opts.WriteTimeout))
+
+		if err := write(w); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	comment := func(text string) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, ": %s\n\n", text)
+			return err
+		}
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	// Keep nginx from buffering the stream.
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	log.Info("click stream opened",
+		slog.String("alias", topic.Alias),
+		slog.Uint64("last_event_id", lastID),
+		slog.Int("backlog", len(backlog)),
+	)
+	defer log.Info("click stream closed", slog.String("alias", topic.Alias))
+
+	if err := send(comment("connected")); err != nil {
+		log.Info("failed to write to client", sl.Err(err))
+
+		return
+	}
+
+	if missed {
+		if err := send(comment("some clicks were missed")); err != nil {
+			log.Info("failed to write to client", sl.Err(err))
+
+			return
+		}
+	}
+
+	for _, c := range backlog {
+		if err := send(event(c)); err != nil {
+			log.Info("failed to write to client", sl.Err(err))
+
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(opts.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-r.Context().Done():
+			return
+		case c, ok := <-sub.C:
+			if !ok {
+				if sub.Lagged() {
+					log.Warn("client fell behind, click stream dropped", slog.String("alias", topic.Alias))
+
+					// Best effort: the client reconnects with the last ID either way.
+					_ = send(comment("too slow, reconnect to catch up"))
+				}
+
+				return
+			}
+
+			err = send(event(c))
+		case <-heartbeat.C:
+			err = send(comment("ping"))
+		}
+
+		if err != nil {
+			log.Info("failed to write to client", sl.Err(err))
+
+			return
+		}
+	}
+}
+
+func event(c clickstream.Click) func(io.Writer) error {
+	return func(w io.Writer) error {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "id: %d\nevent: click\ndata: %s\n\n", c.ID, data)
+		return err
+	}
+}
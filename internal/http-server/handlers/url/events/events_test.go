@@ -0,0 +1,126 @@
+package events_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/events"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/events/mocks"
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/clickstream"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+var testOptions = events.Options{Heartbeat: 20 * time.Millisecond, WriteTimeout: time.Second}
+
+// readEvent returns the next event's fields, skipping comments.
+func readEvent(t *testing.T, sc *bufio.Scanner) map[string]string {
+	t.Helper()
+
+	fields := map[string]string{}
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+
+	t.Fatalf("stream ended: %v", sc.Err())
+
+	return nil
+}
+
+// allowRead lets any user read the link.
+func allowRead(t *testing.T) *saveMocks.AccessChecker {
+	accessCheckerMock := saveMocks.NewAccessChecker(t)
+	accessCheckerMock.On("CheckLink", mock.Anything, mock.Anything, mock.Anything, storage.RoleRead).Return(nil).Maybe()
+
+	return accessCheckerMock
+}
+
+func TestEventsHandler(t *testing.T) {
+	hub := clickstream.New(10, 10)
+	hub.Publish(clickstream.Click{Alias: "docs", URL: "https://example.com/1"})
+	hub.Publish(clickstream.Click{Alias: "blog"})
+	hub.Publish(clickstream.Click{Alias: "docs", URL: "https://example.com/3"})
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", "docs").Return(storage.Link{Alias: "docs"}, nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}/events", events.New(slogdiscard.NewDiscardLogger(), linkGetterMock, allowRead(t), hub, testOptions))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/docs/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	sc := bufio.NewScanner(res.Body)
+
+	// The click after Last-Event-ID comes first.
+	e := readEvent(t, sc)
+	assert.Equal(t, "3", e["id"])
+	assert.Equal(t, "click", e["event"])
+
+	var c clickstream.Click
+	require.NoError(t, json.Unmarshal([]byte(e["data"]), &c))
+	assert.Equal(t, "https://example.com/3", c.URL)
+
+	hub.Publish(clickstream.Click{Alias: "blog"})
+	hub.Publish(clickstream.Click{Alias: "docs"})
+
+	e = readEvent(t, sc)
+	assert.Equal(t, "5", e["id"])
+
+	// Idle streams are kept alive.
+	for sc.Scan() && sc.Text() != ": ping" {
+	}
+	require.NoError(t, sc.Err())
+}
+
+func TestEventsHandler_NotFound(t *testing.T) {
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", "", "missing").Return(storage.Link{}, storage.ErrURLNotFound).Once()
+
+	hub := clickstream.New(1, 1)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}/events", events.New(slogdiscard.NewDiscardLogger(), linkGetterMock, allowRead(t), hub, testOptions))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/missing/events", nil))
+
+	var body response.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "not found", body.Error)
+	assert.Zero(t, hub.Subscribers())
+}
@@ -0,0 +1,175 @@
+package history
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Revision struct {
+	Revision    int64             `json:"revision"`
+	Action      string            `json:"action"`
+	URL         string            `json:"url"`
+	Owner       string            `json:"owner,omitempty"`
+	Rules       []storage.Rule    `json:"rules,omitempty"`
+	Variants    []storage.Variant `json:"variants,omitempty"`
+	Page        *storage.Page     `json:"page,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Deleted     bool              `json:"deleted,omitempty"`
+	RollbackOf  int64             `json:"rollback_of,omitempty"`
+	Actor       string            `json:"actor,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// NewRevision returns the JSON form of rev.
+func NewRevision(rev storage.Revision) Revision {
+	return Revision{
+		Revision:    rev.Revision,
+		Action:      rev.Action,
+		URL:         rev.URL,
+		Owner:       rev.Owner,
+		Rules:       rev.Rules,
+		Variants:    rev.Variants,
+		Page:        rev.Page,
+		Title:       rev.Title,
+		Description: rev.Description,
+		Tags:        rev.Tags,
+		Metadata:    rev.Metadata,
+		Deleted:     rev.Deleted,
+		RollbackOf:  rev.RollbackOf,
+		Actor:       rev.Actor,
+		CreatedAt:   rev.CreatedAt,
+	}
+}
+
+type Response struct {
+	resp.Response
+	Revisions []Revision `json:"revisions"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=RevisionLister
+type RevisionLister interface {
+	ListRevisions(domain string, alias string, limit int) ([]storage.Revision, error)
+	GetRevisionAt(domain string, alias string, at time.Time) (storage.Revision, error)
+}
+
+// New returns a handler listing the revisions of a link, the latest first;
+// ?limit caps the list at up to 1000 entries, 100 by default. ?at, an RFC
+// 3339 time, instead returns only the revision in effect then, to tell
+// where the link redirected at that time. ?domain selects the short domain
+// the alias is on; the default domain otherwise.
+//
+// Users other than the admin need read rights on the link.
+func New(log *slog.Logger, revisionLister RevisionLister, accessChecker save.AccessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.history.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		query := r.URL.Query()
+		host := domain.Normalize(query.Get("domain"))
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleRead) {
+			return
+		}
+
+		var list []storage.Revision
+		if raw := query.Get("at"); raw != "" {
+			at, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				render.JSON(w, r, resp.Error("at must be an RFC 3339 time"))
+
+				return
+			}
+
+			rev, err := revisionLister.GetRevisionAt(host, alias, at)
+			if errors.Is(err, storage.ErrRevisionNotFound) {
+				log.Info("no revision at time", slog.String("alias", alias), slog.Time("at", at))
+
+				render.JSON(w, r, resp.Error("not found"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to get revision", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("internal error"))
+
+				return
+			}
+
+			list = []storage.Revision{rev}
+		} else {
+			limit := defaultLimit
+			if raw := query.Get("limit"); raw != "" {
+				n, err := strconv.Atoi(raw)
+				if err != nil || n < 1 || n > maxLimit {
+					render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+					return
+				}
+				limit = n
+			}
+
+			var err error
+			list, err = revisionLister.ListRevisions(host, alias, limit)
+			if err != nil {
+				log.Error("failed to list revisions", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("internal error"))
+
+				return
+			}
+
+			if len(list) == 0 {
+				log.Info("url not found", "alias", alias)
+
+				render.JSON(w, r, resp.Error("not found"))
+
+				return
+			}
+		}
+
+		revisions := make([]Revision, 0, len(list))
+		for _, rev := range list {
+			revisions = append(revisions, NewRevision(rev))
+		}
+
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Revisions: revisions,
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package list
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/meta"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Tag matching modes for ?match.
+const (
+	MatchAll = "all"
+	MatchAny = "any"
+)
+
+type Link struct {
+	Domain      string            `json:"domain,omitempty"`
+	Alias       string            `json:"alias"`
+	URL         string            `json:"url"`
+	Owner       string            `json:"owner,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Page        *storage.Page     `json:"page,omitempty"`
+	Clicks      int64             `json:"clicks"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// NewLink returns the JSON form of link.
+func NewLink(link storage.Link) Link {
+	return Link{
+		Domain:      link.Domain,
+		Alias:       link.Alias,
+		URL:         link.URL,
+		Owner:       link.Owner,
+		Title:       link.Title,
+		Description: link.Description,
+		Tags:        link.Tags,
+		Metadata:    link.Metadata,
+		Page:        link.Page,
+		Clicks:      link.Clicks,
+		CreatedAt:   link.CreatedAt,
+	}
+}
+
+type Response struct {
+	resp.Response
+	Links []Link `json:"links"`
+	// NextBefore is the ?before for the next page, if there may be one.
+	NextBefore int64 `json:"next_before,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkLister
+type LinkLister interface {
+	ListLinks(filter storage.LinkFilter, limit int) ([]storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AdminChecker
+type AdminChecker interface {
+	IsAdmin(user string) bool
+}
+
+// New returns a handler listing links, the newest first. ?tag selects
+// links by tag, repeated or comma-separated for several; ?match=all, the
+// default, wants every tag on a link and ?match=any one of them. ?domain
+// and ?owner narrow the list further. ?limit caps a page at up to 1000
+// links, 100 by default, and ?before takes the next_before of the previous
+// page.
+//
+// Users other than the admin only see the links they own or have rights on
+// through a collection.
+func New(log *slog.Logger, linkLister LinkLister, adminChecker AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.list.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		filter := storage.LinkFilter{
+			Domain: domain.Normalize(query.Get("domain")),
+			Owner:  query.Get("owner"),
+		}
+
+		if user, _, _ := r.BasicAuth(); !adminChecker.IsAdmin(user) {
+			filter.Viewer = user
+		}
+
+		for _, raw := range query["tag"] {
+			for _, tag := range strings.Split(raw, ",") {
+				tag = meta.NormalizeTag(tag)
+				if err := meta.ValidateTag(tag); err != nil {
+					render.JSON(w, r, resp.Error(err.Error()))
+
+					return
+				}
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+
+		switch query.Get("match") {
+		case "", MatchAll:
+		case MatchAny:
+			filter.AnyTag = true
+		default:
+			render.JSON(w, r, resp.Error("match must be all or any"))
+
+			return
+		}
+
+		limit := defaultLimit
+		if raw := query.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, resp.Error("limit must be between 1 and 1000"))
+
+				return
+			}
+			limit = n
+		}
+
+		if raw := query.Get("before"); raw != "" {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || id < 1 {
+				render.JSON(w, r, resp.Error("invalid before"))
+
+				return
+			}
+			filter.BeforeID = id
+		}
+
+		list, err := linkLister.ListLinks(filter, limit)
+		if err != nil {
+			log.Error("failed to list links", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		res := Response{Response: resp.OK(), Links: make([]Link, 0, len(list))}
+		for _, link := range list {
+			res.Links = append(res.Links, NewLink(link))
+		}
+		if len(list) == limit {
+			res.NextBefore = list[len(list)-1].ID
+		}
+
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,77 @@
+package list_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/list"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/list/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestListHandler(t *testing.T) {
+	bobs := []storage.Link{{ID: 7, Alias: "bob-own", URL: "https://example.com/bob", Owner: "bob"}}
+
+	cases := []struct {
+		name      string
+		user      string
+		admin     bool
+		query     string
+		filter    storage.LinkFilter
+		respError string
+	}{
+		{
+			name:   "Admin sees every link",
+			user:   "admin",
+			admin:  true,
+			query:  "?owner=alice",
+			filter: storage.LinkFilter{Owner: "alice"},
+		},
+		{
+			name:   "Other users see what they may read",
+			user:   "bob",
+			query:  "?tag=Spring",
+			filter: storage.LinkFilter{Tags: []string{"spring"}, Viewer: "bob"},
+		},
+		{
+			name:      "Bad match",
+			user:      "bob",
+			query:     "?match=some",
+			respError: "match must be all or any",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			linkListerMock := mocks.NewLinkLister(t)
+			if tc.respError == "" {
+				linkListerMock.On("ListLinks", tc.filter, 100).Return(bobs, nil).Once()
+			}
+
+			adminCheckerMock := mocks.NewAdminChecker(t)
+			adminCheckerMock.On("IsAdmin", tc.user).Return(tc.admin).Once()
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), linkListerMock, adminCheckerMock)
+
+			req := httptest.NewRequest(http.MethodGet, "/url/"+tc.query, nil)
+			req.SetBasicAuth(tc.user, "secret")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Len(t, resp.Links, 1)
+				assert.Equal(t, "bob-own", resp.Links[0].Alias)
+			}
+		})
+	}
+}
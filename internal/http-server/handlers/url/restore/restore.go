@@ -0,0 +1,103 @@
+package restore
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLRestorer
+type URLRestorer interface {
+	RestoreURL(domain string, alias string, actor string) error
+	GetLink(domain string, alias string) (storage.Link, error)
+}
+
+// New returns a handler bringing a deleted link back from the trash with
+// its stats, as long as it hasn't been purged yet. ?domain selects the
+// short domain the alias is on; the default domain otherwise.
+//
+// Users other than the admin need edit rights on the link.
+func New(log *slog.Logger, urlRestorer URLRestorer, accessChecker save.AccessChecker, eventPublisher save.EventPublisher, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.restore.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+		actor, _, _ := r.BasicAuth()
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleEdit) {
+			return
+		}
+
+		err := urlRestorer.RestoreURL(host, alias, actor)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not in trash", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrURLExists) {
+			// Deduplication allows one live link per normalized URL.
+			log.Info("url already has another link", "alias", alias)
+
+			render.JSON(w, r, resp.Error("url already exists"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to restore url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("url restored", slog.String("alias", alias))
+
+		link, err := urlRestorer.GetLink(host, alias)
+		if err != nil {
+			// The link is back; only the notifications go without it.
+			log.Error("failed to get restored url", sl.Err(err))
+
+			render.JSON(w, r, resp.OK())
+
+			return
+		}
+
+		eventPublisher.Publish(webhook.EventRestored, link)
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionLinkRestore,
+			Domain: host,
+			Alias:  alias,
+			After:  audit.LinkOf(link),
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,82 @@
+package restore_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/restore"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/restore/mocks"
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestRestoreHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		restoreErr error
+		respError  string
+	}{
+		{
+			name: "Restored",
+		},
+		{
+			name:       "Not in trash",
+			restoreErr: storage.ErrURLNotFound,
+			respError:  "not found",
+		},
+		{
+			name:       "URL relinked",
+			restoreErr: storage.ErrURLExists,
+			respError:  "url already exists",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			link := storage.Link{Domain: "go.example", Alias: "promo", URL: "https://example.com", Clicks: 12}
+
+			urlRestorerMock := mocks.NewURLRestorer(t)
+			urlRestorerMock.On("RestoreURL", "go.example", "promo", "alice").Return(tc.restoreErr).Once()
+
+			eventPublisherMock := saveMocks.NewEventPublisher(t)
+			auditRecorderMock := saveMocks.NewAuditRecorder(t)
+			if tc.respError == "" {
+				urlRestorerMock.On("GetLink", "go.example", "promo").Return(link, nil).Once()
+				eventPublisherMock.On("Publish", webhook.EventRestored, link).Once()
+				auditRecorderMock.On("Record", mock.Anything, audit.Entry{
+					Action: audit.ActionLinkRestore,
+					Domain: "go.example",
+					Alias:  "promo",
+					After:  audit.LinkOf(link),
+				}).Once()
+			}
+
+			accessCheckerMock := saveMocks.NewAccessChecker(t)
+			accessCheckerMock.On("CheckLink", "alice", "go.example", "promo", storage.RoleEdit).Return(nil).Maybe()
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/restore", restore.New(slogdiscard.NewDiscardLogger(), urlRestorerMock, accessCheckerMock, eventPublisherMock, auditRecorderMock))
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/url/promo/restore?domain=go.example", nil)
+			req.SetBasicAuth("alice", "secret")
+			r.ServeHTTP(rr, req)
+
+			var res resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			assert.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
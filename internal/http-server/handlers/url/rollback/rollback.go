@@ -0,0 +1,161 @@
+package rollback
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/history"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	// Revision is the one the rollback made.
+	Revision history.Revision `json:"revision"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLRollbacker
+type URLRollbacker interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+	GetRevision(domain string, alias string, revision int64) (storage.Revision, error)
+	RollbackURL(domain string, alias string, revision int64, actor string) (storage.Revision, error)
+}
+
+// New returns a handler setting a link's destination, targeting rules and
+// A/B variants back to those of one of its revisions. The rollback is a
+// new revision itself, so it can be undone the same way. ?domain selects
+// the short domain the alias is on; the default domain otherwise. The
+// revision's destinations go through urlChecker like newly saved ones.
+//
+// Users other than the admin need edit rights on the link.
+func New(
+	log *slog.Logger,
+	urlRollbacker URLRollbacker,
+	accessChecker save.AccessChecker,
+	urlChecker save.URLChecker,
+	eventPublisher save.EventPublisher,
+	auditRecorder save.AuditRecorder,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.rollback.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		revision, err := strconv.ParseInt(chi.URLParam(r, "revision"), 10, 64)
+		if err != nil || revision < 1 {
+			render.JSON(w, r, resp.Error("invalid revision"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+		actor, _, _ := r.BasicAuth()
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleEdit) {
+			return
+		}
+
+		// The link is read first so the audit log keeps what was replaced.
+		link, err := urlRollbacker.GetLink(host, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		var rev storage.Revision
+		target, err := urlRollbacker.GetRevision(host, alias, revision)
+		if err == nil {
+			err = save.CheckDestinations(target.Link(), urlChecker)
+		}
+		if err == nil {
+			rev, err = urlRollbacker.RollbackURL(host, alias, revision, actor)
+		}
+		if errors.Is(err, storage.ErrRevisionNotFound) {
+			log.Info("revision not found", slog.String("alias", alias), slog.Int64("revision", revision))
+
+			render.JSON(w, r, resp.Error("revision not found"))
+
+			return
+		}
+		var reqErr *save.RequestError
+		if errors.As(err, &reqErr) {
+			log.Info("revision rejected", slog.Int64("revision", revision), sl.Err(err))
+
+			render.JSON(w, r, reqErr.Response)
+
+			return
+		}
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrURLExists) {
+			log.Info("url already has another link", "alias", alias)
+
+			render.JSON(w, r, resp.Error("url already exists"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to roll back url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("url rolled back", slog.String("alias", alias), slog.Int64("to", revision), slog.Int64("revision", rev.Revision))
+
+		after := rev.Link()
+		eventPublisher.Publish(webhook.EventUpdated, after)
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionLinkRollback,
+			Domain: host,
+			Alias:  alias,
+			Before: audit.LinkOf(link),
+			After:  audit.LinkOf(after),
+		})
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Revision: history.NewRevision(rev),
+		})
+	}
+}
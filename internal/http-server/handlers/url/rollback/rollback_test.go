@@ -0,0 +1,143 @@
+package rollback_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/rollback"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/rollback/mocks"
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestRollbackHandler(t *testing.T) {
+	current := storage.Link{Domain: "go.example", Alias: "promo", URL: "https://example.com/new", Owner: "alice"}
+	rev := storage.Revision{
+		Domain:     "go.example",
+		Alias:      "promo",
+		Revision:   4,
+		Action:     storage.RevisionRollback,
+		URL:        "https://example.com/old",
+		Owner:      "alice",
+		RollbackOf: 2,
+		Actor:      "bob",
+		CreatedAt:  time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	target := storage.Revision{
+		Domain:   "go.example",
+		Alias:    "promo",
+		Revision: 2,
+		Action:   storage.RevisionUpdate,
+		URL:      "https://example.com/old",
+		Owner:    "alice",
+		Rules:    []storage.Rule{{Name: "ios", URL: "https://blocked.example/app"}},
+		Actor:    "alice",
+	}
+	blocked := &policy.Violation{Rule: policy.RuleThreat, Message: "destination is flagged as malware"}
+
+	cases := []struct {
+		name        string
+		path        string
+		getErr      error
+		revisionErr error
+		policyErr   error
+		respError   string
+	}{
+		{
+			name: "Rolled back",
+			path: "/url/promo/rollback/2",
+		},
+		{
+			name:      "Bad revision",
+			path:      "/url/promo/rollback/first",
+			respError: "invalid revision",
+		},
+		{
+			name:      "Unknown alias",
+			path:      "/url/promo/rollback/2",
+			getErr:    storage.ErrURLNotFound,
+			respError: "not found",
+		},
+		{
+			name:        "Unknown revision",
+			path:        "/url/promo/rollback/2",
+			revisionErr: storage.ErrRevisionNotFound,
+			respError:   "revision not found",
+		},
+		{
+			name:      "Blocked destination",
+			path:      "/url/promo/rollback/2",
+			policyErr: blocked,
+			respError: blocked.Message,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlRollbackerMock := mocks.NewURLRollbacker(t)
+			if tc.respError != "invalid revision" {
+				urlRollbackerMock.On("GetLink", "go.example", "promo").Return(current, tc.getErr).Once()
+			}
+			if tc.getErr == nil && tc.respError != "invalid revision" {
+				urlRollbackerMock.On("GetRevision", "go.example", "promo", int64(2)).Return(target, tc.revisionErr).Once()
+			}
+			if tc.respError == "" {
+				urlRollbackerMock.On("RollbackURL", "go.example", "promo", int64(2), "bob").Return(rev, nil).Once()
+			}
+
+			urlCheckerMock := saveMocks.NewURLChecker(t)
+			urlCheckerMock.On("CheckURL", "https://example.com/old").Return(nil).Maybe()
+			urlCheckerMock.On("CheckURL", "https://blocked.example/app").Return(tc.policyErr).Maybe()
+
+			eventPublisherMock := saveMocks.NewEventPublisher(t)
+			auditRecorderMock := saveMocks.NewAuditRecorder(t)
+			if tc.respError == "" {
+				eventPublisherMock.On("Publish", webhook.EventUpdated, rev.Link()).Once()
+				auditRecorderMock.On("Record", mock.Anything, audit.Entry{
+					Action: audit.ActionLinkRollback,
+					Domain: "go.example",
+					Alias:  "promo",
+					Before: audit.LinkOf(current),
+					After:  audit.LinkOf(rev.Link()),
+				}).Once()
+			}
+
+			accessCheckerMock := saveMocks.NewAccessChecker(t)
+			accessCheckerMock.On("CheckLink", "bob", "go.example", "promo", storage.RoleEdit).Return(nil).Maybe()
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/rollback/{revision}", rollback.New(slogdiscard.NewDiscardLogger(), urlRollbackerMock, accessCheckerMock, urlCheckerMock, eventPublisherMock, auditRecorderMock))
+
+			req := httptest.NewRequest(http.MethodPost, tc.path+"?domain=go.example", nil)
+			req.SetBasicAuth("bob", "secret")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var res rollback.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			assert.Equal(t, tc.respError, res.Error)
+			if tc.respError == "" {
+				assert.Equal(t, int64(4), res.Revision.Revision)
+				assert.Equal(t, int64(2), res.Revision.RollbackOf)
+			}
+			if tc.policyErr != nil {
+				require.Len(t, res.Errors, 1)
+				assert.Equal(t, "Rules[0].URL", res.Errors[0].Field)
+				assert.Equal(t, policy.RuleThreat, res.Errors[0].Rule)
+			}
+		})
+	}
+}
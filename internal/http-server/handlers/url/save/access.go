@@ -0,0 +1,45 @@
+package save
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Authorize checks that the user of r has at least role on the link under
+// alias on host. If not, it answers r itself and returns false.
+func Authorize(log *slog.Logger, w http.ResponseWriter, r *http.Request, accessChecker AccessChecker, host string, alias string, role string) bool {
+	user, _, _ := r.BasicAuth()
+
+	err := accessChecker.CheckLink(user, host, alias, role)
+	if errors.Is(err, access.ErrDenied) {
+		log.Info("access denied", slog.String("user", user), slog.String("alias", alias))
+
+		render.JSON(w, r, resp.Error("access denied"))
+
+		return false
+	}
+	if errors.Is(err, storage.ErrURLNotFound) {
+		log.Info("url not found", "alias", alias)
+
+		render.JSON(w, r, resp.Error("not found"))
+
+		return false
+	}
+	if err != nil {
+		log.Error("failed to check access", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("internal error"))
+
+		return false
+	}
+
+	return true
+}
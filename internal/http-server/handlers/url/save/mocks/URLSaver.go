@@ -2,30 +2,57 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	storage "github.com/MaximShildyakov/url-shortener/internal/storage"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // URLSaver is an autogenerated mock type for the URLSaver type
 type URLSaver struct {
 	mock.Mock
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *URLSaver) SaveURL(urlToSave string, alias string) (int64, error) {
-	ret := _m.Called(urlToSave, alias)
+// SaveURL provides a mock function with given fields: link
+func (_m *URLSaver) SaveURL(link storage.Link) (int64, error) {
+	ret := _m.Called(link)
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (int64, error)); ok {
-		return rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(storage.Link) (int64, error)); ok {
+		return rf(link)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) int64); ok {
-		r0 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(storage.Link) int64); ok {
+		r0 = rf(link)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(1).(func(storage.Link) error); ok {
+		r1 = rf(link)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAliasByNormalizedURL provides a mock function with given fields: owner, domain, normalizedURL
+func (_m *URLSaver) GetAliasByNormalizedURL(owner string, domain string, normalizedURL string) (string, error) {
+	ret := _m.Called(owner, domain, normalizedURL)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, string) (string, error)); ok {
+		return rf(owner, domain, normalizedURL)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(owner, domain, normalizedURL)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(owner, domain, normalizedURL)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -46,4 +73,4 @@ func NewURLSaver(t mockConstructorTestingTNewURLSaver) *URLSaver {
 	t.Cleanup(func() { mock.AssertExpectations(t) })
 
 	return mock
-}
\ No newline at end of file
+}
@@ -2,38 +2,135 @@ package save
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
-	"github.com/MaximShildyakov/url-shortener/internal/lib/random"
-
 	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
 	"github.com/MaximShildyakov/url-shortener/internal/storage"
 	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
-	"github.com/go-playground/validator/v10"
 	"golang.org/x/exp/slog"
 )
 
 type Request struct{
 	URL   string `json:"url" validate:"required,url"`
 	Alias string `json:"alias,omitempty" validate:"omitempty,min=3,max=20"`
+	// Domain is the short domain to create the link on, the default one
+	// if empty.
+	Domain string `json:"domain,omitempty"`
+	// Dedupe overrides Options.Dedupe for this request.
+	Dedupe *bool `json:"dedupe,omitempty"`
+	// Rules send matching visitors elsewhere than URL, first match wins.
+	Rules []storage.Rule `json:"rules,omitempty"`
+	// Variants split the remaining visitors between weighted destinations.
+	Variants []storage.Variant `json:"variants,omitempty"`
+	// Title, Description, Tags and Metadata describe the link for the
+	// people managing it.
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// Page makes the link a page shown at its alias instead of a
+	// redirect. URL, Rules and Variants must be empty then.
+	Page *storage.Page `json:"page,omitempty"`
 }
 
 type Response struct {
 	resp.Response
 	Alias  string `json:"alias,omitempty"`
+	// Existing is set when an earlier link to the same destination was
+	// returned instead of creating a new one.
+	Existing bool `json:"existing,omitempty"`
 }
 
-// TODO: move to config
-const aliasLength = 6
+// Options tune how links are saved.
+type Options struct {
+	// MaxAttempts bounds how many generated aliases are tried.
+	MaxAttempts int
+	// Dedupe makes saving a destination the user already shortened
+	// return the existing alias.
+	Dedupe bool
+	// KeepFragment keeps URL fragments significant when deduplicating.
+	KeepFragment bool
+}
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
 type URLSaver interface{
-	SaveURL(urlToSave string, alias string) (int64, error)
+	SaveURL(link storage.Link) (int64, error)
+	GetAliasByNormalizedURL(owner string, domain string, normalizedURL string) (string, error)
 }
 
-func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc{
+// AliasGenerator produces aliases for requests that don't specify one.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasGenerator
+type AliasGenerator interface {
+	Generate() (string, error)
+}
+
+// AliasChecker rejects aliases that may not be used, such as reserved or
+// blocked words.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasChecker
+type AliasChecker interface {
+	CheckAlias(alias string) error
+}
+
+// URLChecker rejects destinations that may not be shortened.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLChecker
+type URLChecker interface {
+	CheckURL(rawURL string) error
+}
+
+// DomainChecker rejects short domains links may not be created on.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DomainChecker
+type DomainChecker interface {
+	CheckDomain(host string) error
+}
+
+// EventPublisher announces link lifecycle events to webhooks. Publish must
+// not block.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=EventPublisher
+type EventPublisher interface {
+	Publish(event string, link storage.Link)
+}
+
+// AuditRecorder appends changes to the audit log, attributed to the
+// request's user.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditRecorder
+type AuditRecorder interface {
+	Record(r *http.Request, e audit.Entry)
+}
+
+// AccessChecker returns access.ErrDenied unless user has at least role on
+// the link under alias on domain. The handlers changing existing links
+// share it.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AccessChecker
+type AccessChecker interface {
+	CheckLink(user string, domain string, alias string, role string) error
+}
+
+// New returns a handler saving URLs. Generated aliases that collide with an
+// existing one or are rejected by aliasChecker are regenerated up to
+// opts.MaxAttempts times.
+func New(
+	log *slog.Logger,
+	urlSaver URLSaver,
+	aliasGenerator AliasGenerator,
+	aliasChecker AliasChecker,
+	urlChecker URLChecker,
+	domainChecker DomainChecker,
+	eventPublisher EventPublisher,
+	auditRecorder AuditRecorder,
+	opts Options,
+) http.HandlerFunc{
 	return func(w http.ResponseWriter, r *http.Request){
 		const fn = "handler.url.save.New"
 
@@ -55,27 +152,67 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc{
 
 		log.Info("request body decoded", slog.Any("request", req))
 
-		if err := validator.New().StructPartial(req, "URL"); err != nil {
-			log.Error("invalid URL", sl.Err(err))
-		
-			render.JSON(w, r, resp.Error("field URL is not a valid URL"))
+		owner, _, _ := r.BasicAuth()
+
+		link, err := Prepare(req, owner, aliasChecker, urlChecker, domainChecker, opts)
+		if err != nil {
+			var reqErr *RequestError
+			if errors.As(err, &reqErr) {
+				log.Info("request rejected", sl.Err(err))
+
+				render.JSON(w, r, reqErr.Response)
+
+				return
+			}
+
+			log.Error("failed to validate request", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to add url"))
+
 			return
 		}
 
-		alias := req.Alias
-		if alias == ""{
-			alias = random.NewRandomString(aliasLength)
+		dedupe := link.NormalizedURL != ""
+
+		if dedupe {
+			existing, err := urlSaver.GetAliasByNormalizedURL(owner, link.Domain, link.NormalizedURL)
+			if err == nil {
+				log.Info("url already shortened", slog.String("alias", existing))
+
+				responseExisting(w, r, existing)
+
+				return
+			}
+			if !errors.Is(err, storage.ErrURLNotFound) {
+				log.Error("failed to look up url", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("failed to add url"))
+
+				return
+			}
+		}
+
+		alias := link.Alias
+
+		var id int64
+		if alias != "" {
+			id, err = urlSaver.SaveURL(link)
 		} else {
-			if len(alias) < 3 || len(alias) > 20 {
-				log.Error("alias length invalid", slog.String("alias", alias))
-		
-				render.JSON(w, r, resp.Error("alias length must be between 3 and 20 characters"))
-		
+			alias, id, err = saveGenerated(log, urlSaver, aliasGenerator, aliasChecker, opts.MaxAttempts, link)
+		}
+
+		if dedupe && errors.Is(err, storage.ErrURLExists) {
+			// Another request saved the same destination since the lookup.
+			existing, lookupErr := urlSaver.GetAliasByNormalizedURL(owner, link.Domain, link.NormalizedURL)
+			if lookupErr == nil {
+				log.Info("url already shortened", slog.String("alias", existing))
+
+				responseExisting(w, r, existing)
+
 				return
 			}
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
 		if errors.Is(err, storage.ErrURLExists){
 			log.Info("url already exists", slog.String("url", req.URL))
 
@@ -102,15 +239,90 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc{
 
 		log.Info("url added", slog.Int64("id", id))
 
+		link.ID, link.Alias = id, alias
+		eventPublisher.Publish(webhook.EventCreated, link)
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionLinkCreate,
+			Domain: link.Domain,
+			Alias:  alias,
+			After:  audit.LinkOf(link),
+		})
+
 		responseOK(w, r, alias)
 
 
 	}
 }
 
+// Prepare validates req and builds the link to store for owner.
+func Prepare(
+	req Request,
+	owner string,
+	aliasChecker AliasChecker,
+	urlChecker URLChecker,
+	domainChecker DomainChecker,
+	opts Options,
+) (storage.Link, error) {
+	if err := Validate(req, aliasChecker, urlChecker, domainChecker); err != nil {
+		return storage.Link{}, err
+	}
+
+	return NewLink(req, owner, opts)
+}
+
+func saveGenerated(
+	log *slog.Logger,
+	urlSaver URLSaver,
+	aliasGenerator AliasGenerator,
+	aliasChecker AliasChecker,
+	maxAttempts int,
+	link storage.Link,
+) (string, int64, error) {
+	const fn = "handler.url.save.saveGenerated"
+
+	var err error
+	for attempt := 1; attempt <= max(maxAttempts, 1); attempt++ {
+		link.Alias, err = aliasGenerator.Generate()
+		if err != nil {
+			return "", 0, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if err = aliasChecker.CheckAlias(link.Alias); err != nil {
+			log.Info("generated alias rejected, retrying",
+				slog.String("alias", link.Alias),
+				slog.Int("attempt", attempt),
+				sl.Err(err),
+			)
+
+			continue
+		}
+
+		var id int64
+		id, err = urlSaver.SaveURL(link)
+		if !errors.Is(err, storage.ErrAliasExists) {
+			return link.Alias, id, err
+		}
+
+		log.Info("generated alias collides, retrying",
+			slog.String("alias", link.Alias),
+			slog.Int("attempt", attempt),
+		)
+	}
+
+	return "", 0, err
+}
+
 func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
 	render.JSON(w, r, Response{
 		Response: resp.OK(),
 		Alias:    alias,
 	})
+}
+
+func responseExisting(w http.ResponseWriter, r *http.Request, alias string) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+		Alias:    alias,
+		Existing: true,
+	})
 }
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
@@ -14,7 +15,16 @@ import (
 
 	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
 	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/aliasfilter"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
 	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/meta"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/page"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/targeting"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
 )
 
 func TestSaveHandler(t *testing.T) {
@@ -23,7 +33,11 @@ func TestSaveHandler(t *testing.T) {
 		alias     string
 		url       string
 		respError string
+		// respRule is the rule the field error reports, if one is expected.
+		respRule  string
 		mockError error
+		checkErr  error
+		policyErr error
 	}{
 		{
 			name:  "Success",
@@ -39,13 +53,15 @@ func TestSaveHandler(t *testing.T) {
 			name:      "Empty URL",
 			url:       "",
 			alias:     "some_alias",
-			respError: "field URL is a required field",
+			respError: "field URL is not a valid URL",
+			respRule:  "required",
 		},
 		{
 			name:      "Invalid URL",
 			url:       "some invalid URL",
 			alias:     "some_alias",
 			respError: "field URL is not a valid URL",
+			respRule:  "url",
 		},
 		{
 			name:      "SaveURL Error",
@@ -54,6 +70,23 @@ func TestSaveHandler(t *testing.T) {
 			respError: "failed to add url",
 			mockError: errors.New("unexpected error"),
 		},
+		{
+			name:      "Reserved alias",
+			alias:     "url",
+			url:       "https://google.com",
+			respError: "alias is reserved",
+			checkErr:  aliasfilter.ErrReserved,
+		},
+		{
+			name:      "Denied by policy",
+			alias:     "some_alias",
+			url:       "http://127.0.0.1/admin",
+			respError: "private and loopback addresses are not allowed",
+			policyErr: &policy.Violation{
+				Rule:    policy.RulePrivateIP,
+				Message: "private and loopback addresses are not allowed",
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -63,14 +96,51 @@ func TestSaveHandler(t *testing.T) {
 			t.Parallel()
 
 			urlSaverMock := mocks.NewURLSaver(t)
+			aliasGeneratorMock := mocks.NewAliasGenerator(t)
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+
+			aliasCheckerMock.On("CheckAlias", mock.AnythingOfType("string")).
+				Return(tc.checkErr).
+				Maybe()
+
+			urlCheckerMock := mocks.NewURLChecker(t)
+			urlCheckerMock.On("CheckURL", tc.url).
+				Return(tc.policyErr).
+				Maybe()
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+					return link.URL == tc.url && link.NormalizedURL == ""
+				})).
 					Return(int64(1), tc.mockError).
 					Once()
+
+				if tc.alias == "" {
+					aliasGeneratorMock.On("Generate").
+						Return("generated", nil).
+						Once()
+				}
+			}
+
+			eventPublisherMock := mocks.NewEventPublisher(t)
+			auditRecorderMock := mocks.NewAuditRecorder(t)
+			if tc.respError == "" {
+				wantAlias := tc.alias
+				if wantAlias == "" {
+					wantAlias = "generated"
+				}
+
+				eventPublisherMock.On("Publish", webhook.EventCreated, mock.MatchedBy(func(link storage.Link) bool {
+					return link.ID == 1 && link.Alias == wantAlias && link.URL == tc.url
+				})).Once()
+				auditRecorderMock.On("Record", mock.Anything, audit.Entry{
+					Action: audit.ActionLinkCreate,
+					Alias:  wantAlias,
+					After:  audit.Link{Alias: wantAlias, URL: tc.url},
+				}).Once()
 			}
 
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, aliasGeneratorMock, aliasCheckerMock, urlCheckerMock, mocks.NewDomainChecker(t), eventPublisherMock, auditRecorderMock, save.Options{MaxAttempts: 3})
 
 			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
 
@@ -90,7 +160,487 @@ func TestSaveHandler(t *testing.T) {
 
 			require.Equal(t, tc.respError, resp.Error)
 
+			if tc.respRule != "" {
+				require.Len(t, resp.Errors, 1)
+				require.Equal(t, "URL", resp.Errors[0].Field)
+				require.Equal(t, tc.respRule, resp.Errors[0].Rule)
+			}
+
 			// TODO: add more checks
 		})
 	}
-}
\ No newline at end of file
+}
+
+func allowAll(t *testing.T) *mocks.URLChecker {
+	urlCheckerMock := mocks.NewURLChecker(t)
+	urlCheckerMock.On("CheckURL", mock.AnythingOfType("string")).Return(nil).Maybe()
+
+	return urlCheckerMock
+}
+
+func anyEvents(t *testing.T) *mocks.EventPublisher {
+	eventPublisherMock := mocks.NewEventPublisher(t)
+	eventPublisherMock.On("Publish", mock.Anything, mock.Anything).Maybe()
+
+	return eventPublisherMock
+}
+
+func anyAudit(t *testing.T) *mocks.AuditRecorder {
+	auditRecorderMock := mocks.NewAuditRecorder(t)
+	auditRecorderMock.On("Record", mock.Anything, mock.Anything).Maybe()
+
+	return auditRecorderMock
+}
+
+func TestSaveHandler_GeneratedAliasCollision(t *testing.T) {
+	cases := []struct {
+		name      string
+		saveErrs  []error
+		checkErrs []error
+		alias     string
+		respError string
+	}{
+		{
+			name:     "Retry succeeds",
+			saveErrs: []error{storage.ErrAliasExists, nil},
+			alias:    "alias2",
+		},
+		{
+			name:      "Blocked alias regenerated",
+			saveErrs:  []error{nil},
+			checkErrs: []error{aliasfilter.ErrBlocked},
+			alias:     "alias2",
+		},
+		{
+			name:      "Attempts exhausted",
+			saveErrs:  []error{storage.ErrAliasExists, storage.ErrAliasExists, storage.ErrAliasExists},
+			respError: "alias already exists",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			aliasGeneratorMock := mocks.NewAliasGenerator(t)
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+
+			n := 0
+			for _, checkErr := range tc.checkErrs {
+				n++
+				alias := fmt.Sprintf("alias%d", n)
+
+				aliasGeneratorMock.On("Generate").Return(alias, nil).Once()
+				aliasCheckerMock.On("CheckAlias", alias).Return(checkErr).Once()
+			}
+			for _, saveErr := range tc.saveErrs {
+				n++
+				alias := fmt.Sprintf("alias%d", n)
+
+				aliasGeneratorMock.On("Generate").Return(alias, nil).Once()
+				aliasCheckerMock.On("CheckAlias", alias).Return(nil).Once()
+				urlSaverMock.On("SaveURL", storage.Link{URL: "https://google.com", Alias: alias}).
+					Return(int64(1), saveErr).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, aliasGeneratorMock, aliasCheckerMock, allowAll(t), mocks.NewDomainChecker(t), anyEvents(t), anyAudit(t), save.Options{MaxAttempts: 3})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.alias, resp.Alias)
+		})
+	}
+}
+
+func TestSaveHandler_Dedupe(t *testing.T) {
+	const normalized = "https://example.com/?a=1&b=2"
+
+	cases := []struct {
+		name      string
+		input     string
+		lookup    string
+		lookupErr error
+		saveErr   error
+		alias     string
+		existing  bool
+	}{
+		{
+			name:     "Existing link returned",
+			input:    `{"url": "HTTPS://Example.com:443?b=2&a=1#top", "dedupe": true}`,
+			lookup:   "existing",
+			alias:    "existing",
+			existing: true,
+		},
+		{
+			name:      "New link saved",
+			input:     `{"url": "https://example.com/?b=2&a=1", "alias": "fresh", "dedupe": true}`,
+			lookupErr: storage.ErrURLNotFound,
+			alias:     "fresh",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+
+			aliasCheckerMock.On("CheckAlias", mock.AnythingOfType("string")).Return(nil).Maybe()
+
+			urlSaverMock.On("GetAliasByNormalizedURL", "user", "", normalized).
+				Return(tc.lookup, tc.lookupErr).
+				Once()
+
+			if tc.lookupErr != nil {
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+					return link.Owner == "user" && link.NormalizedURL == normalized
+				})).
+					Return(int64(1), tc.saveErr).
+					Once()
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				mocks.NewAliasGenerator(t),
+				aliasCheckerMock,
+				allowAll(t),
+				mocks.NewDomainChecker(t),
+				anyEvents(t),
+				anyAudit(t),
+				save.Options{MaxAttempts: 3},
+			)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req.SetBasicAuth("user", "pass")
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Empty(t, resp.Error)
+			require.Equal(t, tc.alias, resp.Alias)
+			require.Equal(t, tc.existing, resp.Existing)
+		})
+	}
+}
+
+func TestSaveHandler_Domain(t *testing.T) {
+	cases := []struct {
+		name      string
+		domain    string
+		checkErr  error
+		respError string
+	}{
+		{
+			name:   "Registered domain",
+			domain: "Go.Example",
+		},
+		{
+			name:      "Unknown domain",
+			domain:    "other.example",
+			checkErr:  domain.ErrUnknownDomain,
+			respError: domain.ErrUnknownDomain.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+			aliasCheckerMock.On("CheckAlias", "docs").Return(nil).Maybe()
+
+			domainCheckerMock := mocks.NewDomainChecker(t)
+			domainCheckerMock.On("CheckDomain", tc.domain).Return(tc.checkErr).Once()
+
+			if tc.checkErr == nil {
+				urlSaverMock.On("SaveURL", storage.Link{Domain: "go.example", Alias: "docs", URL: "https://docs.example"}).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				mocks.NewAliasGenerator(t),
+				aliasCheckerMock,
+				allowAll(t),
+				domainCheckerMock,
+				anyEvents(t),
+				anyAudit(t),
+				save.Options{MaxAttempts: 3},
+			)
+
+			input := fmt.Sprintf(`{"url": "https://docs.example", "alias": "docs", "domain": "%s"}`, tc.domain)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Rules(t *testing.T) {
+	cases := []struct {
+		name      string
+		rules     string
+		policyErr error
+		respError string
+	}{
+		{
+			name:  "Valid rules",
+			rules: `[{"name": "ios", "platforms": ["ios"], "url": "https://apps.example/ios"}]`,
+		},
+		{
+			name:      "Rule without condition",
+			rules:     `[{"url": "https://apps.example/ios"}]`,
+			respError: "rule rule-1: " + targeting.ErrNoCondition.Error(),
+		},
+		{
+			name:      "Invalid rule URL",
+			rules:     `[{"platforms": ["ios"], "url": "not a url"}]`,
+			respError: "field Rules[0].URL is not a valid URL",
+		},
+		{
+			name:      "Variants without weight",
+			rules:     `[{"platforms": ["ios"], "url": "https://apps.example/ios"}], "variants": [{"url": "https://example.com/a"}]`,
+			respError: "at least one variant needs a positive weight",
+		},
+		{
+			name:  "Rule URL denied by policy",
+			rules: `[{"platforms": ["ios"], "url": "https://apps.example/ios"}]`,
+			policyErr: &policy.Violation{
+				Rule:    policy.RuleDeniedHost,
+				Message: "host is not allowed",
+			},
+			respError: "host is not allowed",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+			aliasCheckerMock.On("CheckAlias", "app").Return(nil).Maybe()
+
+			urlCheckerMock := mocks.NewURLChecker(t)
+			urlCheckerMock.On("CheckURL", "https://example.com/app").Return(nil).Once()
+			urlCheckerMock.On("CheckURL", "https://apps.example/ios").Return(tc.policyErr).Maybe()
+
+			if tc.respError == "" {
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+					return len(link.Rules) == 1 && link.Rules[0].URL == "https://apps.example/ios"
+				})).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				mocks.NewAliasGenerator(t),
+				aliasCheckerMock,
+				urlCheckerMock,
+				mocks.NewDomainChecker(t),
+				anyEvents(t),
+				anyAudit(t),
+				save.Options{MaxAttempts: 3},
+			)
+
+			input := fmt.Sprintf(`{"url": "https://example.com/app", "alias": "app", "rules": %s}`, tc.rules)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Page(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		respError string
+	}{
+		{
+			name:  "Page",
+			input: `{"alias": "jane", "page": {"title": "Jane", "theme": "dark", "buttons": [{"label": "Blog", "url": "https://example.com/blog"}]}}`,
+		},
+		{
+			name:      "Page with a url",
+			input:     `{"alias": "jane", "url": "https://example.com", "page": {"buttons": [{"label": "Blog", "url": "https://example.com/blog"}]}}`,
+			respError: page.ErrHasDestination.Error(),
+		},
+		{
+			name:      "Page without buttons",
+			input:     `{"alias": "jane", "page": {"title": "Jane"}}`,
+			respError: page.ErrNoButtons.Error(),
+		},
+		{
+			name:      "Invalid button URL",
+			input:     `{"alias": "jane", "page": {"buttons": [{"label": "Blog", "url": "not a url"}]}}`,
+			respError: "field Page.Buttons[0].URL is not a valid URL",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+			aliasCheckerMock.On("CheckAlias", "jane").Return(nil).Maybe()
+
+			urlCheckerMock := mocks.NewURLChecker(t)
+			urlCheckerMock.On("CheckURL", "https://example.com/blog").Return(nil).Maybe()
+
+			if tc.respError == "" {
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+					return link.URL == "" && link.NormalizedURL == "" && link.Page != nil &&
+						link.Page.Theme == storage.ThemeDark && len(link.Page.Buttons) == 1
+				})).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				mocks.NewAliasGenerator(t),
+				aliasCheckerMock,
+				urlCheckerMock,
+				mocks.NewDomainChecker(t),
+				anyEvents(t),
+				anyAudit(t),
+				save.Options{MaxAttempts: 3, Dedupe: true},
+			)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Details(t *testing.T) {
+	cases := []struct {
+		name      string
+		details   string
+		tags      []string
+		respError string
+	}{
+		{
+			name:    "Tags normalized",
+			details: `"title": " Launch ", "tags": ["Promo", "spring-2026", "promo"]`,
+			tags:    []string{"promo", "spring-2026"},
+		},
+		{
+			name:      "Invalid tag",
+			details:   `"tags": ["no spaces"]`,
+			respError: meta.ErrInvalidTag.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+			aliasCheckerMock.On("CheckAlias", "app").Return(nil).Maybe()
+
+			urlCheckerMock := mocks.NewURLChecker(t)
+			urlCheckerMock.On("CheckURL", "https://example.com/app").Return(nil).Maybe()
+
+			if tc.respError == "" {
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+					return link.Title == "Launch" && reflect.DeepEqual(link.Tags, tc.tags)
+				})).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				mocks.NewAliasGenerator(t),
+				aliasCheckerMock,
+				urlCheckerMock,
+				mocks.NewDomainChecker(t),
+				anyEvents(t),
+				anyAudit(t),
+				save.Options{MaxAttempts: 3},
+			)
+
+			input := fmt.Sprintf(`{"url": "https://example.com/app", "alias": "app", %s}`, tc.details)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package save
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/alias"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/meta"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/page"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/split"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/targeting"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/urlnorm"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// RequestError is a request rejected before reaching storage. Response is
+// what the client should see.
+type RequestError struct {
+	Response resp.Response
+}
+
+func (e *RequestError) Error() string {
+	return e.Response.Error
+}
+
+func rejected(r resp.Response) error {
+	return &RequestError{Response: r}
+}
+
+// Validate checks req's URL, targeting rules, variants or page, domain and alias. Rejections are
+// returned as *RequestError; any other error means a checker failed.
+func Validate(req Request, aliasChecker AliasChecker, urlChecker URLChecker, domainChecker DomainChecker) error {
+	const fn = "handler.url.save.Validate"
+
+	if req.Page != nil {
+		if err := validatePage(req, urlChecker); err != nil {
+			return err
+		}
+	} else if err := validateRedirect(req, urlChecker); err != nil {
+		return err
+	}
+
+	if req.Domain != "" {
+		if err := domainChecker.CheckDomain(req.Domain); err != nil {
+			if !errors.Is(err, domain.ErrUnknownDomain) {
+				return fmt.Errorf("%s: %w", fn, err)
+			}
+
+			return rejected(resp.Error(err.Error()))
+		}
+	}
+
+	if req.Alias == "" {
+		return nil
+	}
+
+	if err := alias.CheckLength(req.Alias); err != nil {
+		return rejected(resp.Error(err.Error()))
+	}
+
+	if err := aliasChecker.CheckAlias(req.Alias); err != nil {
+		return rejected(resp.Error(err.Error()))
+	}
+
+	return nil
+}
+
+// validateRedirect checks the URL, targeting rules and variants of a
+// request for a redirecting link.
+func validateRedirect(req Request, urlChecker URLChecker) error {
+	const fn = "handler.url.save.validateRedirect"
+
+	if err := validator.New().StructPartial(req, "URL"); err != nil {
+		// The error stays the same whichever rule failed; errors tells
+		// them apart.
+		res := resp.Error("field URL is not a valid URL")
+
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			res.Errors = resp.ValidationError(validateErr).Errors
+		}
+
+		return rejected(res)
+	}
+
+	if err := urlChecker.CheckURL(req.URL); err != nil {
+		var violation *policy.Violation
+		if !errors.As(err, &violation) {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+
+		return rejected(resp.FieldErrors(resp.FieldError{
+			Field:   "URL",
+			Rule:    violation.Rule,
+			Message: violation.Message,
+		}))
+	}
+
+	return validateTargets(req, urlChecker)
+}
+
+// validatePage checks the page of a request for a page link, running its
+// button URLs through urlChecker like a link's own URL.
+func validatePage(req Request, urlChecker URLChecker) error {
+	if req.URL != "" || len(req.Rules) > 0 || len(req.Variants) > 0 {
+		return rejected(resp.Error(page.ErrHasDestination.Error()))
+	}
+
+	if err := page.Validate(*req.Page); err != nil {
+		return rejected(resp.Error(err.Error()))
+	}
+
+	for i, b := range req.Page.Buttons {
+		if err := checkDestination(fmt.Sprintf("Page.Buttons[%d].URL", i), b.URL, urlChecker); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTargets checks the targeting rules and A/B variants, running
+// their destinations through urlChecker like the link's own URL.
+func validateTargets(req Request, urlChecker URLChecker) error {
+	if err := targeting.Validate(req.Rules); err != nil {
+		return rejected(resp.Error(err.Error()))
+	}
+
+	for i, rule := range req.Rules {
+		if err := checkDestination(fmt.Sprintf("Rules[%d].URL", i), rule.URL, urlChecker); err != nil {
+			return err
+		}
+	}
+
+	return ValidateVariants(req.Variants, urlChecker)
+}
+
+// ValidateVariants checks A/B variants and runs their destinations through
+// urlChecker. Rejections are returned as *RequestError.
+func ValidateVariants(variants []storage.Variant, urlChecker URLChecker) error {
+	if err := split.Validate(variants); err != nil {
+		return rejected(resp.Error(err.Error()))
+	}
+
+	for i, v := range variants {
+		if err := checkDestination(fmt.Sprintf("Variants[%d].URL", i), v.URL, urlChecker); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckDestinations runs every destination of link, stored earlier, through
+// urlChecker, as policies may have changed since. Rejections are returned
+// as *RequestError.
+func CheckDestinations(link storage.Link, urlChecker URLChecker) error {
+	if link.Page != nil {
+		for i, b := range link.Page.Buttons {
+			if err := checkDestination(fmt.Sprintf("Page.Buttons[%d].URL", i), b.URL, urlChecker); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := checkDestination("URL", link.URL, urlChecker); err != nil {
+		return err
+	}
+
+	for i, rule := range link.Rules {
+		if err := checkDestination(fmt.Sprintf("Rules[%d].URL", i), rule.URL, urlChecker); err != nil {
+			return err
+		}
+	}
+
+	for i, v := range link.Variants {
+		if err := checkDestination(fmt.Sprintf("Variants[%d].URL", i), v.URL, urlChecker); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkDestination validates the URL in field.
+func checkDestination(field string, rawURL string, urlChecker URLChecker) error {
+	const fn = "handler.url.save.checkDestination"
+
+	if err := validator.New().Var(rawURL, "url"); err != nil {
+		return rejected(resp.FieldErrors(resp.FieldError{
+			Field:   field,
+			Rule:    "url",
+			Message: fmt.Sprintf("field %s is not a valid URL", field),
+		}))
+	}
+
+	if err := urlChecker.CheckURL(rawURL); err != nil {
+		var violation *policy.Violation
+		if !errors.As(err, &violation) {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+
+		return rejected(resp.FieldErrors(resp.FieldError{
+			Field:   field,
+			Rule:    violation.Rule,
+			Message: violation.Message,
+		}))
+	}
+
+	return nil
+}
+
+// NewLink builds the link to store for a validated req, checking its
+// descriptive fields and normalizing its tags. NormalizedURL is set only
+// when the request is deduplicated; pages never are.
+func NewLink(req Request, owner string, opts Options) (storage.Link, error) {
+	link := storage.Link{
+		Domain:      domain.Normalize(req.Domain),
+		Alias:       req.Alias,
+		URL:         req.URL,
+		Owner:       owner,
+		Rules:       req.Rules,
+		Variants:    req.Variants,
+		Title:       req.Title,
+		Description: req.Description,
+		Tags:        req.Tags,
+		Metadata:    req.Metadata,
+		Page:        req.Page,
+	}
+
+	if err := meta.Clean(&link); err != nil {
+		return storage.Link{}, rejected(resp.Error(err.Error()))
+	}
+
+	dedupe := opts.Dedupe
+	if req.Dedupe != nil {
+		dedupe = *req.Dedupe
+	}
+
+	if !dedupe || link.Page != nil {
+		return link, nil
+	}
+
+	normalized, err := urlnorm.Normalize(req.URL, opts.KeepFragment)
+	if err != nil {
+		return storage.Link{}, rejected(resp.Error("field URL is not a valid URL"))
+	}
+	link.NormalizedURL = normalized
+
+	return link, nil
+}
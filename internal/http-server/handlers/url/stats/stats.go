@@ -0,0 +1,223 @@
+package stats
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/page"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/targeting"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// RuleStats counts the clicks a targeting rule sent to its URL.
+type RuleStats struct {
+	Rule   string `json:"rule"`
+	URL    string `json:"url,omitempty"`
+	Clicks int64  `json:"clicks"`
+	// Removed marks rules no longer on the link that still have clicks.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// ButtonStats counts the clicks on a page button.
+type ButtonStats struct {
+	Button string `json:"button"`
+	Label  string `json:"label,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Clicks int64  `json:"clicks"`
+	// Removed marks buttons no longer on the page that still have clicks.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// LocationStats counts the clicks from one country and region. Empty codes
+// stand for visitors that could not be located.
+type LocationStats struct {
+	Country string `json:"country"`
+	Region  string `json:"region,omitempty"`
+	Clicks  int64  `json:"clicks"`
+}
+
+type Response struct {
+	resp.Response
+	Domain     string          `json:"domain,omitempty"`
+	Alias      string          `json:"alias"`
+	URL        string          `json:"url"`
+	Clicks     int64           `json:"clicks"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ByRule     []RuleStats     `json:"by_rule"`
+	ByButton   []ButtonStats   `json:"by_button,omitempty"`
+	ByLocation []LocationStats `json:"by_location"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=StatsGetter
+type StatsGetter interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+	GetRuleClicks(domain string, alias string) (map[string]int64, error)
+	GetButtonClicks(domain string, alias string) (map[string]int64, error)
+	GetGeoClicks(domain string, alias string) ([]storage.GeoClicks, error)
+}
+
+// New returns a handler reporting the clicks on a link, broken down by the
+// targeting rule that matched and by the visitor's location. Clicks on the
+// link's own URL are counted under the default rule. Clicks on a page are
+// also broken down by button. ?domain selects the short domain the alias
+// is on; the default domain otherwise.
+//
+// Users other than the admin need read rights on the link.
+func New(log *slog.Logger, statsGetter StatsGetter, accessChecker save.AccessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.stats.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleRead) {
+			return
+		}
+
+		link, err := statsGetter.GetLink(host, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get link", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		clicks, err := statsGetter.GetRuleClicks(host, alias)
+		if err != nil {
+			log.Error("failed to get rule clicks", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		var buttons []ButtonStats
+		if link.Page != nil {
+			buttonClicks, err := statsGetter.GetButtonClicks(host, alias)
+			if err != nil {
+				log.Error("failed to get button clicks", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("internal error"))
+
+				return
+			}
+
+			buttons = byButton(*link.Page, buttonClicks)
+		}
+
+		geoClicks, err := statsGetter.GetGeoClicks(host, alias)
+		if err != nil {
+			log.Error("failed to get geo clicks", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		byLocation := make([]LocationStats, 0, len(geoClicks))
+		for _, c := range geoClicks {
+			byLocation = append(byLocation, LocationStats{Country: c.Country, Region: c.Region, Clicks: c.Clicks})
+		}
+
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Domain:     link.Domain,
+			Alias:      link.Alias,
+			URL:        link.URL,
+			Clicks:     link.Clicks,
+			CreatedAt:  link.CreatedAt,
+			ByRule:     byRule(link, clicks),
+			ByButton:   buttons,
+			ByLocation: byLocation,
+		})
+	}
+}
+
+// byRule lists the link's rules in evaluation order, then the default,
+// then removed rules that were clicked.
+func byRule(link storage.Link, clicks map[string]int64) []RuleStats {
+	stats := make([]RuleStats, 0, len(link.Rules)+1)
+	seen := make(map[string]bool, len(link.Rules)+1)
+
+	for i, rule := range link.Rules {
+		name := targeting.RuleName(i, rule)
+		stats = append(stats, RuleStats{Rule: name, URL: rule.URL, Clicks: clicks[name]})
+		seen[name] = true
+	}
+
+	stats = append(stats, RuleStats{Rule: storage.DefaultRule, URL: link.URL, Clicks: clicks[storage.DefaultRule]})
+	seen[storage.DefaultRule] = true
+
+	var removed []string
+	for name := range clicks {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, name := range removed {
+		stats = append(stats, RuleStats{Rule: name, Clicks: clicks[name], Removed: true})
+	}
+
+	return stats
+}
+
+// byButton lists the page's buttons in order, then removed buttons that
+// were clicked.
+func byButton(p storage.Page, clicks map[string]int64) []ButtonStats {
+	stats := make([]ButtonStats, 0, len(p.Buttons))
+	seen := make(map[string]bool, len(p.Buttons))
+
+	for i, b := range p.Buttons {
+		name := page.ButtonName(i, b)
+		stats = append(stats, ButtonStats{Button: name, Label: b.Label, URL: b.URL, Clicks: clicks[name]})
+		seen[name] = true
+	}
+
+	var removed []string
+	for name := range clicks {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, name := range removed {
+		stats = append(stats, ButtonStats{Button: name, Clicks: clicks[name], Removed: true})
+	}
+
+	return stats
+}
@@ -0,0 +1,141 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/stats"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/stats/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// allowRead lets any user read the link.
+func allowRead(t *testing.T) *saveMocks.AccessChecker {
+	accessCheckerMock := saveMocks.NewAccessChecker(t)
+	accessCheckerMock.On("CheckLink", mock.Anything, mock.Anything, mock.Anything, storage.RoleRead).Return(nil).Maybe()
+
+	return accessCheckerMock
+}
+
+func TestStatsHandler(t *testing.T) {
+	link := storage.Link{
+		Domain: "go.example",
+		Alias:  "app",
+		URL:    "https://example.com/app",
+		Clicks: 10,
+		Rules: []storage.Rule{
+			{Name: "ios", Platforms: []string{"ios"}, URL: "https://apps.apple.com/app"},
+			{Platforms: []string{"android"}, URL: "https://play.google.com/app"},
+		},
+	}
+
+	statsGetterMock := mocks.NewStatsGetter(t)
+	statsGetterMock.On("GetLink", "go.example", "app").Return(link, nil).Once()
+	statsGetterMock.On("GetRuleClicks", "go.example", "app").
+		Return(map[string]int64{"ios": 4, storage.DefaultRule: 3, "web": 3}, nil).
+		Once()
+	statsGetterMock.On("GetGeoClicks", "go.example", "app").
+		Return([]storage.GeoClicks{{Country: "US", Region: "US-CA", Clicks: 7}, {Clicks: 3}}, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), statsGetterMock, allowRead(t)))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/app/stats?domain=Go.Example", nil))
+
+	var resp stats.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, int64(10), resp.Clicks)
+	assert.Equal(t, []stats.RuleStats{
+		{Rule: "ios", URL: "https://apps.apple.com/app", Clicks: 4},
+		{Rule: "rule-2", URL: "https://play.google.com/app"},
+		{Rule: storage.DefaultRule, URL: "https://example.com/app", Clicks: 3},
+		{Rule: "web", Clicks: 3, Removed: true},
+	}, resp.ByRule)
+	assert.Equal(t, []stats.LocationStats{
+		{Country: "US", Region: "US-CA", Clicks: 7},
+		{Clicks: 3},
+	}, resp.ByLocation)
+}
+
+func TestStatsHandler_Page(t *testing.T) {
+	link := storage.Link{
+		Alias:  "jane",
+		Clicks: 6,
+		Page: &storage.Page{Buttons: []storage.Button{
+			{Label: "Blog", URL: "https://example.com/blog"},
+			{Name: "shop", Label: "Shop", URL: "https://shop.example.com/"},
+		}},
+	}
+
+	statsGetterMock := mocks.NewStatsGetter(t)
+	statsGetterMock.On("GetLink", "", "jane").Return(link, nil).Once()
+	statsGetterMock.On("GetRuleClicks", "", "jane").Return(map[string]int64{}, nil).Once()
+	statsGetterMock.On("GetButtonClicks", "", "jane").
+		Return(map[string]int64{"shop": 4, "button-1": 1, "old": 1}, nil).
+		Once()
+	statsGetterMock.On("GetGeoClicks", "", "jane").Return([]storage.GeoClicks{{Clicks: 6}}, nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), statsGetterMock, allowRead(t)))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/jane/stats", nil))
+
+	var resp stats.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, []stats.ButtonStats{
+		{Button: "button-1", Label: "Blog", URL: "https://example.com/blog", Clicks: 1},
+		{Button: "shop", Label: "Shop", URL: "https://shop.example.com/", Clicks: 4},
+		{Button: "old", Clicks: 1, Removed: true},
+	}, resp.ByButton)
+}
+
+func TestStatsHandler_NotFound(t *testing.T) {
+	statsGetterMock := mocks.NewStatsGetter(t)
+	statsGetterMock.On("GetLink", "", "missing").Return(storage.Link{}, storage.ErrURLNotFound).Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), statsGetterMock, allowRead(t)))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/missing/stats", nil))
+
+	var resp stats.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Equal(t, "not found", resp.Error)
+}
+
+func TestStatsHandler_AccessDenied(t *testing.T) {
+	accessCheckerMock := saveMocks.NewAccessChecker(t)
+	accessCheckerMock.On("CheckLink", "bob", "", "app", storage.RoleRead).Return(access.ErrDenied).Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), mocks.NewStatsGetter(t), accessCheckerMock))
+
+	req := httptest.NewRequest(http.MethodGet, "/url/app/stats", nil)
+	req.SetBasicAuth("bob", "secret")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	var resp stats.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Equal(t, "access denied", resp.Error)
+}
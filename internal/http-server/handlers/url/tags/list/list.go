@@ -0,0 +1,75 @@
+package list
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Tag struct {
+	Tag    string `json:"tag"`
+	Links  int64  `json:"links"`
+	Clicks int64  `json:"clicks"`
+}
+
+type Response struct {
+	resp.Response
+	Tags []Tag `json:"tags"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TagLister
+type TagLister interface {
+	ListTagStats(user string) ([]storage.TagStats, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AdminChecker
+type AdminChecker interface {
+	IsAdmin(user string) bool
+}
+
+// New returns a handler listing the tags in use with how many links carry
+// each and their clicks, the most clicked first. Links in the trash are
+// left out.
+//
+// Users other than the admin only count the links they own or have rights
+// on through a collection.
+func New(log *slog.Logger, tagLister TagLister, adminChecker AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.tags.list.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, _, _ := r.BasicAuth()
+		if adminChecker.IsAdmin(user) {
+			user = ""
+		}
+
+		stats, err := tagLister.ListTagStats(user)
+		if err != nil {
+			log.Error("failed to list tags", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		tags := make([]Tag, 0, len(stats))
+		for _, st := range stats {
+			tags = append(tags, Tag{Tag: st.Tag, Links: st.Links, Clicks: st.Clicks})
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Tags:     tags,
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package list_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/tags/list"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/tags/list/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestListHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		user   string
+		admin  bool
+		viewer string
+	}{
+		{name: "Admin counts every link", user: "admin", admin: true},
+		{name: "Other users count what they may read", user: "bob", viewer: "bob"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tagListerMock := mocks.NewTagLister(t)
+			tagListerMock.On("ListTagStats", tc.viewer).
+				Return([]storage.TagStats{{Tag: "spring", Links: 2, Clicks: 5}}, nil).Once()
+
+			adminCheckerMock := mocks.NewAdminChecker(t)
+			adminCheckerMock.On("IsAdmin", tc.user).Return(tc.admin).Once()
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), tagListerMock, adminCheckerMock)
+
+			req := httptest.NewRequest(http.MethodGet, "/url/tags", nil)
+			req.SetBasicAuth(tc.user, "secret")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Empty(t, resp.Error)
+			assert.Equal(t, []list.Tag{{Tag: "spring", Links: 2, Clicks: 5}}, resp.Tags)
+		})
+	}
+}
+
+func TestListHandler_NoTags(t *testing.T) {
+	tagListerMock := mocks.NewTagLister(t)
+	tagListerMock.On("ListTagStats", "bob").Return(nil, nil).Once()
+
+	adminCheckerMock := mocks.NewAdminChecker(t)
+	adminCheckerMock.On("IsAdmin", "bob").Return(false).Once()
+
+	handler := list.New(slogdiscard.NewDiscardLogger(), tagListerMock, adminCheckerMock)
+
+	req := httptest.NewRequest(http.MethodGet, "/url/tags", nil)
+	req.SetBasicAuth("bob", "secret")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.JSONEq(t, `{"status":"OK","tags":[]}`, rr.Body.String())
+}
@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	linkStats "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/stats"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/meta"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Tag        string                    `json:"tag"`
+	Links      int64                     `json:"links"`
+	Clicks     int64                     `json:"clicks"`
+	ByLocation []linkStats.LocationStats `json:"by_location"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TagStatsGetter
+type TagStatsGetter interface {
+	GetTagStats(tag string, user string) (storage.TagStats, error)
+	GetTagGeoClicks(tag string, user string) ([]storage.GeoClicks, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AdminChecker
+type AdminChecker interface {
+	IsAdmin(user string) bool
+}
+
+// New returns a handler adding up the clicks on the links carrying a tag,
+// in total and by the visitor's location. Links in the trash are left out.
+//
+// Users other than the admin only count the links they own or have rights
+// on through a collection.
+func New(log *slog.Logger, tagStatsGetter TagStatsGetter, adminChecker AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.tags.stats.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		tag := meta.NormalizeTag(chi.URLParam(r, "tag"))
+		if err := meta.ValidateTag(tag); err != nil {
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		user, _, _ := r.BasicAuth()
+		if adminChecker.IsAdmin(user) {
+			user = ""
+		}
+
+		st, err := tagStatsGetter.GetTagStats(tag, user)
+		if err != nil {
+			log.Error("failed to get tag stats", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		if st.Links == 0 {
+			log.Info("tag not found", slog.String("tag", tag))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+
+		geoClicks, err := tagStatsGetter.GetTagGeoClicks(tag, user)
+		if err != nil {
+			log.Error("failed to get geo clicks", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		byLocation := make([]linkStats.LocationStats, 0, len(geoClicks))
+		for _, c := range geoClicks {
+			byLocation = append(byLocation, linkStats.LocationStats{Country: c.Country, Region: c.Region, Clicks: c.Clicks})
+		}
+
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Tag:        tag,
+			Links:      st.Links,
+			Clicks:     st.Clicks,
+			ByLocation: byLocation,
+		})
+	}
+}
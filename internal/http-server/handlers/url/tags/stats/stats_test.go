@@ -0,0 +1,114 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	linkStats "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/stats"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/tags/stats"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/tags/stats/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/meta"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestStatsHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		user      string
+		admin     bool
+		viewer    string
+		path      string
+		links     int64
+		respError string
+	}{
+		{
+			name:  "Admin counts every link",
+			user:  "admin",
+			admin: true,
+			path:  "/url/tags/Spring/stats",
+			links: 2,
+		},
+		{
+			name:   "Other users count what they may read",
+			user:   "bob",
+			viewer: "bob",
+			path:   "/url/tags/spring/stats",
+			links:  2,
+		},
+		{
+			name:      "Empty tag",
+			user:      "bob",
+			path:      "/url/tags/%20/stats",
+			respError: meta.ErrEmptyTag.Error(),
+		},
+		{
+			name:      "Invalid tag",
+			user:      "bob",
+			path:      "/url/tags/no%20spaces/stats",
+			respError: meta.ErrInvalidTag.Error(),
+		},
+		{
+			name:      "Unknown tag",
+			user:      "bob",
+			viewer:    "bob",
+			path:      "/url/tags/spring/stats",
+			respError: "not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			validTag := tc.links > 0 || tc.respError == "not found"
+
+			tagStatsGetterMock := mocks.NewTagStatsGetter(t)
+			if validTag {
+				tagStatsGetterMock.On("GetTagStats", "spring", tc.viewer).
+					Return(storage.TagStats{Tag: "spring", Links: tc.links, Clicks: 5}, nil).Once()
+			}
+			if tc.links > 0 {
+				tagStatsGetterMock.On("GetTagGeoClicks", "spring", tc.viewer).
+					Return([]storage.GeoClicks{{Country: "DE", Region: "BE", Clicks: 3}, {Clicks: 2}}, nil).Once()
+			}
+
+			adminCheckerMock := mocks.NewAdminChecker(t)
+			if validTag {
+				adminCheckerMock.On("IsAdmin", tc.user).Return(tc.admin).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/url/tags/{tag}/stats", stats.New(slogdiscard.NewDiscardLogger(), tagStatsGetterMock, adminCheckerMock))
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.SetBasicAuth(tc.user, "secret")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var resp stats.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			if tc.respError != "" {
+				return
+			}
+
+			assert.Equal(t, "spring", resp.Tag)
+			assert.Equal(t, int64(2), resp.Links)
+			assert.Equal(t, int64(5), resp.Clicks)
+			assert.Equal(t, []linkStats.LocationStats{
+				{Country: "DE", Region: "BE", Clicks: 3},
+				{Clicks: 2},
+			}, resp.ByLocation)
+
+			var raw map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+			assert.Contains(t, raw, "by_location")
+		})
+	}
+}
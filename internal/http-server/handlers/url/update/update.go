@@ -0,0 +1,143 @@
+package update
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/meta"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Request changes the descriptive fields of a link. Fields left out keep
+// their value; tags and metadata are replaced as a whole.
+type Request struct {
+	Title       *string            `json:"title"`
+	Description *string            `json:"description"`
+	Tags        *[]string          `json:"tags"`
+	Metadata    *map[string]string `json:"metadata"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DetailsUpdater
+type DetailsUpdater interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+	UpdateDetails(link storage.Link, actor string) error
+}
+
+// New returns a handler changing the title, description, tags and metadata
+// of a link. ?domain selects the short domain the alias is on; the default
+// domain otherwise.
+//
+// Users other than the admin need edit rights on the link.
+func New(log *slog.Logger, detailsUpdater DetailsUpdater, accessChecker save.AccessChecker, eventPublisher save.EventPublisher, auditRecorder save.AuditRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.update.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+		actor, _, _ := r.BasicAuth()
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleEdit) {
+			return
+		}
+
+		before, err := detailsUpdater.GetLink(host, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		link := before
+		if req.Title != nil {
+			link.Title = *req.Title
+		}
+		if req.Description != nil {
+			link.Description = *req.Description
+		}
+		if req.Tags != nil {
+			link.Tags = *req.Tags
+		}
+		if req.Metadata != nil {
+			link.Metadata = *req.Metadata
+		}
+
+		if err := meta.Clean(&link); err != nil {
+			log.Info("request rejected", sl.Err(err))
+
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		err = detailsUpdater.UpdateDetails(link, actor)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to update url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("url details updated", slog.String("alias", alias))
+
+		eventPublisher.Publish(webhook.EventUpdated, link)
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionLinkUpdate,
+			Domain: host,
+			Alias:  alias,
+			Before: audit.LinkOf(before),
+			After:  audit.LinkOf(link),
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
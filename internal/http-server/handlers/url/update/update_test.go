@@ -0,0 +1,126 @@
+package update_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/update"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/update/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/access"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	current := storage.Link{
+		Domain:   "go.example",
+		Alias:    "promo",
+		URL:      "https://example.com",
+		Title:    "Promo",
+		Tags:     []string{"spring"},
+		Metadata: map[string]string{"campaign": "spring-24"},
+	}
+
+	cases := []struct {
+		name      string
+		body      string
+		accessErr error
+		getErr    error
+		want      *storage.Link
+		respError string
+	}{
+		{
+			name: "Tags replaced, title kept",
+			body: `{"tags": ["Spring", "Q2", "spring"], "description": "Spring sale"}`,
+			want: &storage.Link{
+				Domain:      "go.example",
+				Alias:       "promo",
+				URL:         "https://example.com",
+				Title:       "Promo",
+				Description: "Spring sale",
+				Tags:        []string{"spring", "q2"},
+				Metadata:    map[string]string{"campaign": "spring-24"},
+			},
+		},
+		{
+			name: "Metadata cleared",
+			body: `{"metadata": {}}`,
+			want: &storage.Link{
+				Domain:   "go.example",
+				Alias:    "promo",
+				URL:      "https://example.com",
+				Title:    "Promo",
+				Tags:     []string{"spring"},
+				Metadata: map[string]string{},
+			},
+		},
+		{
+			name:      "Bad tag",
+			body:      `{"tags": ["two words"]}`,
+			respError: "tags must be 1 to 64 letters, digits or -_.:",
+		},
+		{
+			name:      "Unknown alias",
+			body:      `{"title": "x"}`,
+			getErr:    storage.ErrURLNotFound,
+			respError: "not found",
+		},
+		{
+			name:      "Not shared with the user",
+			body:      `{"title": "x"}`,
+			accessErr: access.ErrDenied,
+			respError: "access denied",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			accessCheckerMock := saveMocks.NewAccessChecker(t)
+			accessCheckerMock.On("CheckLink", "alice", "go.example", "promo", storage.RoleEdit).Return(tc.accessErr).Once()
+
+			detailsUpdaterMock := mocks.NewDetailsUpdater(t)
+			if tc.accessErr == nil {
+				detailsUpdaterMock.On("GetLink", "go.example", "promo").Return(current, tc.getErr).Once()
+			}
+
+			eventPublisherMock := saveMocks.NewEventPublisher(t)
+			auditRecorderMock := saveMocks.NewAuditRecorder(t)
+			if tc.want != nil {
+				detailsUpdaterMock.On("UpdateDetails", *tc.want, "alice").Return(nil).Once()
+				eventPublisherMock.On("Publish", webhook.EventUpdated, *tc.want).Once()
+				auditRecorderMock.On("Record", mock.Anything, audit.Entry{
+					Action: audit.ActionLinkUpdate,
+					Domain: "go.example",
+					Alias:  "promo",
+					Before: audit.LinkOf(current),
+					After:  audit.LinkOf(*tc.want),
+				}).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/url/{alias}", update.New(slogdiscard.NewDiscardLogger(), detailsUpdaterMock, accessCheckerMock, eventPublisherMock, auditRecorderMock))
+
+			req := httptest.NewRequest(http.MethodPatch, "/url/promo?domain=go.example", strings.NewReader(tc.body))
+			req.SetBasicAuth("alice", "secret")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var res resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			assert.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
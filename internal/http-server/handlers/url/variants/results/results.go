@@ -0,0 +1,145 @@
+package results
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/split"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Variant is the result of one A/B variant so far.
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"`
+	Weight int    `json:"weight"`
+	Clicks int64  `json:"clicks"`
+	// Share is the variant's fraction of all variant clicks.
+	Share float64 `json:"share"`
+	// Removed marks variants no longer on the link that still have clicks.
+	Removed bool `json:"removed,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Domain   string    `json:"domain,omitempty"`
+	Alias    string    `json:"alias"`
+	Variants []Variant `json:"variants"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ResultsGetter
+type ResultsGetter interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+	GetVariantClicks(domain string, alias string) (map[string]int64, error)
+}
+
+// New returns a handler reporting the clicks each A/B variant of a link
+// received. ?domain selects the short domain the alias is on; the default
+// domain otherwise.
+//
+// Users other than the admin need read rights on the link.
+func New(log *slog.Logger, resultsGetter ResultsGetter, accessChecker save.AccessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.variants.results.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleRead) {
+			return
+		}
+
+		link, err := resultsGetter.GetLink(host, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get link", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		clicks, err := resultsGetter.GetVariantClicks(host, alias)
+		if err != nil {
+			log.Error("failed to get variant clicks", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Domain:   link.Domain,
+			Alias:    link.Alias,
+			Variants: variants(link.Variants, clicks),
+		})
+	}
+}
+
+// variants lists the link's variants in order, then removed variants that
+// were clicked.
+func variants(current []storage.Variant, clicks map[string]int64) []Variant {
+	var total int64
+	for _, n := range clicks {
+		total += n
+	}
+
+	list := make([]Variant, 0, len(current))
+	seen := make(map[string]bool, len(current))
+
+	for i, v := range current {
+		name := split.VariantName(i, v)
+		list = append(list, Variant{Name: name, URL: v.URL, Weight: v.Weight, Clicks: clicks[name]})
+		seen[name] = true
+	}
+
+	var removed []string
+	for name := range clicks {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, name := range removed {
+		list = append(list, Variant{Name: name, Clicks: clicks[name], Removed: true})
+	}
+
+	if total > 0 {
+		for i := range list {
+			list[i].Share = float64(list[i].Clicks) / float64(total)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,60 @@
+package results_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/variants/results"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/variants/results/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// allowRead lets any user read the link.
+func allowRead(t *testing.T) *saveMocks.AccessChecker {
+	accessCheckerMock := saveMocks.NewAccessChecker(t)
+	accessCheckerMock.On("CheckLink", mock.Anything, mock.Anything, mock.Anything, storage.RoleRead).Return(nil).Maybe()
+
+	return accessCheckerMock
+}
+
+func TestResultsHandler(t *testing.T) {
+	link := storage.Link{
+		Alias: "promo",
+		URL:   "https://example.com/",
+		Variants: []storage.Variant{
+			{Name: "a", URL: "https://example.com/a", Weight: 3},
+			{URL: "https://example.com/b", Weight: 1},
+		},
+	}
+
+	resultsGetterMock := mocks.NewResultsGetter(t)
+	resultsGetterMock.On("GetLink", "", "promo").Return(link, nil).Once()
+	resultsGetterMock.On("GetVariantClicks", "", "promo").
+		Return(map[string]int64{"a": 6, "variant-2": 2, "old": 2}, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/variants", results.New(slogdiscard.NewDiscardLogger(), resultsGetterMock, allowRead(t)))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/promo/variants", nil))
+
+	var resp results.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, []results.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 3, Clicks: 6, Share: 0.6},
+		{Name: "variant-2", URL: "https://example.com/b", Weight: 1, Clicks: 2, Share: 0.2},
+		{Name: "old", Clicks: 2, Share: 0.2, Removed: true},
+	}, resp.Variants)
+}
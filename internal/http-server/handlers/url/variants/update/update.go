@@ -0,0 +1,140 @@
+package update
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/page"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type Request struct {
+	// Variants replace the link's variants; an empty list ends the split.
+	Variants []storage.Variant `json:"variants"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=VariantsUpdater
+type VariantsUpdater interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+	UpdateVariants(domain string, alias string, variants []storage.Variant, actor string) error
+}
+
+// New returns a handler replacing the A/B variants of a link, e.g. to
+// change their weights while the experiment runs. Clicks stay with the
+// variant names. ?domain selects the short domain the alias is on; the
+// default domain otherwise.
+//
+// Users other than the admin need edit rights on the link.
+func New(
+	log *slog.Logger,
+	variantsUpdater VariantsUpdater,
+	accessChecker save.AccessChecker,
+	urlChecker save.URLChecker,
+	eventPublisher save.EventPublisher,
+	auditRecorder save.AuditRecorder,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.url.variants.update.New"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := save.ValidateVariants(req.Variants, urlChecker); err != nil {
+			var reqErr *save.RequestError
+			if errors.As(err, &reqErr) {
+				log.Info("request rejected", sl.Err(err))
+
+				render.JSON(w, r, reqErr.Response)
+
+				return
+			}
+
+			log.Error("failed to validate variants", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		host := domain.Normalize(r.URL.Query().Get("domain"))
+		actor, _, _ := r.BasicAuth()
+
+		if !save.Authorize(log, w, r, accessChecker, host, alias, storage.RoleEdit) {
+			return
+		}
+
+		link, err := variantsUpdater.GetLink(host, alias)
+		if err == nil && link.Page != nil && len(req.Variants) > 0 {
+			log.Info("url is a page", "alias", alias)
+
+			render.JSON(w, r, resp.Error(page.ErrHasDestination.Error()))
+
+			return
+		}
+		if err == nil {
+			err = variantsUpdater.UpdateVariants(host, alias, req.Variants, actor)
+		}
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to update variants", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("variants updated", slog.String("alias", alias), slog.Int("variants", len(req.Variants)))
+
+		eventPublisher.Publish(webhook.EventUpdated, storage.Link{Domain: host, Alias: alias, Variants: req.Variants})
+
+		before := audit.LinkOf(link)
+		after := before
+		after.Variants = req.Variants
+		auditRecorder.Record(r, audit.Entry{
+			Action: audit.ActionLinkUpdate,
+			Domain: host,
+			Alias:  alias,
+			Before: before,
+			After:  after,
+		})
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,112 @@
+package update_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	saveMocks "github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/variants/update"
+	"github.com/MaximShildyakov/url-shortener/internal/http-server/handlers/url/variants/update/mocks"
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		variants  []storage.Variant
+		getErr    error
+		respError string
+	}{
+		{
+			name: "Reweighted",
+			body: `{"variants": [{"name": "a", "url": "https://example.com/a", "weight": 1}, {"name": "b", "url": "https://example.com/b", "weight": 9}]}`,
+			variants: []storage.Variant{
+				{Name: "a", URL: "https://example.com/a", Weight: 1},
+				{Name: "b", URL: "https://example.com/b", Weight: 9},
+			},
+		},
+		{
+			name:     "Split ended",
+			body:     `{"variants": []}`,
+			variants: []storage.Variant{},
+		},
+		{
+			name:      "No weight",
+			body:      `{"variants": [{"url": "https://example.com/a"}]}`,
+			respError: "at least one variant needs a positive weight",
+		},
+		{
+			name:      "Unknown alias",
+			body:      `{"variants": [{"url": "https://example.com/a", "weight": 1}]}`,
+			getErr:    storage.ErrURLNotFound,
+			respError: "not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlCheckerMock := saveMocks.NewURLChecker(t)
+			urlCheckerMock.On("CheckURL", mock.AnythingOfType("string")).Return(nil).Maybe()
+
+			link := storage.Link{
+				Domain:   "go.example",
+				Alias:    "promo",
+				URL:      "https://example.com",
+				Variants: []storage.Variant{{URL: "https://example.com/old", Weight: 1}},
+			}
+
+			variantsUpdaterMock := mocks.NewVariantsUpdater(t)
+			if tc.variants != nil || tc.getErr != nil {
+				variantsUpdaterMock.On("GetLink", "go.example", "promo").Return(link, tc.getErr).Once()
+			}
+			if tc.variants != nil {
+				variantsUpdaterMock.On("UpdateVariants", "go.example", "promo", tc.variants, "alice").Return(nil).Once()
+			}
+
+			eventPublisherMock := saveMocks.NewEventPublisher(t)
+			auditRecorderMock := saveMocks.NewAuditRecorder(t)
+			if tc.respError == "" {
+				eventPublisherMock.On("Publish", webhook.EventUpdated, storage.Link{Domain: "go.example", Alias: "promo", Variants: tc.variants}).Once()
+
+				after := audit.LinkOf(link)
+				after.Variants = tc.variants
+				auditRecorderMock.On("Record", mock.Anything, audit.Entry{
+					Action: audit.ActionLinkUpdate,
+					Domain: "go.example",
+					Alias:  "promo",
+					Before: audit.LinkOf(link),
+					After:  after,
+				}).Once()
+			}
+
+			accessCheckerMock := saveMocks.NewAccessChecker(t)
+			accessCheckerMock.On("CheckLink", "alice", "go.example", "promo", storage.RoleEdit).Return(nil).Maybe()
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}/variants", update.New(slogdiscard.NewDiscardLogger(), variantsUpdaterMock, accessCheckerMock, urlCheckerMock, eventPublisherMock, auditRecorderMock))
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/url/promo/variants?domain=go.example", strings.NewReader(tc.body))
+			req.SetBasicAuth("alice", "secret")
+			r.ServeHTTP(rr, req)
+
+			var res resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			assert.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package access
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	// MaxName caps the length of collection names.
+	MaxName = 100
+	// MaxGrants caps the grants on one collection.
+	MaxGrants = 100
+)
+
+var (
+	ErrDenied         = errors.New("access denied")
+	ErrInvalidName    = fmt.Errorf("collection name must be 1 to %d characters", MaxName)
+	ErrTooManyGrants  = fmt.Errorf("a collection may have at most %d grants", MaxGrants)
+	ErrNoUser         = errors.New("grant needs a user")
+	ErrInvalidRole    = errors.New("grant role must be read or edit")
+	ErrDuplicateGrant = errors.New("users may only be granted once")
+)
+
+// Store tells what rights users have through ownership and collection
+// grants.
+type Store interface {
+	LinkRole(domain string, alias string, user string) (string, error)
+	CollectionRole(id int64, user string) (string, error)
+}
+
+// Checker decides whether users may act on links and collections. The
+// admin may do anything; other users may act on what they own and what
+// collections share with them.
+type Checker struct {
+	store Store
+	admin string
+}
+
+// New returns a Checker giving admin every right.
+func New(store Store, admin string) *Checker {
+	return &Checker{store: store, admin: admin}
+}
+
+// IsAdmin reports whether user is the admin.
+func (c *Checker) IsAdmin(user string) bool {
+	return user == c.admin
+}
+
+// CheckLink returns ErrDenied unless user has at least role on the link
+// under alias on domain, which may be in the trash.
+func (c *Checker) CheckLink(user string, domain string, alias string, role string) error {
+	const op = "lib.access.CheckLink"
+
+	if c.IsAdmin(user) {
+		return nil
+	}
+
+	has, err := c.store.LinkRole(domain, alias, user)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !Allows(has, role) {
+		return ErrDenied
+	}
+
+	return nil
+}
+
+// CheckCollection returns ErrDenied unless user has at least role on the
+// collection with id.
+func (c *Checker) CheckCollection(user string, id int64, role string) error {
+	const op = "lib.access.CheckCollection"
+
+	if c.IsAdmin(user) {
+		return nil
+	}
+
+	has, err := c.store.CollectionRole(id, user)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !Allows(has, role) {
+		return ErrDenied
+	}
+
+	return nil
+}
+
+// Allows reports whether having role has gives the rights of role want.
+func Allows(has string, want string) bool {
+	return rank(want) > 0 && rank(has) >= rank(want)
+}
+
+func rank(role string) int {
+	switch role {
+	case storage.RoleRead:
+		return 1
+	case storage.RoleEdit:
+		return 2
+	case storage.RoleOwner:
+		return 3
+	}
+
+	return 0
+}
+
+// Name trims a collection name and checks its length.
+func Name(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxName {
+		return "", ErrInvalidName
+	}
+
+	return name, nil
+}
+
+// ValidateGrants checks that grants name each user once with the read or
+// edit role.
+func ValidateGrants(grants []storage.Grant) error {
+	if len(grants) > MaxGrants {
+		return ErrTooManyGrants
+	}
+
+	seen := make(map[string]bool, len(grants))
+	for _, g := range grants {
+		if g.User == "" {
+			return ErrNoUser
+		}
+
+		if g.Role != storage.RoleRead && g.Role != storage.RoleEdit {
+			return ErrInvalidRole
+		}
+
+		if seen[g.User] {
+			return ErrDuplicateGrant
+		}
+		seen[g.User] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+type fakeStore map[string]string
+
+func (f fakeStore) LinkRole(domain string, alias string, user string) (string, error) {
+	if alias == "missing" {
+		return "", storage.ErrURLNotFound
+	}
+
+	return f[user], nil
+}
+
+func (f fakeStore) CollectionRole(id int64, user string) (string, error) {
+	return f[user], nil
+}
+
+func TestChecker(t *testing.T) {
+	c := New(fakeStore{"alice": storage.RoleOwner, "bob": storage.RoleEdit, "carol": storage.RoleRead}, "admin")
+
+	cases := []struct {
+		user string
+		role string
+		err  error
+	}{
+		{"admin", storage.RoleOwner, nil},
+		{"alice", storage.RoleOwner, nil},
+		{"bob", storage.RoleEdit, nil},
+		{"bob", storage.RoleOwner, ErrDenied},
+		{"carol", storage.RoleRead, nil},
+		{"carol", storage.RoleEdit, ErrDenied},
+		{"dave", storage.RoleRead, ErrDenied},
+	}
+
+	for _, tc := range cases {
+		assert.ErrorIs(t, c.CheckLink(tc.user, "", "promo", tc.role), tc.err, "%s as %s", tc.user, tc.role)
+		assert.ErrorIs(t, c.CheckCollection(tc.user, 1, tc.role), tc.err, "%s as %s", tc.user, tc.role)
+	}
+
+	assert.ErrorIs(t, c.CheckLink("bob", "", "missing", storage.RoleEdit), storage.ErrURLNotFound)
+	assert.NoError(t, c.CheckLink("admin", "", "missing", storage.RoleEdit))
+}
+
+func TestValidateGrants(t *testing.T) {
+	assert.NoError(t, ValidateGrants([]storage.Grant{{User: "bob", Role: storage.RoleEdit}, {User: "carol", Role: storage.RoleRead}}))
+	assert.ErrorIs(t, ValidateGrants([]storage.Grant{{Role: storage.RoleRead}}), ErrNoUser)
+	assert.ErrorIs(t, ValidateGrants([]storage.Grant{{User: "bob", Role: storage.RoleOwner}}), ErrInvalidRole)
+	assert.ErrorIs(t, ValidateGrants([]storage.Grant{{User: "bob", Role: storage.RoleRead}, {User: "bob", Role: storage.RoleEdit}}), ErrDuplicateGrant)
+}
+
+func TestName(t *testing.T) {
+	name, err := Name("  Q3 launch ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Q3 launch", name)
+
+	_, err = Name(" ")
+	assert.ErrorIs(t, err, ErrInvalidName)
+}
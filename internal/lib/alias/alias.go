@@ -0,0 +1,73 @@
+package alias
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	StrategyRandom   = "random"
+	StrategySequence = "sequence"
+	StrategyNanoID   = "nanoid"
+	StrategyHashids  = "hashids"
+	StrategyWords    = "words"
+)
+
+const base62Alphabet = "0123456789" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz"
+
+// MinLength and MaxLength bound the length of aliases users choose.
+const (
+	MinLength = 3
+	MaxLength = 20
+)
+
+var (
+	ErrUnknownStrategy = errors.New("unknown alias strategy")
+	ErrInvalidLength   = errors.New("alias length must be positive")
+	ErrBadLength       = fmt.Errorf("alias length must be between %d and %d characters", MinLength, MaxLength)
+)
+
+// CheckLength returns ErrBadLength unless alias is between MinLength and
+// MaxLength bytes long.
+func CheckLength(alias string) error {
+	if len(alias) < MinLength || len(alias) > MaxLength {
+		return ErrBadLength
+	}
+
+	return nil
+}
+
+// Sequencer hands out monotonically increasing numbers, usually backed by storage.
+type Sequencer interface {
+	NextSequence() (uint64, error)
+}
+
+// EncodeBase62 encodes n using digits, then upper and lower case letters.
+func EncodeBase62(n uint64) string {
+	if n == 0 {
+		return base62Alphabet[:1]
+	}
+
+	var b []byte
+	for n > 0 {
+		b = append(b, base62Alphabet[n%62])
+		n /= 62
+	}
+
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+
+	return string(b)
+}
+
+func padLeft(s string, minLength int) string {
+	if len(s) >= minLength {
+		return s
+	}
+
+	return strings.Repeat(base62Alphabet[:1], minLength-len(s)) + s
+}
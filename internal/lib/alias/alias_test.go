@@ -0,0 +1,136 @@
+package alias
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	n uint64
+}
+
+func (c *counter) NextSequence() (uint64, error) {
+	c.n++
+	return c.n, nil
+}
+
+func TestEncodeBase62(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{n: 0, want: "0"},
+		{n: 9, want: "9"},
+		{n: 10, want: "A"},
+		{n: 61, want: "z"},
+		{n: 62, want: "10"},
+		{n: 3843, want: "zz"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, EncodeBase62(tt.n))
+	}
+}
+
+func TestSequence(t *testing.T) {
+	g, err := NewSequence(&counter{n: 61}, 4)
+	require.NoError(t, err)
+
+	a1, err := g.Generate()
+	require.NoError(t, err)
+	a2, err := g.Generate()
+	require.NoError(t, err)
+
+	assert.Equal(t, "0010", a1)
+	assert.Equal(t, "0011", a2)
+}
+
+func TestHashids(t *testing.T) {
+	tests := []struct {
+		name      string
+		minLength int
+		n         uint64
+		want      string
+	}{
+		{
+			name:      "no padding",
+			minLength: 1,
+			n:         12345,
+			want:      "NkK9",
+		},
+		{
+			name:      "padded",
+			minLength: 8,
+			n:         1,
+			want:      "gB0NV05e",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewHashids(&counter{}, "this is my salt", tt.minLength)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, g.Encode(tt.n))
+		})
+	}
+
+	_, err := NewHashids(&counter{}, "", 6)
+	assert.ErrorIs(t, err, ErrEmptySalt)
+}
+
+func TestRandomStrategies(t *testing.T) {
+	nanoid, err := NewNanoID(12)
+	require.NoError(t, err)
+	rnd, err := NewRandom(8, "abcdefgh")
+	require.NoError(t, err)
+	words, err := NewWords(3)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		gen   interface{ Generate() (string, error) }
+		check func(t *testing.T, alias string)
+	}{
+		{
+			name: "nanoid",
+			gen:  nanoid,
+			check: func(t *testing.T, alias string) {
+				assert.Len(t, alias, 12)
+				for _, c := range alias {
+					assert.Contains(t, nanoidAlphabet, string(c))
+				}
+			},
+		},
+		{
+			name: "random",
+			gen:  rnd,
+			check: func(t *testing.T, alias string) {
+				assert.Len(t, alias, 8)
+				assert.Empty(t, strings.Trim(alias, "abcdefgh"))
+			},
+		},
+		{
+			name: "words",
+			gen:  words,
+			check: func(t *testing.T, alias string) {
+				assert.Len(t, strings.Split(alias, wordSeparator), 3)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a1, err := tt.gen.Generate()
+			require.NoError(t, err)
+			a2, err := tt.gen.Generate()
+			require.NoError(t, err)
+
+			tt.check(t, a1)
+			assert.NotEqual(t, a1, a2)
+		})
+	}
+
+	_, err = NewNanoID(0)
+	assert.ErrorIs(t, err, ErrInvalidLength)
+}
@@ -0,0 +1,168 @@
+package alias
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	hashidsAlphabet  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+	hashidsSeparator = "cfhistuCFHISTU"
+	hashidsSepDiv    = 3.5
+	hashidsGuardDiv  = 12
+)
+
+var ErrEmptySalt = errors.New("hashids salt must not be empty")
+
+// Hashids obfuscates sequence numbers with the hashids algorithm
+// (https://hashids.org), so aliases stay short and unique without
+// revealing how many links exist.
+type Hashids struct {
+	seq       Sequencer
+	minLength int
+	salt      []byte
+	alphabet  []byte
+	seps      []byte
+	guards    []byte
+}
+
+func NewHashids(seq Sequencer, salt string, minLength int) (*Hashids, error) {
+	if minLength <= 0 {
+		return nil, ErrInvalidLength
+	}
+	if salt == "" {
+		return nil, ErrEmptySalt
+	}
+
+	h := &Hashids{seq: seq, minLength: minLength, salt: []byte(salt)}
+
+	alphabet := []byte(hashidsAlphabet)
+	var seps []byte
+	for _, c := range []byte(hashidsSeparator) {
+		if i := strings.IndexByte(string(alphabet), c); i >= 0 {
+			seps = append(seps, c)
+			alphabet = append(alphabet[:i], alphabet[i+1:]...)
+		}
+	}
+
+	consistentShuffle(seps, h.salt)
+
+	if len(seps) == 0 || float64(len(alphabet))/float64(len(seps)) > hashidsSepDiv {
+		sepsLength := ceilDiv(float64(len(alphabet)), hashidsSepDiv)
+		if sepsLength == 1 {
+			sepsLength = 2
+		}
+		if sepsLength > len(seps) {
+			diff := sepsLength - len(seps)
+			seps = append(seps, alphabet[:diff]...)
+			alphabet = alphabet[diff:]
+		} else {
+			seps = seps[:sepsLength]
+		}
+	}
+
+	consistentShuffle(alphabet, h.salt)
+
+	guardCount := ceilDiv(float64(len(alphabet)), hashidsGuardDiv)
+	if len(alphabet) < 3 {
+		h.guards, seps = seps[:guardCount], seps[guardCount:]
+	} else {
+		h.guards, alphabet = alphabet[:guardCount], alphabet[guardCount:]
+	}
+
+	h.alphabet = alphabet
+	h.seps = seps
+
+	return h, nil
+}
+
+func (g *Hashids) Generate() (string, error) {
+	const op = "lib.alias.Hashids.Generate"
+
+	n, err := g.seq.NextSequence()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return g.Encode(n), nil
+}
+
+// Encode returns the hashid of a single number.
+func (g *Hashids) Encode(n uint64) string {
+	alphabet := append([]byte(nil), g.alphabet...)
+
+	numbersHash := n % 100
+	lottery := alphabet[numbersHash%uint64(len(alphabet))]
+
+	buffer := make([]byte, 0, 1+len(g.salt)+len(alphabet))
+	buffer = append(buffer, lottery)
+	buffer = append(buffer, g.salt...)
+	buffer = append(buffer, alphabet...)
+	consistentShuffle(alphabet, buffer[:len(alphabet)])
+
+	ret := append([]byte{lottery}, hashidsHash(n, alphabet)...)
+
+	if len(ret) < g.minLength {
+		guard := g.guards[(numbersHash+uint64(ret[0]))%uint64(len(g.guards))]
+		ret = append([]byte{guard}, ret...)
+
+		if len(ret) < g.minLength {
+			guard = g.guards[(numbersHash+uint64(ret[2]))%uint64(len(g.guards))]
+			ret = append(ret, guard)
+		}
+	}
+
+	half := len(alphabet) / 2
+	for len(ret) < g.minLength {
+		consistentShuffle(alphabet, append([]byte(nil), alphabet...))
+
+		padded := make([]byte, 0, len(ret)+len(alphabet))
+		padded = append(padded, alphabet[half:]...)
+		padded = append(padded, ret...)
+		padded = append(padded, alphabet[:half]...)
+		ret = padded
+
+		if excess := len(ret) - g.minLength; excess > 0 {
+			ret = ret[excess/2 : excess/2+g.minLength]
+		}
+	}
+
+	return string(ret)
+}
+
+func hashidsHash(n uint64, alphabet []byte) []byte {
+	size := uint64(len(alphabet))
+
+	var hash []byte
+	for {
+		hash = append([]byte{alphabet[n%size]}, hash...)
+		n /= size
+		if n == 0 {
+			return hash
+		}
+	}
+}
+
+func consistentShuffle(alphabet []byte, salt []byte) {
+	if len(salt) == 0 {
+		return
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		integer := int(salt[v])
+		p += integer
+		j := (integer + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+}
+
+func ceilDiv(a, b float64) int {
+	n := int(a / b)
+	if float64(n)*b < a {
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,46 @@
+package alias
+
+import (
+	"fmt"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/random"
+)
+
+// Random produces aliases drawn uniformly from an alphabet.
+type Random struct {
+	length int
+	source *random.Source
+}
+
+// NewRandom returns a generator over alphabet, or over
+// random.AlphabetAlphanumeric when alphabet is empty.
+func NewRandom(length int, alphabet string) (*Random, error) {
+	const op = "lib.alias.NewRandom"
+
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	if alphabet == "" {
+		alphabet = random.AlphabetAlphanumeric
+	}
+
+	source, err := random.New(alphabet)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Random{length: length, source: source}, nil
+}
+
+func (g *Random) Generate() (string, error) {
+	return g.source.String(g.length)
+}
+
+// nanoidAlphabet is the URL-safe alphabet used by nanoid.
+const nanoidAlphabet = "_-" + base62Alphabet
+
+// NewNanoID returns a generator of nanoid-style aliases.
+func NewNanoID(length int) (*Random, error) {
+	return NewRandom(length, nanoidAlphabet)
+}
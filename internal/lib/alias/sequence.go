@@ -0,0 +1,31 @@
+package alias
+
+import "fmt"
+
+// Sequence produces base62-encoded sequence numbers, left-padded to minLength.
+//
+// Aliases are short and never collide with each other, but they are
+// enumerable; use Hashids when that matters.
+type Sequence struct {
+	seq       Sequencer
+	minLength int
+}
+
+func NewSequence(seq Sequencer, minLength int) (*Sequence, error) {
+	if minLength <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	return &Sequence{seq: seq, minLength: minLength}, nil
+}
+
+func (g *Sequence) Generate() (string, error) {
+	const op = "lib.alias.Sequence.Generate"
+
+	n, err := g.seq.NextSequence()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return padLeft(EncodeBase62(n), g.minLength), nil
+}
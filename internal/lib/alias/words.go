@@ -0,0 +1,46 @@
+package alias
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+//go:embed words.txt
+var wordList string
+
+const wordSeparator = "-"
+
+// Words produces human-readable aliases such as "lake-bold-moon".
+type Words struct {
+	count int
+	words []string
+}
+
+// NewWords returns a generator joining count randomly chosen words.
+func NewWords(count int) (*Words, error) {
+	if count <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	return &Words{count: count, words: strings.Fields(wordList)}, nil
+}
+
+func (g *Words) Generate() (string, error) {
+	const op = "lib.alias.Words.Generate"
+
+	max := big.NewInt(int64(len(g.words)))
+
+	parts := make([]string, g.count)
+	for i := range parts {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		parts[i] = g.words[n.Int64()]
+	}
+
+	return strings.Join(parts, wordSeparator), nil
+}
@@ -0,0 +1,136 @@
+package aliasfilter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrReserved = errors.New("alias is reserved")
+	ErrBlocked  = errors.New("alias is not allowed")
+)
+
+// Filter rejects aliases that are reserved words or match the blocklist.
+//
+// Reserved words are compared case-insensitively. The blocklist is a file of
+// case-insensitive regular expressions, one per line; blank lines and lines
+// starting with # are ignored. A Filter is safe for concurrent use.
+type Filter struct {
+	blocklistPath string
+
+	mu       sync.RWMutex
+	reserved map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// New returns a Filter reserving the given words and loading the blocklist
+// from blocklistPath, if it is set.
+func New(reserved []string, blocklistPath string) (*Filter, error) {
+	const op = "lib.aliasfilter.New"
+
+	f := &Filter{
+		blocklistPath: blocklistPath,
+		reserved:      make(map[string]struct{}, len(reserved)),
+	}
+
+	f.Reserve(reserved...)
+
+	if _, err := f.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f, nil
+}
+
+// Reserve adds words to the reserved list.
+func (f *Filter) Reserve(words ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			f.reserved[strings.ToLower(w)] = struct{}{}
+		}
+	}
+}
+
+// Reload re-reads the blocklist file and returns the number of patterns
+// loaded. On error the previous blocklist stays in effect.
+func (f *Filter) Reload() (int, error) {
+	const op = "lib.aliasfilter.Reload"
+
+	if f.blocklistPath == "" {
+		return 0, nil
+	}
+
+	patterns, err := load(f.blocklistPath)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f.mu.Lock()
+	f.patterns = patterns
+	f.mu.Unlock()
+
+	return len(patterns), nil
+}
+
+// CheckAlias returns ErrReserved or ErrBlocked if alias may not be used.
+func (f *Filter) CheckAlias(alias string) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if _, ok := f.reserved[strings.ToLower(alias)]; ok {
+		return ErrReserved
+	}
+
+	// A trailing + selects the preview page of the alias before it, and
+	// the router can't tell where an alias containing + ends.
+	if strings.Contains(alias, "+") {
+		return ErrReserved
+	}
+
+	for _, p := range f.patterns {
+		if p.MatchString(alias) {
+			return ErrBlocked
+		}
+	}
+
+	return nil
+}
+
+func load(path string) ([]*regexp.Regexp, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var patterns []*regexp.Regexp
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		p, err := regexp.Compile("(?i)" + text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		patterns = append(patterns, p)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return patterns, nil
+}
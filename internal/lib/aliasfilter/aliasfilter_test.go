@@ -0,0 +1,66 @@
+package aliasfilter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_CheckAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# offensive words\n\n^bad\nword$\n"), 0o600))
+
+	f, err := New([]string{"url", "Healthz"}, path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alias string
+		err   error
+	}{
+		{alias: "fine", err: nil},
+		{alias: "url", err: ErrReserved},
+		{alias: "URL", err: ErrReserved},
+		{alias: "healthz", err: ErrReserved},
+		{alias: "badalias", err: ErrBlocked},
+		{alias: "BADalias", err: ErrBlocked},
+		{alias: "notbad", err: nil},
+		{alias: "swordword", err: ErrBlocked},
+		{alias: "fine+", err: ErrReserved},
+		{alias: "a+b", err: ErrReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			assert.ErrorIs(t, f.CheckAlias(tt.alias), tt.err)
+		})
+	}
+}
+
+func TestFilter_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	f, err := New(nil, path)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, f.CheckAlias("first"), ErrBlocked)
+	assert.NoError(t, f.CheckAlias("second"))
+
+	require.NoError(t, os.WriteFile(path, []byte("second\nthird\n"), 0o600))
+
+	n, err := f.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	assert.NoError(t, f.CheckAlias("first"))
+	assert.ErrorIs(t, f.CheckAlias("second"), ErrBlocked)
+
+	// A broken file keeps the previous blocklist.
+	require.NoError(t, os.WriteFile(path, []byte("(unclosed\n"), 0o600))
+
+	_, err = f.Reload()
+	require.Error(t, err)
+	assert.ErrorIs(t, f.CheckAlias("second"), ErrBlocked)
+}
@@ -8,9 +8,16 @@ import (
 )
 
 type Response struct {
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
-	
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
 
 const (
@@ -32,21 +39,40 @@ func Error(msg string) Response {
 }
 
 func ValidationError(errs validator.ValidationErrors) Response {
-	var errMsgs []string
+	var fieldErrs []FieldError
 
 	for _, err := range errs {
+		var msg string
+
 		switch err.ActualTag() {
 		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
+			msg = fmt.Sprintf("field %s is a required field", err.Field())
 		case "url":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+			msg = fmt.Sprintf("field %s is not a valid URL", err.Field())
 		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
+			msg = fmt.Sprintf("field %s is not valid", err.Field())
 		}
+
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   err.Field(),
+			Rule:    err.ActualTag(),
+			Message: msg,
+		})
+	}
+
+	return FieldErrors(fieldErrs...)
+}
+
+// FieldErrors reports rejected fields, joining their messages into Error.
+func FieldErrors(errs ...FieldError) Response {
+	errMsgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		errMsgs = append(errMsgs, err.Message)
 	}
 
 	return Response{
 		Status: StatusError,
 		Error:  strings.Join(errMsgs, ", "),
+		Errors: errs,
 	}
 }
\ No newline at end of file
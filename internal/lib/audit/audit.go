@@ -0,0 +1,232 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionLinkCreate       = "link.create"
+	ActionLinkUpdate       = "link.update"
+	ActionLinkDelete       = "link.delete"
+	ActionLinkRestore      = "link.restore"
+	ActionLinkRollback     = "link.rollback"
+	ActionDomainCreate     = "domain.create"
+	ActionDomainUpdate     = "domain.update"
+	ActionDomainDelete     = "domain.delete"
+	ActionWebhookCreate    = "webhook.create"
+	ActionWebhookDelete    = "webhook.delete"
+	ActionDeadLetterReplay = "webhook.dead_letter.replay"
+	ActionCollectionCreate = "collection.create"
+	ActionCollectionUpdate = "collection.update"
+	ActionCollectionDelete = "collection.delete"
+	ActionCollectionAdd    = "collection.link.add"
+	ActionCollectionRemove = "collection.link.remove"
+	ActionDatabaseRestore  = "database.restore"
+)
+
+// Entry is a change as seen by the handler making it.
+type Entry struct {
+	Action string
+	Domain string
+	Alias  string
+	// Before and After are encoded as JSON; nil records nothing.
+	Before any
+	After  any
+}
+
+// Link is the snapshot of a link kept in the log.
+type Link struct {
+	Domain   string            `json:"domain,omitempty"`
+	Alias    string            `json:"alias"`
+	URL      string            `json:"url"`
+	Owner    string            `json:"owner,omitempty"`
+	Rules    []storage.Rule    `json:"rules,omitempty"`
+	Variants []storage.Variant `json:"variants,omitempty"`
+
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	Page *storage.Page `json:"page,omitempty"`
+}
+
+// LinkOf returns the snapshot of link.
+func LinkOf(link storage.Link) Link {
+	return Link{
+		Domain:   link.Domain,
+		Alias:    link.Alias,
+		URL:      link.URL,
+		Owner:    link.Owner,
+		Rules:    link.Rules,
+		Variants: link.Variants,
+
+		Title:       link.Title,
+		Description: link.Description,
+		Tags:        link.Tags,
+		Metadata:    link.Metadata,
+
+		Page: link.Page,
+	}
+}
+
+// Domain is the snapshot of a short domain kept in the log.
+type Domain struct {
+	Host         string `json:"host"`
+	FallbackURL  string `json:"fallback_url,omitempty"`
+	RedirectCode int    `json:"redirect_code"`
+}
+
+// DomainOf returns the snapshot of d.
+func DomainOf(d storage.Domain) Domain {
+	return Domain{Host: d.Host, FallbackURL: d.FallbackURL, RedirectCode: d.RedirectCode}
+}
+
+// Webhook is the snapshot of a webhook kept in the log. Secrets are left
+// out.
+type Webhook struct {
+	ID     int64    `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// WebhookOf returns the snapshot of w.
+func WebhookOf(w storage.Webhook) Webhook {
+	return Webhook{ID: w.ID, URL: w.URL, Events: w.Events}
+}
+
+// Collection is the snapshot of a collection kept in the log.
+type Collection struct {
+	ID     int64   `json:"id"`
+	Name   string  `json:"name"`
+	Owner  string  `json:"owner"`
+	Grants []Grant `json:"grants,omitempty"`
+}
+
+type Grant struct {
+	User string `json:"user"`
+	Role string `json:"role"`
+}
+
+// CollectionOf returns the snapshot of c.
+func CollectionOf(c storage.Collection) Collection {
+	snap := Collection{ID: c.ID, Name: c.Name, Owner: c.Owner}
+	for _, g := range c.Grants {
+		snap.Grants = append(snap.Grants, Grant{User: g.User, Role: g.Role})
+	}
+
+	return snap
+}
+
+// Membership is logged for links added to or removed from a collection.
+type Membership struct {
+	CollectionID int64 `json:"collection_id"`
+}
+
+// Snapshot is logged for database restores: Before is where the replaced
+// contents were saved, if anywhere, and After the snapshot restored.
+type Snapshot struct {
+	Path string `json:"path"`
+}
+
+// Store appends entries to the log.
+type Store interface {
+	AppendAudit(e storage.AuditEntry) error
+}
+
+// Log records changes in the audit log.
+type Log struct {
+	log   *slog.Logger
+	store Store
+}
+
+func New(log *slog.Logger, store Store) *Log {
+	return &Log{
+		log:   log.With(slog.String("component", "audit")),
+		store: store,
+	}
+}
+
+// Record appends e to the log, attributed to the authenticated user, the
+// request ID and the client IP of r. By then the change is made, so
+// failures are logged rather than returned.
+func (l *Log) Record(r *http.Request, e Entry) {
+	const op = "lib.audit.Record"
+
+	actor, _, _ := r.BasicAuth()
+
+	l.append(op, e, storage.AuditEntry{
+		Actor:     actor,
+		RequestID: middleware.GetReqID(r.Context()),
+		ClientIP:  clientIP(r),
+	})
+}
+
+// RecordAs appends e to the log, attributed to actor, for changes made
+// outside of an HTTP request, such as by commands. Failures are logged
+// like Record's.
+func (l *Log) RecordAs(actor string, e Entry) {
+	const op = "lib.audit.RecordAs"
+
+	l.append(op, e, storage.AuditEntry{Actor: actor})
+}
+
+// append completes entry, which says who made the change, with e and
+// appends it.
+func (l *Log) append(op string, e Entry, entry storage.AuditEntry) {
+	entry.Action = e.Action
+	entry.Domain = e.Domain
+	entry.Alias = e.Alias
+	entry.CreatedAt = time.Now()
+
+	var err error
+	if entry.Before, err = snapshot(e.Before); err == nil {
+		entry.After, err = snapshot(e.After)
+	}
+	if err == nil {
+		err = l.store.AppendAudit(entry)
+	}
+	if err != nil {
+		l.log.Error("failed to record change",
+			slog.String("op", op),
+			slog.String("action", e.Action),
+			slog.String("alias", e.Alias),
+			slog.String("request_id", entry.RequestID),
+			sl.Err(err),
+		)
+	}
+}
+
+// clientIP returns the IP of r's client, which middleware.RealIP takes from
+// proxy headers.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+func snapshot(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil || bytes.Equal(b, []byte("null")) {
+		return nil, err
+	}
+
+	return b, nil
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+	"github.com/MaximShildyakov/url-shortener/internal/storage/sqlite"
+)
+
+func newRequest(user string, requestID string) *http.Request {
+	r := httptest.NewRequest(http.MethodDelete, "/url/docs", nil)
+	r.SetBasicAuth(user, "secret")
+	r.RemoteAddr = "198.51.100.7:41000"
+
+	return r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, requestID))
+}
+
+func TestLog_Record(t *testing.T) {
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	l := New(slogdiscard.NewDiscardLogger(), db)
+
+	link := storage.Link{Alias: "docs", URL: "https://example.com/docs", Owner: "alice", NormalizedURL: "https://example.com/docs"}
+
+	l.Record(newRequest("alice", "req-1"), Entry{
+		Action: ActionLinkCreate,
+		Alias:  "docs",
+		After:  LinkOf(link),
+	})
+	l.Record(newRequest("bob", "req-2"), Entry{
+		Action: ActionLinkDelete,
+		Alias:  "docs",
+		Before: LinkOf(link),
+	})
+	l.Record(newRequest("bob", "req-3"), Entry{
+		Action: ActionDomainCreate,
+		Domain: "go.example",
+		After:  DomainOf(storage.Domain{Host: "go.example", RedirectCode: 301}),
+	})
+
+	entries, err := db.ListAudit(storage.AuditFilter{Alias: "docs"}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	deleted := entries[0]
+	assert.Equal(t, "bob", deleted.Actor)
+	assert.Equal(t, ActionLinkDelete, deleted.Action)
+	assert.Equal(t, "req-2", deleted.RequestID)
+	assert.Equal(t, "198.51.100.7", deleted.ClientIP)
+	assert.Nil(t, deleted.After)
+
+	var before Link
+	require.NoError(t, json.Unmarshal(deleted.Before, &before))
+	assert.Equal(t, LinkOf(link), before)
+
+	assert.Nil(t, entries[1].Before)
+
+	// Filters combine, and pages continue before the last ID seen.
+	entries, err = db.ListAudit(storage.AuditFilter{Actor: "bob"}, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ActionDomainCreate, entries[0].Action)
+
+	entries, err = db.ListAudit(storage.AuditFilter{Actor: "bob", BeforeID: entries[0].ID}, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ActionLinkDelete, entries[0].Action)
+
+	entries, err = db.ListAudit(storage.AuditFilter{Domain: "go.example"}, 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	var actions []string
+	require.NoError(t, db.ForEachAudit(storage.AuditFilter{}, func(e storage.AuditEntry) error {
+		actions = append(actions, e.Action)
+		return nil
+	}))
+	assert.Equal(t, []string{ActionLinkCreate, ActionLinkDelete, ActionDomainCreate}, actions)
+}
+
+func TestLog_RecordAs(t *testing.T) {
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	l := New(slogdiscard.NewDiscardLogger(), db)
+
+	l.RecordAs("root", Entry{
+		Action: ActionDatabaseRestore,
+		Before: Snapshot{Path: "/backups/before.db"},
+		After:  Snapshot{Path: "/backups/nightly.db"},
+	})
+
+	entries, err := db.ListAudit(storage.AuditFilter{Actor: "root"}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ActionDatabaseRestore, entries[0].Action)
+	assert.Empty(t, entries[0].RequestID)
+	assert.Empty(t, entries[0].ClientIP)
+
+	var after Snapshot
+	require.NoError(t, json.Unmarshal(entries[0].After, &after))
+	assert.Equal(t, "/backups/nightly.db", after.Path)
+}
+
+func TestLog_AppendOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	db, err := sqlite.New(path)
+	require.NoError(t, err)
+
+	require.NoError(t, db.AppendAudit(storage.AuditEntry{Actor: "alice", Action: ActionLinkCreate}))
+
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer func() { _ = raw.Close() }()
+
+	_, err = raw.Exec("UPDATE audit_log SET actor = 'mallory'")
+	assert.ErrorContains(t, err, "append-only")
+
+	_, err = raw.Exec("DELETE FROM audit_log")
+	assert.ErrorContains(t, err, "append-only")
+}
@@ -0,0 +1,157 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix = "snapshot-"
+	fileSuffix = ".db"
+	// timeLayout sorts lexicographically in time order.
+	timeLayout = "20060102-150405.000"
+)
+
+// Backuper writes a consistent snapshot of the database to a file.
+type Backuper interface {
+	Backup(ctx context.Context, destPath string) error
+}
+
+// Snapshot is a database snapshot in the backup directory.
+type Snapshot struct {
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Manager writes timestamped snapshots to a directory and keeps only the
+// most recent ones. It is safe for concurrent use; snapshots are taken one
+// at a time.
+type Manager struct {
+	backuper Backuper
+	dir      string
+	keep     int
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// New returns a Manager keeping the last keep snapshots in dir. A keep of
+// zero or less keeps every snapshot.
+func New(backuper Backuper, dir string, keep int) *Manager {
+	return &Manager{
+		backuper: backuper,
+		dir:      dir,
+		keep:     keep,
+		now:      time.Now,
+	}
+}
+
+// Snapshot takes a new snapshot and removes the ones past the retention.
+func (m *Manager) Snapshot(ctx context.Context) (Snapshot, error) {
+	const op = "lib.backup.Snapshot"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	createdAt := m.now().UTC()
+	path := filepath.Join(m.dir, filePrefix+createdAt.Format(timeLayout)+fileSuffix)
+
+	if err := m.backuper.Backup(ctx, path); err != nil {
+		return Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := m.prune(); err != nil {
+		return Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return Snapshot{Path: path, Size: info.Size(), CreatedAt: createdAt}, nil
+}
+
+// List returns the snapshots in the backup directory, newest first.
+func (m *Manager) List() ([]Snapshot, error) {
+	const op = "lib.backup.List"
+
+	snapshots, err := m.list()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return snapshots, nil
+}
+
+func (m *Manager) list() ([]Snapshot, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+
+		createdAt, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			// Not one of ours.
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, Snapshot{
+			Path:      filepath.Join(m.dir, name),
+			Size:      info.Size(),
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+func (m *Manager) prune() error {
+	if m.keep <= 0 {
+		return nil
+	}
+
+	snapshots, err := m.list()
+	if err != nil {
+		return err
+	}
+
+	for i := m.keep; i < len(snapshots); i++ {
+		if err := os.Remove(snapshots[i].Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+	"github.com/MaximShildyakov/url-shortener/internal/storage/sqlite"
+)
+
+func TestManager_Retention(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := sqlite.New(filepath.Join(dir, "storage.db"))
+	require.NoError(t, err)
+
+	backupDir := filepath.Join(dir, "backups")
+	m := New(db, backupDir, 2)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+
+	var taken []Snapshot
+	for i := 0; i < 3; i++ {
+		snapshot, err := m.Snapshot(context.Background())
+		require.NoError(t, err)
+
+		taken = append(taken, snapshot)
+	}
+
+	// A file the manager didn't write is left alone.
+	require.NoError(t, os.WriteFile(filepath.Join(backupDir, "notes.txt"), nil, 0o600))
+
+	snapshots, err := m.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{taken[2], taken[1]}, snapshots)
+
+	_, err = os.Stat(taken[0].Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSnapshot_Restore(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	db, err := sqlite.New(filepath.Join(dir, "storage.db"))
+	require.NoError(t, err)
+
+	_, err = db.SaveURL(storage.Link{Alias: "kept", URL: "https://example.com"})
+	require.NoError(t, err)
+
+	snapshot, err := New(db, filepath.Join(dir, "backups"), 0).Snapshot(ctx)
+	require.NoError(t, err)
+
+	_, err = db.SaveURL(storage.Link{Alias: "later", URL: "https://example.org"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Restore(ctx, snapshot.Path))
+
+	url, err := db.GetURL("", "kept")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", url)
+
+	_, err = db.GetURL("", "later")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestRestore_RejectsUnknownSchema(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	db, err := sqlite.New(filepath.Join(dir, "storage.db"))
+	require.NoError(t, err)
+
+	_, err = db.SaveURL(storage.Link{Alias: "kept", URL: "https://example.com"})
+	require.NoError(t, err)
+
+	blank := filepath.Join(dir, "blank.db")
+	require.NoError(t, os.WriteFile(blank, nil, 0o600))
+
+	newer := filepath.Join(dir, "newer.db")
+	other, err := sql.Open("sqlite3", newer)
+	require.NoError(t, err)
+	_, err = other.Exec("CREATE TABLE url(id INTEGER PRIMARY KEY); PRAGMA user_version = 1000")
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+
+	assert.ErrorIs(t, db.Restore(ctx, blank), sqlite.ErrUnsupportedSchema)
+	assert.ErrorIs(t, db.Restore(ctx, newer), sqlite.ErrUnsupportedSchema)
+
+	url, err := db.GetURL("", "kept")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", url)
+}
+
+func TestRestore_BaselineSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := sqlite.New(filepath.Join(dir, "storage.db"))
+	require.NoError(t, err)
+
+	// A database file from before migrations were tracked.
+	baseline := filepath.Join(dir, "baseline.db")
+	old, err := sql.Open("sqlite3", baseline)
+	require.NoError(t, err)
+	_, err = old.Exec(`
+	CREATE TABLE url(id INTEGER PRIMARY KEY, alias TEXT NOT NULL UNIQUE, url TEXT NOT NULL);
+	CREATE INDEX idx_alias ON url(alias);
+	INSERT INTO url(alias, url) VALUES('old', 'https://example.com/old')`)
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	require.NoError(t, db.Restore(context.Background(), baseline))
+
+	url, err := db.GetURL("", "old")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/old", url)
+}
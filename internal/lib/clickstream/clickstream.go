@@ -0,0 +1,164 @@
+package clickstream
+
+import (
+	"sync"
+	"time"
+)
+
+// Click is a redirect as streamed to subscribers.
+type Click struct {
+	// ID orders the clicks of this process, counting from 1. It starts
+	// over when the process restarts.
+	ID      uint64    `json:"id"`
+	Domain  string    `json:"domain,omitempty"`
+	Alias   string    `json:"alias"`
+	URL     string    `json:"url"`
+	Rule    string    `json:"rule,omitempty"`
+	Variant string    `json:"variant,omitempty"`
+	Button  string    `json:"button,omitempty"`
+	Country string    `json:"country,omitempty"`
+	Region  string    `json:"region,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Topic selects the clicks a subscriber gets. The zero Topic gets every
+// click.
+type Topic struct {
+	Domain string
+	Alias  string
+}
+
+func (t Topic) matches(c Click) bool {
+	return t.Alias == "" || t.Domain == c.Domain && t.Alias == c.Alias
+}
+
+// Hub fans clicks out to subscribers and keeps the latest ones so
+// reconnecting subscribers can catch up.
+//
+// Publish never waits for subscribers: one whose buffer is full is dropped
+// and has to resubscribe from the last click it got.
+type Hub struct {
+	mu sync.Mutex
+	// ring holds the latest clicks; the oldest is at start.
+	ring   []Click
+	start  int
+	count  int
+	lastID uint64
+
+	subs         map[*Subscription]struct{}
+	clientBuffer int
+}
+
+// New returns a Hub keeping the latest bufferSize clicks and buffering up to
+// clientBuffer clicks per subscriber.
+func New(bufferSize int, clientBuffer int) *Hub {
+	return &Hub{
+		ring:         make([]Click, max(bufferSize, 1)),
+		subs:         make(map[*Subscription]struct{}),
+		clientBuffer: max(clientBuffer, 1),
+	}
+}
+
+// Subscription receives the clicks of a Topic on C. C is closed when the
+// subscription is closed or dropped.
+type Subscription struct {
+	C <-chan Click
+
+	hub    *Hub
+	topic  Topic
+	c      chan Click
+	lagged bool
+}
+
+// Publish assigns c its ID and sends it to the matching subscribers.
+func (h *Hub) Publish(c Click) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	c.ID = h.lastID
+
+	if h.count < len(h.ring) {
+		h.ring[(h.start+h.count)%len(h.ring)] = c
+		h.count++
+	} else {
+		h.ring[h.start] = c
+		h.start = (h.start + 1) % len(h.ring)
+	}
+
+	for sub := range h.subs {
+		if !sub.topic.matches(c) {
+			continue
+		}
+
+		select {
+		case sub.c <- c:
+		default:
+			sub.lagged = true
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts a subscription to topic. Clicks after lastID that are
+// still kept are returned to be sent first; missed reports whether older
+// ones after lastID were already discarded. A zero lastID or one from
+// before a restart replays nothing.
+func (h *Hub) Subscribe(topic Topic, lastID uint64) (sub *Subscription, backlog []Click, missed bool) {
+	c := make(chan Click, h.clientBuffer)
+	sub = &Subscription{C: c, hub: h, topic: topic, c: c}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if lastID > 0 && lastID < h.lastID {
+		oldest := h.lastID - uint64(h.count) + 1
+		missed = lastID+1 < oldest
+
+		for i := 0; i < h.count; i++ {
+			click := h.ring[(h.start+i)%len(h.ring)]
+			if click.ID > lastID && topic.matches(click) {
+				backlog = append(backlog, click)
+			}
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+
+	return sub, backlog, missed
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
+
+// Lagged reports whether the subscription was dropped for falling behind.
+// It is only meaningful once C is closed.
+func (s *Subscription) Lagged() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.lagged
+}
+
+// Subscribers returns the number of open subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+
+	delete(h.subs, sub)
+	close(sub.c)
+}
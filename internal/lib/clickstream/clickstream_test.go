@@ -0,0 +1,117 @@
+package clickstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ids(clicks []Click) []uint64 {
+	var out []uint64
+	for _, c := range clicks {
+		out = append(out, c.ID)
+	}
+
+	return out
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	h := New(3, 10)
+
+	docs := Topic{Alias: "docs"}
+
+	sub, backlog, missed := h.Subscribe(docs, 0)
+	defer sub.Close()
+	assert.Empty(t, backlog)
+	assert.False(t, missed)
+
+	h.Publish(Click{Alias: "docs"})
+	h.Publish(Click{Alias: "blog"})
+	h.Publish(Click{Domain: "go.example.com", Alias: "docs"})
+	h.Publish(Click{Alias: "docs"})
+
+	got := <-sub.C
+	assert.Equal(t, uint64(1), got.ID)
+	got = <-sub.C
+	assert.Equal(t, uint64(4), got.ID, "other aliases and domains are filtered out")
+
+	// Clicks 2 to 4 are kept; 1 was pushed out of the ring.
+	resumed, backlog, missed := h.Subscribe(Topic{}, 2)
+	defer resumed.Close()
+	assert.Equal(t, []uint64{3, 4}, ids(backlog))
+	assert.False(t, missed)
+
+	resumed, backlog, missed = h.Subscribe(docs, 0)
+	defer resumed.Close()
+	assert.Empty(t, backlog, "a zero ID replays nothing")
+	assert.False(t, missed)
+
+	h.Publish(Click{Alias: "blog"})
+
+	resumed, backlog, missed = h.Subscribe(docs, 1)
+	defer resumed.Close()
+	assert.Equal(t, []uint64{4}, ids(backlog))
+	assert.True(t, missed, "click 2 is gone")
+
+	resumed, backlog, missed = h.Subscribe(docs, 99)
+	defer resumed.Close()
+	assert.Empty(t, backlog, "IDs from before a restart replay nothing")
+	assert.False(t, missed)
+}
+
+func TestHub_DropsLaggingSubscribers(t *testing.T) {
+	h := New(10, 2)
+
+	slow, _, _ := h.Subscribe(Topic{}, 0)
+	fast, _, _ := h.Subscribe(Topic{}, 0)
+	defer fast.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			h.Publish(Click{Alias: "docs"})
+			<-fast.C
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+
+	var got []uint64
+	for c := range slow.C {
+		got = append(got, c.ID)
+	}
+	assert.Equal(t, []uint64{1, 2}, got)
+	assert.True(t, slow.Lagged())
+	assert.False(t, fast.Lagged())
+	assert.Equal(t, 1, h.Subscribers())
+
+	// The dropped subscriber picks up where it left off.
+	resumed, backlog, missed := h.Subscribe(Topic{}, got[len(got)-1])
+	defer resumed.Close()
+	require.Equal(t, []uint64{3}, ids(backlog))
+	assert.False(t, missed)
+
+	slow.Close()
+}
+
+func TestSubscription_Close(t *testing.T) {
+	h := New(1, 1)
+
+	sub, _, _ := h.Subscribe(Topic{}, 0)
+	sub.Close()
+	sub.Close()
+
+	_, open := <-sub.C
+	assert.False(t, open)
+	assert.False(t, sub.Lagged())
+	assert.Zero(t, h.Subscribers())
+
+	h.Publish(Click{Alias: "docs"})
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+var (
+	ErrUnknownDomain       = errors.New("domain is not served by this shortener")
+	ErrInvalidRedirectCode = errors.New("redirect code must be one of 301, 302, 303, 307 or 308")
+)
+
+// Store lists the registered short domains.
+type Store interface {
+	ListDomains() ([]storage.Domain, error)
+}
+
+// Registry resolves request hosts to short domains. Hosts that aren't
+// registered belong to the default domain, whose links have an empty
+// domain.
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	store Store
+
+	mu      sync.RWMutex
+	domains map[string]storage.Domain
+}
+
+// New loads the registered domains from store.
+func New(store Store) (*Registry, error) {
+	const op = "lib.domain.New"
+
+	r := &Registry{store: store}
+
+	if err := r.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// Reload re-reads the domains from the store, after they were changed. On
+// error the previous domains stay in effect.
+func (r *Registry) Reload() error {
+	const op = "lib.domain.Reload"
+
+	list, err := r.store.ListDomains()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	domains := make(map[string]storage.Domain, len(list))
+	for _, d := range list {
+		domains[d.Host] = d
+	}
+
+	r.mu.Lock()
+	r.domains = domains
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Resolve returns the domain serving host, which may carry a port. Unknown
+// hosts get the default domain.
+func (r *Registry) Resolve(host string) storage.Domain {
+	r.mu.RLock()
+	d, ok := r.domains[Normalize(host)]
+	r.mu.RUnlock()
+
+	if !ok {
+		return storage.Domain{RedirectCode: http.StatusFound}
+	}
+
+	return d
+}
+
+// CheckDomain returns ErrUnknownDomain unless links may be created on host.
+// The empty host is the default domain.
+func (r *Registry) CheckDomain(host string) error {
+	host = Normalize(host)
+	if host == "" {
+		return nil
+	}
+
+	r.mu.RLock()
+	_, ok := r.domains[host]
+	r.mu.RUnlock()
+
+	if !ok {
+		return ErrUnknownDomain
+	}
+
+	return nil
+}
+
+// CheckURL rejects destinations on our own short domains, which would
+// redirect in a loop.
+func (r *Registry) CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// Left to the destination policy.
+		return nil
+	}
+
+	if r.CheckDomain(u.Hostname()) == nil && u.Hostname() != "" {
+		return &policy.Violation{
+			Rule:    policy.RuleShortDomain,
+			Message: "links to this shortener are not allowed",
+		}
+	}
+
+	return nil
+}
+
+// Normalize returns host lowercased, without a port or trailing dot.
+func Normalize(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// ValidRedirectCode reports whether code is a redirect status a domain may
+// use.
+func ValidRedirectCode(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+	"github.com/MaximShildyakov/url-shortener/internal/storage/sqlite"
+)
+
+func TestRegistry(t *testing.T) {
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	require.NoError(t, db.SaveDomain(storage.Domain{
+		Host:         "go.example",
+		FallbackURL:  "https://example.com/",
+		RedirectCode: http.StatusMovedPermanently,
+	}))
+
+	r, err := New(db)
+	require.NoError(t, err)
+
+	d := r.Resolve("GO.example.:8080")
+	assert.Equal(t, "go.example", d.Host)
+	assert.Equal(t, http.StatusMovedPermanently, d.RedirectCode)
+	assert.Equal(t, "https://example.com/", d.FallbackURL)
+
+	assert.Equal(t, storage.Domain{RedirectCode: http.StatusFound}, r.Resolve("localhost:8082"))
+
+	assert.NoError(t, r.CheckDomain(""))
+	assert.NoError(t, r.CheckDomain("Go.Example"))
+	assert.ErrorIs(t, r.CheckDomain("other.example"), ErrUnknownDomain)
+
+	var violation *policy.Violation
+	assert.ErrorAs(t, r.CheckURL("https://go.example/abc"), &violation)
+	assert.NoError(t, r.CheckURL("https://example.com/"))
+
+	require.NoError(t, db.SaveDomain(storage.Domain{Host: "new.example", RedirectCode: http.StatusFound}))
+	assert.ErrorIs(t, r.CheckDomain("new.example"), ErrUnknownDomain)
+	require.NoError(t, r.Reload())
+	assert.NoError(t, r.CheckDomain("new.example"))
+}
+
+func TestStorage_AliasPerDomain(t *testing.T) {
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	require.NoError(t, db.SaveDomain(storage.Domain{Host: "go.example", RedirectCode: http.StatusFound}))
+	assert.ErrorIs(t, db.SaveDomain(storage.Domain{Host: "go.example"}), storage.ErrDomainExists)
+
+	_, err = db.SaveURL(storage.Link{Alias: "docs", URL: "https://docs.example/"})
+	require.NoError(t, err)
+	_, err = db.SaveURL(storage.Link{Domain: "go.example", Alias: "docs", URL: "https://go.docs.example/"})
+	require.NoError(t, err)
+	_, err = db.SaveURL(storage.Link{Domain: "go.example", Alias: "docs", URL: "https://again.example/"})
+	assert.ErrorIs(t, err, storage.ErrAliasExists)
+
+	url, err := db.GetURL("", "docs")
+	require.NoError(t, err)
+	assert.Equal(t, "https://docs.example/", url)
+
+	url, err = db.GetURL("go.example", "docs")
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.docs.example/", url)
+
+	assert.ErrorIs(t, db.DeleteDomain("go.example"), storage.ErrDomainInUse)
+	require.NoError(t, db.DeleteURL("go.example", "docs", "alice"))
+	// The deleted link holds on to the domain until it is purged.
+	assert.ErrorIs(t, db.DeleteDomain("go.example"), storage.ErrDomainInUse)
+	_, err = db.PurgeDeleted(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, db.DeleteDomain("go.example"))
+	assert.ErrorIs(t, db.DeleteDomain("go.example"), storage.ErrDomainNotFound)
+
+	_, err = db.GetURL("", "docs")
+	assert.NoError(t, err)
+}
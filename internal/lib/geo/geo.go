@@ -0,0 +1,157 @@
+package geo
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is where an IP address is, as far as the database knows.
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 code, such as US.
+	Country string
+	// Region is the ISO 3166-2 code of the first-level subdivision, such
+	// as US-CA. Country databases have no regions.
+	Region string
+}
+
+// record holds the fields read from GeoIP2/GeoLite2 Country and City
+// databases.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+// DB looks up IP addresses in a local MaxMind-format (MMDB) database. No
+// lookups leave the machine.
+//
+// A DB is safe for concurrent use.
+type DB struct {
+	path string
+
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+}
+
+// New opens the database at path. With an empty path every lookup returns
+// an empty Location.
+func New(path string) (*DB, error) {
+	const op = "lib.geo.New"
+
+	db := &DB{path: path}
+
+	if path == "" {
+		return db, nil
+	}
+
+	if err := db.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return db, nil
+}
+
+// Reload re-reads the database file. On error the previous database stays
+// in effect.
+//
+// The file is read into memory, so it may be overwritten in place.
+func (db *DB) Reload() error {
+	const op = "lib.geo.Reload"
+
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := os.ReadFile(db.path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", op, db.path, err)
+	}
+
+	db.mu.Lock()
+	db.reader = reader
+	db.modTime = info.ModTime()
+	db.mu.Unlock()
+
+	return nil
+}
+
+// ReloadIfChanged reloads the database if the file was modified since it
+// was last read.
+func (db *DB) ReloadIfChanged() (bool, error) {
+	const op = "lib.geo.ReloadIfChanged"
+
+	if db.path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db.mu.RLock()
+	changed := !info.ModTime().Equal(db.modTime)
+	db.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+
+	if err := db.Reload(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Lookup returns the location of remoteAddr, an IP address that may carry
+// a port as in http.Request.RemoteAddr. Unknown and private addresses
+// have an empty Location.
+func (db *DB) Lookup(remoteAddr string) Location {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return Location{}
+	}
+
+	db.mu.RLock()
+	reader := db.reader
+	db.mu.RUnlock()
+
+	if reader == nil {
+		return Location{}
+	}
+
+	var rec record
+	if err := reader.Lookup(ip, &rec); err != nil {
+		// IPv6 addresses in an IPv4-only database, for one.
+		return Location{}
+	}
+
+	loc := Location{Country: strings.ToUpper(rec.Country.ISOCode)}
+	if loc.Country != "" && len(rec.Subdivisions) > 0 && rec.Subdivisions[0].ISOCode != "" {
+		loc.Region = loc.Country + "-" + strings.ToUpper(rec.Subdivisions[0].ISOCode)
+	}
+
+	return loc
+}
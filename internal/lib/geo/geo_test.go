@@ -0,0 +1,183 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.mmdb")
+	writeMMDB(t, path, map[string][2]string{
+		"203.0.113.0/24":  {"US", "CA"},
+		"198.51.100.0/24": {"de", ""},
+	})
+
+	db, err := New(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, Location{Country: "US", Region: "US-CA"}, db.Lookup("203.0.113.7:51234"))
+	assert.Equal(t, Location{Country: "DE"}, db.Lookup("198.51.100.1"))
+	assert.Equal(t, Location{}, db.Lookup("192.0.2.1"))
+	assert.Equal(t, Location{}, db.Lookup("not an ip"))
+
+	changed, err := db.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	writeMMDB(t, path, map[string][2]string{"192.0.2.0/24": {"FR", "IDF"}})
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	changed, err = db.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, Location{Country: "FR", Region: "FR-IDF"}, db.Lookup("192.0.2.1"))
+
+	// A broken file leaves the loaded database in place.
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+	require.NoError(t, os.Chtimes(path, later.Add(time.Minute), later.Add(time.Minute)))
+
+	_, err = db.ReloadIfChanged()
+	assert.Error(t, err)
+	assert.Equal(t, "FR", db.Lookup("192.0.2.1").Country)
+}
+
+func TestDB_NoPath(t *testing.T) {
+	db, err := New("")
+	require.NoError(t, err)
+
+	assert.Equal(t, Location{}, db.Lookup("203.0.113.7"))
+
+	changed, err := db.ReloadIfChanged()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
+
+// writeMMDB writes an IPv4 MaxMind DB mapping networks to a country and
+// subdivision ISO code.
+func writeMMDB(t *testing.T, path string, networks map[string][2]string) {
+	t.Helper()
+
+	const empty = -1
+
+	// Records are node indexes, empty, or -(data offset + 2).
+	nodes := [][2]int{{empty, empty}}
+	var data bytes.Buffer
+
+	for cidr, codes := range networks {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+
+		country := mmdbMap(mmdbString("iso_code"), mmdbString(codes[0]))
+		var value []byte
+		if codes[1] != "" {
+			value = mmdbMap(
+				mmdbString("country"), country,
+				mmdbString("subdivisions"), mmdbArray(mmdbMap(mmdbString("iso_code"), mmdbString(codes[1]))),
+			)
+		} else {
+			value = mmdbMap(mmdbString("country"), country)
+		}
+
+		offset := data.Len()
+		data.Write(value)
+
+		ones, _ := network.Mask.Size()
+		ip := binary.BigEndian.Uint32(network.IP.To4())
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := ip >> (31 - i) & 1
+			if i == ones-1 {
+				nodes[node][bit] = -(offset + 2)
+				break
+			}
+			if nodes[node][bit] == empty {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	nodeCount := len(nodes)
+
+	var out bytes.Buffer
+	for _, n := range nodes {
+		for _, rec := range n {
+			v := rec
+			switch {
+			case rec == empty:
+				v = nodeCount
+			case rec < 0:
+				v = nodeCount + 16 + (-rec - 2)
+			}
+			out.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	out.Write(mmdbMap(
+		mmdbString("node_count"), mmdbUint(6, uint64(nodeCount), 4),
+		mmdbString("record_size"), mmdbUint(5, 24, 2),
+		mmdbString("ip_version"), mmdbUint(5, 4, 2),
+		mmdbString("database_type"), mmdbString("Test"),
+		mmdbString("languages"), mmdbArray(),
+		mmdbString("binary_format_major_version"), mmdbUint(5, 2, 2),
+		mmdbString("binary_format_minor_version"), mmdbUint(5, 0, 2),
+		mmdbString("build_epoch"), mmdbUint(9, uint64(time.Now().Unix()), 8),
+		mmdbString("description"), mmdbMap(),
+	))
+
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o600))
+}
+
+// mmdbControl encodes a field's type and size, which must be below 29.
+func mmdbControl(typ int, size int) []byte {
+	if typ <= 7 {
+		return []byte{byte(typ<<5 | size)}
+	}
+
+	return []byte{byte(size), byte(typ - 7)}
+}
+
+func mmdbString(s string) []byte {
+	return append(mmdbControl(2, len(s)), s...)
+}
+
+func mmdbUint(typ int, v uint64, size int) []byte {
+	b := mmdbControl(typ, size)
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(8*i)))
+	}
+
+	return b
+}
+
+func mmdbMap(kv ...[]byte) []byte {
+	b := mmdbControl(7, len(kv)/2)
+	for _, field := range kv {
+		b = append(b, field...)
+	}
+
+	return b
+}
+
+func mmdbArray(items ...[]byte) []byte {
+	b := mmdbControl(11, len(items))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+
+	return b
+}
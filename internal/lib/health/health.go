@@ -0,0 +1,288 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Store keeps the results of health checks.
+type Store interface {
+	ListHealthDue(checkedBefore time.Time, limit int) ([]storage.Health, error)
+	SaveHealth(h storage.Health) error
+}
+
+// Options tune the checker.
+type Options struct {
+	// Interval is how long a result stays fresh before the link is checked
+	// again.
+	Interval time.Duration
+	// BatchSize caps the links checked in one run.
+	BatchSize int
+	// Concurrency caps the hosts checked at the same time. Links on one
+	// host are always checked one after another.
+	Concurrency int
+	// HostDelay is the pause between two requests to the same host.
+	HostDelay time.Duration
+	// Timeout bounds a single check, redirects included.
+	Timeout time.Duration
+	// WebhookURL, if set, is POSTed a JSON Event when a link breaks.
+	WebhookURL string
+	UserAgent  string
+}
+
+// Event is the webhook payload sent when a link's destination breaks.
+type Event struct {
+	Event      string    `json:"event"`
+	Domain     string    `json:"domain,omitempty"`
+	Alias      string    `json:"alias"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// EventBroken is the Event sent when a link's destination breaks.
+const EventBroken = "link.broken"
+
+// Checker checks that link destinations still answer. Only a link's own
+// URL is checked, not its rule or variant destinations.
+type Checker struct {
+	log    *slog.Logger
+	store  Store
+	opts   Options
+	client *http.Client
+}
+
+// New returns a Checker keeping results in store.
+func New(log *slog.Logger, store Store, opts Options) *Checker {
+	return &Checker{
+		log:    log.With(slog.String("component", "health")),
+		store:  store,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+// Run checks the links that are due, returning once all of them were
+// checked or ctx is done. It returns how many links were checked.
+func (c *Checker) Run(ctx context.Context) (int, error) {
+	const op = "lib.health.Run"
+
+	due, err := c.store.ListHealthDue(time.Now().Add(-c.opts.Interval), max(c.opts.BatchSize, 1))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Each host gets one goroutine working through its links, so the
+	// politeness delay holds; the semaphore bounds the hosts in flight.
+	byHost := make(map[string][]storage.Health)
+	var hosts []string
+	for _, h := range due {
+		host := hostOf(h.URL)
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], h)
+	}
+
+	sem := make(chan struct{}, max(c.opts.Concurrency, 1))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	checked := 0
+
+	for _, host := range hosts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return checked, ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(links []storage.Health) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			for i, prev := range links {
+				if i > 0 && !sleep(ctx, c.opts.HostDelay) {
+					return
+				}
+
+				if err := c.checkLink(ctx, prev); err != nil {
+					c.log.Error("failed to save health", slog.String("alias", prev.Alias), sl.Err(err))
+					continue
+				}
+
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			}
+		}(byHost[host])
+	}
+
+	wg.Wait()
+
+	return checked, ctx.Err()
+}
+
+// checkLink checks one link and stores the result. prev holds the last
+// result, if any.
+func (c *Checker) checkLink(ctx context.Context, prev storage.Health) error {
+	h := c.Check(ctx, prev.URL)
+	h.Domain, h.Alias = prev.Domain, prev.Alias
+
+	if !h.Healthy {
+		h.Failures = prev.Failures + 1
+	}
+
+	if err := c.store.SaveHealth(h); err != nil {
+		return err
+	}
+
+	// Only the first failure is announced, not every check after it.
+	if !h.Healthy && (prev.CheckedAt.IsZero() || prev.Healthy) {
+		c.log.Warn("link is broken",
+			slog.String("alias", h.Alias),
+			slog.String("url", h.URL),
+			slog.Int("status", h.StatusCode),
+			slog.String("error", h.Error),
+		)
+
+		if err := c.notify(ctx, h); err != nil {
+			c.log.Error("failed to send broken link webhook", slog.String("alias", h.Alias), sl.Err(err))
+		}
+	}
+
+	return nil
+}
+
+// Check requests rawURL and reports whether it answers with a status
+// below 400. Servers that refuse HEAD are asked again with GET.
+func (c *Checker) Check(ctx context.Context, rawURL string) storage.Health {
+	h := storage.Health{URL: rawURL}
+
+	start := time.Now()
+	status, err := c.request(ctx, http.MethodHead, rawURL)
+	if (err == nil && status >= http.StatusBadRequest) || (err != nil && !isTimeout(err)) {
+		start = time.Now()
+		status, err = c.request(ctx, http.MethodGet, rawURL)
+	}
+
+	h.Latency = time.Since(start)
+	h.CheckedAt = time.Now().UTC()
+	h.StatusCode = status
+
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+
+	h.Healthy = status < http.StatusBadRequest
+
+	return h
+}
+
+func (c *Checker) request(ctx context.Context, method string, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Drain a little so the connection can be reused; don't download
+	// whole pages.
+	_, _ = io.CopyN(io.Discard, resp.Body, 4<<10)
+
+	return resp.StatusCode, nil
+}
+
+func (c *Checker) notify(ctx context.Context, h storage.Health) error {
+	if c.opts.WebhookURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(Event{
+		Event:      EventBroken,
+		Domain:     h.Domain,
+		Alias:      h.Alias,
+		URL:        h.URL,
+		StatusCode: h.StatusCode,
+		Error:      h.Error,
+		CheckedAt:  h.CheckedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
+
+func isTimeout(err error) bool {
+	var netErr interface{ Timeout() bool }
+
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+	"github.com/MaximShildyakov/url-shortener/internal/storage/sqlite"
+)
+
+func TestCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New(slogdiscard.NewDiscardLogger(), nil, Options{Timeout: 100 * time.Millisecond})
+
+	cases := []struct {
+		path     string
+		healthy  bool
+		status   int
+		hasError bool
+	}{
+		{path: "/ok", healthy: true, status: http.StatusOK},
+		{path: "/no-head", healthy: true, status: http.StatusOK},
+		{path: "/gone", status: http.StatusNotFound},
+		{path: "/slow", hasError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			h := c.Check(context.Background(), srv.URL+tc.path)
+
+			assert.Equal(t, tc.healthy, h.Healthy)
+			assert.Equal(t, tc.status, h.StatusCode)
+			assert.Equal(t, tc.hasError, h.Error != "")
+			assert.False(t, h.CheckedAt.IsZero())
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var hits []time.Time
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer destination.Close()
+
+	events := make(chan Event, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		events <- e
+	}))
+	defer webhook.Close()
+
+	for alias, path := range map[string]string{"ok": "/ok", "gone": "/gone"} {
+		_, err := db.SaveURL(storage.Link{Alias: alias, URL: destination.URL + path})
+		require.NoError(t, err)
+	}
+
+	opts := Options{
+		Interval:    time.Hour,
+		BatchSize:   10,
+		Concurrency: 4,
+		HostDelay:   50 * time.Millisecond,
+		Timeout:     time.Second,
+		WebhookURL:  webhook.URL,
+	}
+	c := New(slogdiscard.NewDiscardLogger(), db, opts)
+
+	checked, err := c.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, checked)
+
+	// Both links are on one host, so the second check waited.
+	mu.Lock()
+	require.GreaterOrEqual(t, len(hits), 2)
+	assert.GreaterOrEqual(t, hits[len(hits)-1].Sub(hits[0]), opts.HostDelay)
+	mu.Unlock()
+
+	select {
+	case e := <-events:
+		assert.Equal(t, EventBroken, e.Event)
+		assert.Equal(t, "gone", e.Alias)
+		assert.Equal(t, http.StatusNotFound, e.StatusCode)
+	case <-time.After(time.Second):
+		t.Fatal("no webhook")
+	}
+
+	broken, err := db.ListBrokenLinks(10)
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	assert.Equal(t, "gone", broken[0].Alias)
+	assert.Equal(t, 1, broken[0].Failures)
+
+	// Fresh results aren't checked again.
+	checked, err = c.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, checked)
+
+	// A link that stays broken is announced once.
+	c.opts.Interval = 0
+	_, err = c.Run(context.Background())
+	require.NoError(t, err)
+
+	broken, err = db.ListBrokenLinks(10)
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	assert.Equal(t, 2, broken[0].Failures)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,126 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Limits on the descriptive fields of one link.
+const (
+	MaxTitle       = 200
+	MaxDescription = 2000
+	MaxTags        = 20
+	MaxTag         = 64
+	MaxMetadata    = 50
+	MaxKey         = 64
+	MaxValue       = 1024
+)
+
+var (
+	ErrTitleTooLong       = fmt.Errorf("title may be at most %d characters", MaxTitle)
+	ErrDescriptionTooLong = fmt.Errorf("description may be at most %d characters", MaxDescription)
+	ErrTooManyTags        = fmt.Errorf("a link may have at most %d tags", MaxTags)
+	ErrInvalidTag         = fmt.Errorf("tags must be 1 to %d letters, digits or -_.:", MaxTag)
+	ErrTooManyKeys        = fmt.Errorf("metadata may have at most %d keys", MaxMetadata)
+	ErrInvalidKey         = fmt.Errorf("metadata keys must be 1 to %d characters", MaxKey)
+	ErrValueTooLong       = fmt.Errorf("metadata values may be at most %d characters", MaxValue)
+	ErrEmptyTag           = errors.New("tag is empty")
+)
+
+// NormalizeTag returns tag in the form it is stored and matched in, so
+// "Summer-Sale " and "summer-sale" are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Tags normalizes and checks tags, dropping duplicates but keeping their
+// order otherwise.
+func Tags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	clean := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if err := ValidateTag(tag); err != nil {
+			return nil, err
+		}
+
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		clean = append(clean, tag)
+	}
+
+	if len(clean) > MaxTags {
+		return nil, ErrTooManyTags
+	}
+
+	return clean, nil
+}
+
+// ValidateTag checks a normalized tag.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return ErrEmptyTag
+	}
+
+	if utf8.RuneCountInString(tag) > MaxTag {
+		return ErrInvalidTag
+	}
+
+	for _, r := range tag {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', strings.ContainsRune("-_.:", r):
+		default:
+			return ErrInvalidTag
+		}
+	}
+
+	return nil
+}
+
+// Clean checks the title, description, tags and metadata of link, trims
+// its title and description and normalizes its tags in place.
+func Clean(link *storage.Link) error {
+	link.Title = strings.TrimSpace(link.Title)
+	link.Description = strings.TrimSpace(link.Description)
+
+	if utf8.RuneCountInString(link.Title) > MaxTitle {
+		return ErrTitleTooLong
+	}
+
+	if utf8.RuneCountInString(link.Description) > MaxDescription {
+		return ErrDescriptionTooLong
+	}
+
+	tags, err := Tags(link.Tags)
+	if err != nil {
+		return err
+	}
+	link.Tags = tags
+
+	if len(link.Metadata) > MaxMetadata {
+		return ErrTooManyKeys
+	}
+
+	for key, value := range link.Metadata {
+		if key == "" || utf8.RuneCountInString(key) > MaxKey {
+			return ErrInvalidKey
+		}
+
+		if utf8.RuneCountInString(value) > MaxValue {
+			return ErrValueTooLong
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestTags(t *testing.T) {
+	tags, err := Tags([]string{" Summer-Sale", "q3", "summer-sale", "region:eu"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summer-sale", "q3", "region:eu"}, tags)
+
+	tags, err = Tags(nil)
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+
+	_, err = Tags([]string{"two words"})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	_, err = Tags([]string{"  "})
+	assert.ErrorIs(t, err, ErrEmptyTag)
+
+	_, err = Tags([]string{strings.Repeat("a", MaxTag+1)})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	many := make([]string, 0, MaxTags+1)
+	for i := 0; i <= MaxTags; i++ {
+		many = append(many, "t"+strings.Repeat("x", i))
+	}
+	_, err = Tags(many)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+}
+
+func TestClean(t *testing.T) {
+	link := storage.Link{
+		Title:    "Spring launch",
+		Tags:     []string{"Launch"},
+		Metadata: map[string]string{"campaign": "spring-24", "owner_team": "growth"},
+	}
+	require.NoError(t, Clean(&link))
+	assert.Equal(t, []string{"launch"}, link.Tags)
+
+	cases := []struct {
+		name string
+		link storage.Link
+		err  error
+	}{
+		{"Long title", storage.Link{Title: strings.Repeat("x", MaxTitle+1)}, ErrTitleTooLong},
+		{"Long description", storage.Link{Description: strings.Repeat("x", MaxDescription+1)}, ErrDescriptionTooLong},
+		{"Empty key", storage.Link{Metadata: map[string]string{"": "x"}}, ErrInvalidKey},
+		{"Long value", storage.Link{Metadata: map[string]string{"k": strings.Repeat("x", MaxValue+1)}}, ErrValueTooLong},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, Clean(&tc.link), tc.err)
+		})
+	}
+}
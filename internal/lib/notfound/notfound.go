@@ -0,0 +1,137 @@
+package notfound
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "github.com/MaximShildyakov/url-shortener/internal/lib/api/response"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Strategies for answering requests for unknown aliases.
+const (
+	// StrategyPage renders an HTML 404 page.
+	StrategyPage = "page"
+	// StrategyRedirect sends the visitor to a fallback URL.
+	StrategyRedirect = "redirect"
+	// StrategyJSON answers every miss with a JSON error.
+	StrategyJSON = "json"
+)
+
+// defaultPage is the template used for domains without their own page.
+const defaultPage = "404.html"
+
+var ErrUnknownStrategy = errors.New("unknown not-found strategy")
+
+//go:embed 404.html
+var builtinPage string
+
+// Responder answers requests for aliases that don't exist.
+//
+// Clients that ask for JSON only always get a JSON error. Everyone else
+// is redirected to the domain's fallback URL if it has one; otherwise the
+// strategy decides.
+type Responder struct {
+	strategy    string
+	fallbackURL string
+	pages       *template.Template
+}
+
+// New returns a Responder using strategy. fallbackURL is where the
+// redirect strategy sends misses on domains without a fallback URL of
+// their own. templateDir may hold 404.html to replace the built-in page,
+// and <host>.html pages for individual domains.
+func New(strategy string, templateDir string, fallbackURL string) (*Responder, error) {
+	const op = "lib.notfound.New"
+
+	switch strategy {
+	case StrategyPage, StrategyRedirect, StrategyJSON:
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownStrategy, strategy)
+	}
+
+	pages, err := template.New(defaultPage).Parse(builtinPage)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if templateDir != "" {
+		if _, err := os.Stat(templateDir); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		files, err := filepath.Glob(filepath.Join(templateDir, "*.html"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// A 404.html in the directory replaces the built-in page.
+		if len(files) > 0 {
+			if pages, err = pages.ParseFiles(files...); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	return &Responder{
+		strategy:    strategy,
+		fallbackURL: fallbackURL,
+		pages:       pages,
+	}, nil
+}
+
+// Respond writes the answer to a request for alias on domain.
+func (n *Responder) Respond(w http.ResponseWriter, r *http.Request, domain storage.Domain, alias string) error {
+	if n.strategy == StrategyJSON || wantsJSON(r) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, resp.Error("not found"))
+
+		return nil
+	}
+
+	fallbackURL := domain.FallbackURL
+	if fallbackURL == "" && n.strategy == StrategyRedirect {
+		fallbackURL = n.fallbackURL
+	}
+	if fallbackURL != "" {
+		http.Redirect(w, r, fallbackURL, http.StatusFound)
+
+		return nil
+	}
+
+	page := n.pages.Lookup(domain.Host + ".html")
+	if domain.Host == "" || page == nil {
+		page = n.pages.Lookup(defaultPage)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNotFound)
+
+	return page.Execute(w, struct {
+		Alias  string
+		Domain string
+	}{
+		Alias:  alias,
+		Domain: domain.Host,
+	})
+}
+
+func wantsJSON(r *http.Request) bool {
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+		return format == "json"
+	}
+
+	accept := r.Header.Get("Accept")
+
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
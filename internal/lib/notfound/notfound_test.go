@@ -0,0 +1,109 @@
+package notfound
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestResponder(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.example.html"), []byte("<p>No {{.Alias}} here</p>"), 0o600))
+
+	defaultDomain := storage.Domain{RedirectCode: http.StatusFound}
+	goDomain := storage.Domain{Host: "go.example", RedirectCode: http.StatusFound}
+	promoDomain := storage.Domain{Host: "promo.example", FallbackURL: "https://promo.example/", RedirectCode: http.StatusFound}
+
+	cases := []struct {
+		name        string
+		strategy    string
+		domain      storage.Domain
+		accept      string
+		code        int
+		location    string
+		contentType string
+		contains    string
+	}{
+		{
+			name:        "Built-in page",
+			strategy:    StrategyPage,
+			domain:      defaultDomain,
+			code:        http.StatusNotFound,
+			contentType: "text/html; charset=utf-8",
+			contains:    "There is no short link <strong>abc</strong>",
+		},
+		{
+			name:        "Domain page",
+			strategy:    StrategyPage,
+			domain:      goDomain,
+			code:        http.StatusNotFound,
+			contentType: "text/html; charset=utf-8",
+			contains:    "<p>No abc here</p>",
+		},
+		{
+			name:        "JSON by Accept",
+			strategy:    StrategyPage,
+			domain:      promoDomain,
+			accept:      "application/json",
+			code:        http.StatusNotFound,
+			contentType: "application/json",
+			contains:    `"error":"not found"`,
+		},
+		{
+			name:     "Domain fallback",
+			strategy: StrategyPage,
+			domain:   promoDomain,
+			code:     http.StatusFound,
+			location: "https://promo.example/",
+		},
+		{
+			name:     "Global fallback",
+			strategy: StrategyRedirect,
+			domain:   goDomain,
+			code:     http.StatusFound,
+			location: "https://example.com/",
+		},
+		{
+			name:        "JSON strategy",
+			strategy:    StrategyJSON,
+			domain:      defaultDomain,
+			accept:      "text/html",
+			code:        http.StatusNotFound,
+			contentType: "application/json",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := New(tc.strategy, dir, "https://example.com/")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			require.NoError(t, n.Respond(rr, req, tc.domain, "abc"))
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, tc.location, rr.Header().Get("Location"))
+			if tc.contentType != "" {
+				assert.Contains(t, rr.Header().Get("Content-Type"), tc.contentType)
+			}
+			assert.Contains(t, rr.Body.String(), tc.contains)
+		})
+	}
+}
+
+func TestNew_UnknownStrategy(t *testing.T) {
+	_, err := New("teapot", "", "")
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+}
@@ -0,0 +1,121 @@
+package page
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// MaxButtons caps the buttons on one page.
+const MaxButtons = 50
+
+// MaxTitle and MaxLabel cap the length of page titles and button labels.
+const (
+	MaxTitle = 200
+	MaxLabel = 100
+)
+
+var (
+	ErrHasDestination    = errors.New("a page can't have a url, rules or variants")
+	ErrNoButtons         = errors.New("page needs at least one button")
+	ErrTooManyButtons    = fmt.Errorf("a page may have at most %d buttons", MaxButtons)
+	ErrTitleTooLong      = fmt.Errorf("page title must be at most %d characters", MaxTitle)
+	ErrUnknownTheme      = errors.New("page theme must be light or dark")
+	ErrInvalidAvatar     = errors.New("page avatar_url must be an http or https url")
+	ErrNoLabel           = errors.New("button needs a label")
+	ErrLabelTooLong      = fmt.Errorf("button label must be at most %d characters", MaxLabel)
+	ErrNoURL             = errors.New("button needs a url")
+	ErrInvalidButtonName = errors.New("button names may only use letters, digits, - and _")
+	ErrDuplicateButton   = errors.New("button names must be unique")
+)
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ButtonName returns the name the i-th button is counted under.
+func ButtonName(i int, b storage.Button) string {
+	if b.Name != "" {
+		return b.Name
+	}
+
+	return "button-" + strconv.Itoa(i+1)
+}
+
+// Theme returns the page's theme, ThemeLight if none is set.
+func Theme(p storage.Page) string {
+	if p.Theme == "" {
+		return storage.ThemeLight
+	}
+
+	return p.Theme
+}
+
+// Find returns the index of the button named name, or -1.
+func Find(p storage.Page, name string) int {
+	for i, b := range p.Buttons {
+		if ButtonName(i, b) == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Validate checks a page before it is stored. Button URLs are left to the
+// destination policy.
+func Validate(p storage.Page) error {
+	if len([]rune(p.Title)) > MaxTitle {
+		return ErrTitleTooLong
+	}
+
+	switch p.Theme {
+	case "", storage.ThemeLight, storage.ThemeDark:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownTheme, p.Theme)
+	}
+
+	if p.AvatarURL != "" && !isWebURL(p.AvatarURL) {
+		return ErrInvalidAvatar
+	}
+
+	if len(p.Buttons) == 0 {
+		return ErrNoButtons
+	}
+	if len(p.Buttons) > MaxButtons {
+		return ErrTooManyButtons
+	}
+
+	names := make(map[string]bool, len(p.Buttons))
+
+	for i, b := range p.Buttons {
+		name := ButtonName(i, b)
+
+		if !nameRe.MatchString(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidButtonName, name)
+		}
+		if b.Label == "" {
+			return fmt.Errorf("button %s: %w", name, ErrNoLabel)
+		}
+		if len([]rune(b.Label)) > MaxLabel {
+			return fmt.Errorf("button %s: %w", name, ErrLabelTooLong)
+		}
+		if b.URL == "" {
+			return fmt.Errorf("button %s: %w", name, ErrNoURL)
+		}
+		if names[name] {
+			return fmt.Errorf("%w: %q", ErrDuplicateButton, name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,61 @@
+package page
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestValidate(t *testing.T) {
+	button := storage.Button{Label: "Blog", URL: "https://example.com/blog"}
+
+	cases := []struct {
+		name string
+		page storage.Page
+		err  error
+	}{
+		{
+			name: "Valid",
+			page: storage.Page{
+				Title:     "Jane",
+				AvatarURL: "https://example.com/jane.png",
+				Theme:     storage.ThemeDark,
+				Buttons:   []storage.Button{button, {Name: "shop", Label: "Shop", URL: "https://example.com/shop"}},
+			},
+		},
+		{name: "Default theme", page: storage.Page{Buttons: []storage.Button{button}}},
+		{name: "No buttons", page: storage.Page{Title: "Jane"}, err: ErrNoButtons},
+		{name: "Unknown theme", page: storage.Page{Theme: "neon", Buttons: []storage.Button{button}}, err: ErrUnknownTheme},
+		{name: "Avatar not a web url", page: storage.Page{AvatarURL: "javascript:alert(1)", Buttons: []storage.Button{button}}, err: ErrInvalidAvatar},
+		{name: "Title too long", page: storage.Page{Title: strings.Repeat("a", MaxTitle+1), Buttons: []storage.Button{button}}, err: ErrTitleTooLong},
+		{name: "No label", page: storage.Page{Buttons: []storage.Button{{URL: "https://example.com"}}}, err: ErrNoLabel},
+		{name: "No url", page: storage.Page{Buttons: []storage.Button{{Label: "Blog"}}}, err: ErrNoURL},
+		{name: "Name not url safe", page: storage.Page{Buttons: []storage.Button{{Name: "my blog", Label: "Blog", URL: "https://example.com"}}}, err: ErrInvalidButtonName},
+		{
+			name: "Name clashes with a default",
+			page: storage.Page{Buttons: []storage.Button{button, {Name: "button-1", Label: "Shop", URL: "https://example.com/shop"}}},
+			err:  ErrDuplicateButton,
+		},
+		{name: "Too many buttons", page: storage.Page{Buttons: make([]storage.Button, MaxButtons+1)}, err: ErrTooManyButtons},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, Validate(tc.page), tc.err)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	p := storage.Page{Buttons: []storage.Button{
+		{Label: "Blog", URL: "https://example.com/blog"},
+		{Name: "shop", Label: "Shop", URL: "https://example.com/shop"},
+	}}
+
+	assert.Equal(t, 0, Find(p, "button-1"))
+	assert.Equal(t, 1, Find(p, "shop"))
+	assert.Equal(t, -1, Find(p, "button-2"))
+}
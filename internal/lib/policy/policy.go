@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+const (
+	RuleInvalid     = "invalid"
+	RuleScheme      = "scheme"
+	RuleDeniedHost  = "denied_host"
+	RulePrivateIP   = "private_ip"
+	RuleShortDomain = "short_domain"
+	RuleThreat      = "threat"
+)
+
+// Config is the destination policy as read from the policy file.
+type Config struct {
+	// AllowedSchemes lists the URL schemes links may point to.
+	AllowedSchemes []string `yaml:"allowed_schemes" env-default:"http,https"`
+	// DeniedHosts lists hosts links may not point to. Patterns use
+	// path.Match syntax, so "*.example.com" covers every subdomain.
+	DeniedHosts []string `yaml:"denied_hosts"`
+	// AllowPrivateIPs permits loopback, private and link-local addresses.
+	AllowPrivateIPs bool `yaml:"allow_private_ips" env-default:"false"`
+	// ShortDomains are our own hosts; shortening them would loop.
+	ShortDomains []string `yaml:"short_domains"`
+}
+
+// Violation is a destination rejected by a policy rule.
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Checker vets destinations, returning a *Violation for rejected ones.
+type Checker interface {
+	CheckURL(rawURL string) error
+}
+
+// Chain runs checkers in order and returns the first violation.
+type Chain []Checker
+
+func (c Chain) CheckURL(rawURL string) error {
+	for _, checker := range c {
+		if err := checker.CheckURL(rawURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Policy decides which destinations may be shortened.
+type Policy struct {
+	schemes         map[string]struct{}
+	deniedHosts     []string
+	allowPrivateIPs bool
+	shortDomains    map[string]struct{}
+}
+
+// Load reads a policy file. An empty path yields the default policy.
+func Load(configPath string) (Config, error) {
+	const op = "lib.policy.Load"
+
+	var cfg Config
+
+	if configPath == "" {
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return cfg, nil
+	}
+
+	if _, err := os.Stat(configPath); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cfg, nil
+}
+
+func New(cfg Config) *Policy {
+	p := &Policy{
+		schemes:         make(map[string]struct{}, len(cfg.AllowedSchemes)),
+		allowPrivateIPs: cfg.AllowPrivateIPs,
+		shortDomains:    make(map[string]struct{}, len(cfg.ShortDomains)),
+	}
+
+	for _, s := range cfg.AllowedSchemes {
+		p.schemes[strings.ToLower(s)] = struct{}{}
+	}
+	for _, h := range cfg.DeniedHosts {
+		p.deniedHosts = append(p.deniedHosts, strings.ToLower(h))
+	}
+	p.AddShortDomains(cfg.ShortDomains...)
+
+	return p
+}
+
+// AddShortDomains forbids links to the given hosts, which may carry a port.
+// It must be called before the policy is in use.
+func (p *Policy) AddShortDomains(hosts ...string) {
+	for _, h := range hosts {
+		if host, _, err := net.SplitHostPort(h); err == nil {
+			h = host
+		}
+		if h = normalizeHost(h); h != "" {
+			p.shortDomains[h] = struct{}{}
+		}
+	}
+}
+
+// CheckURL returns a *Violation if rawURL may not be shortened.
+func (p *Policy) CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &Violation{Rule: RuleInvalid, Message: "URL can't be parsed"}
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := p.schemes[scheme]; !ok {
+		return &Violation{
+			Rule:    RuleScheme,
+			Message: fmt.Sprintf("scheme %q is not allowed", scheme),
+		}
+	}
+
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return &Violation{Rule: RuleInvalid, Message: "URL has no host"}
+	}
+
+	if _, ok := p.shortDomains[host]; ok {
+		return &Violation{
+			Rule:    RuleShortDomain,
+			Message: "links to this shortener are not allowed",
+		}
+	}
+
+	for _, pattern := range p.deniedHosts {
+		if ok, _ := path.Match(pattern, host); ok {
+			return &Violation{
+				Rule:    RuleDeniedHost,
+				Message: fmt.Sprintf("host %q is not allowed", host),
+			}
+		}
+	}
+
+	if !p.allowPrivateIPs && isPrivateHost(host) {
+		return &Violation{
+			Rule:    RulePrivateIP,
+			Message: "private and loopback addresses are not allowed",
+		}
+	}
+
+	return nil
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// isPrivateHost reports whether host is, literally, a non-public address.
+// No DNS lookups are made, so names resolving to private addresses pass.
+func isPrivateHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	addr, ok := parseIP(host)
+	if !ok {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range from RFC 6598.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// parseIP parses host as an IP address, also accepting the shorthand IPv4
+// forms browsers do, such as 2130706433, 0x7f.1 or 127.1.
+func parseIP(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return addr, true
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+
+	nums := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 0, 32)
+		if err != nil || part == "" {
+			return netip.Addr{}, false
+		}
+		nums[i] = n
+	}
+
+	// The last part fills all remaining bytes; the others take one each.
+	var v uint64
+	for _, n := range nums[:len(nums)-1] {
+		if n > 0xff {
+			return netip.Addr{}, false
+		}
+		v = v<<8 | n
+	}
+
+	last := nums[len(nums)-1]
+	restBits := uint(8 * (5 - len(nums)))
+	if last >= 1<<restBits {
+		return netip.Addr{}, false
+	}
+	v = v<<restBits | last
+
+	return netip.AddrFrom4([4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}), true
+}
@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_CheckURL(t *testing.T) {
+	p := New(Config{
+		AllowedSchemes: []string{"http", "https"},
+		DeniedHosts:    []string{"*.evil.com", "bad.example"},
+		ShortDomains:   []string{"sho.rt"},
+	})
+	p.AddShortDomains("localhost:8082", "Go.Brand.")
+
+	tests := []struct {
+		name string
+		url  string
+		rule string
+	}{
+		{name: "public https", url: "https://example.com/path"},
+		{name: "public ip", url: "http://8.8.8.8/"},
+		{name: "javascript", url: "javascript:alert(1)", rule: RuleScheme},
+		{name: "data", url: "data:text/html;base64,PHNjcmlwdD4=", rule: RuleScheme},
+		{name: "file", url: "file:///etc/passwd", rule: RuleScheme},
+		{name: "uppercase scheme", url: "HTTPS://example.com", rule: ""},
+		{name: "wildcard subdomain", url: "https://a.b.evil.com/", rule: RuleDeniedHost},
+		{name: "wildcard apex not covered", url: "https://evil.com/"},
+		{name: "exact host", url: "https://BAD.example./x", rule: RuleDeniedHost},
+		{name: "own domain", url: "https://sho.rt/abc", rule: RuleShortDomain},
+		{name: "own domain with port", url: "http://go.brand:9000/abc", rule: RuleShortDomain},
+		{name: "loopback", url: "http://127.0.0.1/", rule: RulePrivateIP},
+		{name: "localhost", url: "http://localhost:8080/", rule: RuleShortDomain},
+		{name: "localhost subdomain", url: "http://app.localhost/", rule: RulePrivateIP},
+		{name: "private", url: "http://10.1.2.3/", rule: RulePrivateIP},
+		{name: "link local metadata", url: "http://169.254.169.254/latest", rule: RulePrivateIP},
+		{name: "decimal loopback", url: "http://2130706433/", rule: RulePrivateIP},
+		{name: "hex shorthand loopback", url: "http://0x7f.1/", rule: RulePrivateIP},
+		{name: "ipv6 loopback", url: "http://[::1]/", rule: RulePrivateIP},
+		{name: "ipv4 mapped", url: "http://[::ffff:192.168.0.1]/", rule: RulePrivateIP},
+		{name: "unique local ipv6", url: "http://[fd00::1]/", rule: RulePrivateIP},
+		{name: "no host", url: "https:///path", rule: RuleInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.CheckURL(tt.url)
+			if tt.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var v *Violation
+			require.True(t, errors.As(err, &v), "expected violation, got %v", err)
+			assert.Equal(t, tt.rule, v.Rule)
+		})
+	}
+}
+
+func TestPolicy_AllowPrivateIPs(t *testing.T) {
+	p := New(Config{AllowedSchemes: []string{"http"}, AllowPrivateIPs: true})
+
+	assert.NoError(t, p.CheckURL("http://192.168.1.1/"))
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http", "https"}, cfg.AllowedSchemes)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("allowed_schemes: [\"https\"]\ndenied_hosts: [\"*.evil.com\"]\n"), 0o600))
+
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https"}, cfg.AllowedSchemes)
+	assert.Equal(t, []string{"*.evil.com"}, cfg.DeniedHosts)
+}
@@ -0,0 +1,248 @@
+package qr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	MinSize   = 64
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+var (
+	ErrInvalidFormat = errors.New("format must be png or svg")
+	ErrInvalidSize   = fmt.Errorf("size must be between %d and %d", MinSize, MaxSize)
+	ErrInvalidMargin = fmt.Errorf("margin must be between 0 and %d", MaxMargin)
+	ErrInvalidLevel  = errors.New("level must be one of L, M, Q or H")
+	ErrInvalidColor  = errors.New("colors must be hex RGB such as 000 or 1a2b3c")
+	ErrLowContrast   = errors.New("foreground and background colors are too similar to scan")
+)
+
+// Options describe how a code is drawn.
+type Options struct {
+	Format string
+	// Size is the requested width in pixels. Modules are whole pixels, so
+	// PNGs may come out slightly smaller.
+	Size int
+	// Margin is the quiet zone in modules. Scanners expect 4.
+	Margin int
+	// Level is the error correction level: L, M, Q or H.
+	Level      string
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+// DefaultOptions are black on white, 256px, medium error correction.
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       256,
+		Margin:     4,
+		Level:      "M",
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Validate checks that every option is in range.
+func (o Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return ErrInvalidFormat
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return ErrInvalidSize
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return ErrInvalidMargin
+	}
+	if _, ok := levels[o.Level]; !ok {
+		return ErrInvalidLevel
+	}
+	if contrast(o.Foreground, o.Background) < minContrast {
+		return ErrLowContrast
+	}
+
+	return nil
+}
+
+// Key identifies the options, for building cache validators.
+func (o Options) Key() string {
+	return fmt.Sprintf("%s:%d:%d:%s:%s:%s", o.Format, o.Size, o.Margin, o.Level, Hex(o.Foreground), Hex(o.Background))
+}
+
+// ContentType returns the MIME type of the rendered code.
+func (o Options) ContentType() string {
+	if o.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+
+	return "image/png"
+}
+
+// Encode writes the QR code for content to w.
+func Encode(w io.Writer, content string, opts Options) error {
+	const op = "lib.qr.Encode"
+
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	code, err := qrcode.New(content, levels[opts.Level])
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	code.DisableBorder = true
+
+	modules := code.Bitmap()
+
+	if opts.Format == FormatSVG {
+		err = writeSVG(w, modules, opts)
+	} else {
+		err = writePNG(w, modules, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func writePNG(w io.Writer, modules [][]bool, opts Options) error {
+	total := len(modules) + 2*opts.Margin
+	scale := max(opts.Size/total, 1)
+	side := total * scale
+
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{opts.Background, opts.Foreground})
+
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+
+			x0 := (x + opts.Margin) * scale
+			y0 := (y + opts.Margin) * scale
+			for py := y0; py < y0+scale; py++ {
+				for px := x0; px < x0+scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+
+	return enc.Encode(w, img)
+}
+
+func writeSVG(w io.Writer, modules [][]bool, opts Options) error {
+	total := len(modules) + 2*opts.Margin
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`, total, total, svgFill(opts.Background))
+	fmt.Fprintf(bw, `<path fill="%s" d="`, svgFill(opts.Foreground))
+
+	// One subpath per horizontal run of dark modules.
+	for y, row := range modules {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+
+			fmt.Fprintf(bw, "M%d %dh%dv1h-%dz", start+opts.Margin, y+opts.Margin, x-start, x-start)
+		}
+	}
+
+	bw.WriteString(`"/></svg>`)
+
+	return bw.Flush()
+}
+
+// svgFill returns the value of a fill attribute, followed by fill-opacity
+// for translucent colors.
+func svgFill(c color.NRGBA) string {
+	fill := fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	if c.A == 0xff {
+		return fill
+	}
+
+	return fmt.Sprintf(`%s" fill-opacity="%.3g`, fill, float64(c.A)/0xff)
+}
+
+// ParseColor parses a hex color with an optional leading #: RGB, RRGGBB or
+// RRGGBBAA.
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return color.NRGBA{}, ErrInvalidColor
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, ErrInvalidColor
+	}
+
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// Hex formats c as RRGGBB, or RRGGBBAA if it is not opaque.
+func Hex(c color.NRGBA) string {
+	if c.A == 0xff {
+		return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+	}
+
+	return fmt.Sprintf("%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// minContrast is a luminance difference most phone scanners still read.
+const minContrast = 0.3
+
+func contrast(a color.NRGBA, b color.NRGBA) float64 {
+	d := luminance(a) - luminance(b)
+	if d < 0 {
+		d = -d
+	}
+
+	return d
+}
+
+func luminance(c color.NRGBA) float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 0xff
+}
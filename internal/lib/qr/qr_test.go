@@ -0,0 +1,103 @@
+package qr
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode_PNG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Size = 300
+	opts.Foreground = color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, "http://localhost:8082/abc", opts))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+
+	// Version 2 is 25 modules, plus 4 of margin on each side: 33 modules
+	// of 9px each.
+	assert.Equal(t, 297, img.Bounds().Dx())
+	assert.Equal(t, 297, img.Bounds().Dy())
+
+	// The margin is background, the top-left finder pattern is foreground.
+	assert.Equal(t, toNRGBA(opts.Background), toNRGBA(img.At(0, 0)))
+	assert.Equal(t, toNRGBA(opts.Foreground), toNRGBA(img.At(4*9, 4*9)))
+}
+
+func TestEncode_SVG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = FormatSVG
+	opts.Margin = 0
+	opts.Background = color.NRGBA{R: 0xff, G: 0xff, B: 0xff}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, "http://localhost:8082/abc", opts))
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256" viewBox="0 0 25 25"`))
+	assert.Contains(t, svg, `fill="#ffffff" fill-opacity="0"`)
+	// The finder pattern's top edge is a run of 7 dark modules.
+	assert.Contains(t, svg, `M0 0h7v1h-7z`)
+	assert.True(t, strings.HasSuffix(svg, `"/></svg>`))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(o *Options)
+		err    error
+	}{
+		{name: "defaults", modify: func(o *Options) {}},
+		{name: "format", modify: func(o *Options) { o.Format = "gif" }, err: ErrInvalidFormat},
+		{name: "too small", modify: func(o *Options) { o.Size = 10 }, err: ErrInvalidSize},
+		{name: "too large", modify: func(o *Options) { o.Size = 5000 }, err: ErrInvalidSize},
+		{name: "margin", modify: func(o *Options) { o.Margin = -1 }, err: ErrInvalidMargin},
+		{name: "level", modify: func(o *Options) { o.Level = "X" }, err: ErrInvalidLevel},
+		{name: "contrast", modify: func(o *Options) { o.Foreground = color.NRGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff} }, err: ErrLowContrast},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+
+			assert.ErrorIs(t, opts.Validate(), tt.err)
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.NRGBA
+		err  error
+	}{
+		{in: "000", want: color.NRGBA{A: 0xff}},
+		{in: "#f80", want: color.NRGBA{R: 0xff, G: 0x88, B: 0x00, A: 0xff}},
+		{in: "1a2B3c", want: color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}},
+		{in: "#ffffff80", want: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x80}},
+		{in: "red", err: ErrInvalidColor},
+		{in: "12345", err: ErrInvalidColor},
+		{in: "gggggg", err: ErrInvalidColor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseColor(tt.in)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func toNRGBA(c color.Color) color.NRGBA {
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
+}
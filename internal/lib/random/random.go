@@ -1,21 +1,122 @@
 package random
 
 import (
-	"time"
-	"math/rand"
+	"crypto/rand"
+	"errors"
+	"fmt"
 )
 
-func NewRandomString(size int) string{
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+const (
+	// AlphabetAlphanumeric is the default alphabet: upper and lower case
+	// latin letters and digits.
+	AlphabetAlphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
 		"abcdefghijklmnopqrstuvwxyz" +
-		"0123456789")
+		"0123456789"
+
+	// AlphabetUnambiguous drops characters that are easily confused when
+	// read aloud or printed: 0/O/o, 1/l/I.
+	AlphabetUnambiguous = "ABCDEFGHJKLMNPQRSTUVWXYZ" +
+		"abcdefghijkmnpqrstuvwxyz" +
+		"23456789"
+)
+
+var (
+	ErrEmptyAlphabet       = errors.New("alphabet is empty")
+	ErrAlphabetTooLong     = errors.New("alphabet is longer than 256 characters")
+	ErrDuplicateInAlphabet = errors.New("alphabet contains duplicate characters")
+)
+
+// Source draws strings from an alphabet using crypto/rand.
+//
+// A Source is immutable and safe for concurrent use.
+type Source struct {
+	alphabet []byte
+	mask     byte
+}
+
+var defaultSource = MustNew(AlphabetAlphanumeric)
+
+// New returns a Source for the given alphabet of single-byte characters.
+func New(alphabet string) (*Source, error) {
+	if alphabet == "" {
+		return nil, ErrEmptyAlphabet
+	}
+	if len(alphabet) > 256 {
+		return nil, ErrAlphabetTooLong
+	}
+
+	var seen [256]bool
+	for i := 0; i < len(alphabet); i++ {
+		if seen[alphabet[i]] {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateInAlphabet, alphabet[i])
+		}
+		seen[alphabet[i]] = true
+	}
+
+	// mask is the smallest all-ones bit pattern covering every index.
+	mask := byte(0)
+	for int(mask) < len(alphabet)-1 {
+		mask = mask<<1 | 1
+	}
+
+	return &Source{alphabet: []byte(alphabet), mask: mask}, nil
+}
+
+// MustNew is like New but panics on an invalid alphabet.
+func MustNew(alphabet string) *Source {
+	s, err := New(alphabet)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// String returns a random string of the given size.
+//
+// Random bytes are masked down to the alphabet's bit width and values that
+// fall outside it are rejected, so every character is equally likely.
+func (s *Source) String(size int) (string, error) {
+	const op = "lib.random.Source.String"
+
+	if size <= 0 {
+		return "", nil
+	}
+
+	res := make([]byte, 0, size)
+	// Over-read a little so a single read usually covers the rejections.
+	buf := make([]byte, size+size/2+1)
+
+	for len(res) < size {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, b := range buf {
+			idx := int(b & s.mask)
+			if idx >= len(s.alphabet) {
+				continue
+			}
+
+			res = append(res, s.alphabet[idx])
+			if len(res) == size {
+				break
+			}
+		}
+	}
+
+	return string(res), nil
+}
 
-	b := make([]rune, size)
-	for i := range b {
-		b[i] = chars[rnd.Intn(len(chars))]
+// NewRandomString returns a random alphanumeric string of the given size.
+//
+// It panics if the system's secure random source fails, which leaves
+// nothing sensible to fall back to.
+func NewRandomString(size int) string {
+	s, err := defaultSource.String(size)
+	if err != nil {
+		panic(err)
 	}
 
-	return string(b)
-}
\ No newline at end of file
+	return s
+}
@@ -1,9 +1,13 @@
 package random
 
 import (
+	"math"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRandomString(t *testing.T) {
@@ -46,4 +50,125 @@ func TestNewRandomString(t *testing.T) {
 			assert.NotEqual(t, str1, str2)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		err      error
+	}{
+		{
+			name:     "alphanumeric",
+			alphabet: AlphabetAlphanumeric,
+		},
+		{
+			name:     "single character",
+			alphabet: "a",
+		},
+		{
+			name:     "empty",
+			alphabet: "",
+			err:      ErrEmptyAlphabet,
+		},
+		{
+			name:     "duplicates",
+			alphabet: "abca",
+			err:      ErrDuplicateInAlphabet,
+		},
+		{
+			name:     "too long",
+			alphabet: strings.Repeat("a", 257),
+			err:      ErrAlphabetTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.alphabet)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestSource_Alphabet(t *testing.T) {
+	s := MustNew(AlphabetUnambiguous)
+
+	str, err := s.String(10000)
+	require.NoError(t, err)
+
+	assert.Len(t, str, 10000)
+	for _, c := range "0Oo1lI" {
+		assert.NotContains(t, str, string(c))
+	}
+}
+
+func TestSource_Distribution(t *testing.T) {
+	const perChar = 2000
+
+	// Alphabets whose size is not a power of two are the ones a naive
+	// modulo would bias.
+	for _, alphabet := range []string{AlphabetAlphanumeric, AlphabetUnambiguous, "abc"} {
+		t.Run(alphabet, func(t *testing.T) {
+			s := MustNew(alphabet)
+
+			str, err := s.String(perChar * len(alphabet))
+			require.NoError(t, err)
+
+			counts := make(map[rune]int, len(alphabet))
+			for _, c := range str {
+				counts[c]++
+			}
+			require.Len(t, counts, len(alphabet))
+
+			// Pearson's chi-squared test against the uniform distribution.
+			var chi2 float64
+			for _, n := range counts {
+				d := float64(n - perChar)
+				chi2 += d * d / perChar
+			}
+
+			// Wilson-Hilferty approximation of the 99.99th percentile.
+			df := float64(len(alphabet) - 1)
+			h := 2 / (9 * df)
+			critical := df * math.Pow(1-h+3.72*math.Sqrt(h), 3)
+
+			assert.Lessf(t, chi2, critical, "distribution is not uniform: %v", counts)
+		})
+	}
+}
+
+func TestSource_ConcurrentUniqueness(t *testing.T) {
+	const (
+		workers   = 16
+		perWorker = 1000
+	)
+
+	s := MustNew(AlphabetAlphanumeric)
+
+	results := make(chan string, workers*perWorker)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < perWorker; j++ {
+				str, err := s.String(12)
+				assert.NoError(t, err)
+				results <- str
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[string]struct{}, workers*perWorker)
+	for str := range results {
+		_, dup := seen[str]
+		require.Falsef(t, dup, "duplicate string %q", str)
+		seen[str] = struct{}{}
+	}
+
+	assert.Len(t, seen, workers*perWorker)
+}
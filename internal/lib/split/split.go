@@ -0,0 +1,178 @@
+package split
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Ways of keeping a visitor on the variant they were first assigned.
+const (
+	// StickyCookie remembers the variant in a cookie scoped to the alias.
+	StickyCookie = "cookie"
+	// StickyIP derives the variant from a hash of the client IP, so no
+	// cookie is set. Changing weights may move visitors.
+	StickyIP = "ip"
+)
+
+// CookieName is the cookie holding the assigned variant.
+const CookieName = "variant"
+
+// MaxVariants caps the variants of one link.
+const MaxVariants = 10
+
+var (
+	ErrUnknownSticky    = errors.New("unknown sticky mode")
+	ErrTooManyVariants  = fmt.Errorf("a link may have at most %d variants", MaxVariants)
+	ErrNoURL            = errors.New("variant needs a url")
+	ErrNegativeWeight   = errors.New("variant weight must not be negative")
+	ErrNoWeight         = errors.New("at least one variant needs a positive weight")
+	ErrDuplicateVariant = errors.New("variant names must be unique")
+)
+
+// Picker assigns visitors to variants.
+type Picker struct {
+	sticky       string
+	cookieMaxAge time.Duration
+}
+
+// New returns a Picker keeping visitors on their variant the sticky way.
+// Cookies expire after cookieMaxAge.
+func New(sticky string, cookieMaxAge time.Duration) (*Picker, error) {
+	const op = "lib.split.New"
+
+	switch sticky {
+	case StickyCookie, StickyIP:
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownSticky, sticky)
+	}
+
+	return &Picker{sticky: sticky, cookieMaxAge: cookieMaxAge}, nil
+}
+
+// Pick returns the index of the variant the visitor sending r is assigned
+// for alias on domain, or -1 if variants is empty. In cookie mode it sets
+// the cookie on w.
+func (p *Picker) Pick(w http.ResponseWriter, r *http.Request, domain string, alias string, variants []storage.Variant) int {
+	total := totalWeight(variants)
+	if total == 0 {
+		return -1
+	}
+
+	if p.sticky == StickyIP {
+		return byWeight(variants, hashIP(r.RemoteAddr, domain, alias)%uint64(total))
+	}
+
+	if c, err := r.Cookie(CookieName); err == nil {
+		for i, v := range variants {
+			// Visitors stay unless their variant was switched off.
+			if VariantName(i, v) == c.Value && v.Weight > 0 {
+				return i
+			}
+		}
+	}
+
+	i := byWeight(variants, rand.Uint64N(uint64(total)))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    VariantName(i, variants[i]),
+		Path:     "/" + alias,
+		MaxAge:   int(p.cookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return i
+}
+
+// VariantName returns the name the i-th variant is counted under.
+func VariantName(i int, v storage.Variant) string {
+	if v.Name != "" {
+		return v.Name
+	}
+
+	return "variant-" + strconv.Itoa(i+1)
+}
+
+// Validate checks variants before they are stored. URLs are left to the
+// destination policy.
+func Validate(variants []storage.Variant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	if len(variants) > MaxVariants {
+		return ErrTooManyVariants
+	}
+
+	names := make(map[string]bool, len(variants))
+
+	for i, v := range variants {
+		name := VariantName(i, v)
+
+		if v.URL == "" {
+			return fmt.Errorf("variant %s: %w", name, ErrNoURL)
+		}
+		if v.Weight < 0 {
+			return fmt.Errorf("variant %s: %w", name, ErrNegativeWeight)
+		}
+		if names[name] {
+			return fmt.Errorf("%w: %q", ErrDuplicateVariant, name)
+		}
+		names[name] = true
+	}
+
+	if totalWeight(variants) == 0 {
+		return ErrNoWeight
+	}
+
+	return nil
+}
+
+func totalWeight(variants []storage.Variant) int {
+	total := 0
+	for _, v := range variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+
+	return total
+}
+
+// byWeight returns the variant covering point, which is below the total
+// weight.
+func byWeight(variants []storage.Variant, point uint64) int {
+	for i, v := range variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if point < uint64(v.Weight) {
+			return i
+		}
+		point -= uint64(v.Weight)
+	}
+
+	return -1
+}
+
+// hashIP spreads client IPs evenly, differently for every link.
+func hashIP(remoteAddr string, domain string, alias string) uint64 {
+	ip := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		ip = host
+	}
+
+	sum := sha256.Sum256([]byte(ip + "\x00" + domain + "\x00" + alias))
+
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,89 @@
+package split
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestPicker_Cookie(t *testing.T) {
+	p, err := New(StickyCookie, time.Hour)
+	require.NoError(t, err)
+
+	variants := []storage.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 1},
+		{Name: "b", URL: "https://example.com/b", Weight: 3},
+	}
+
+	counts := make([]int, len(variants))
+	for range 4000 {
+		rr := httptest.NewRecorder()
+		i := p.Pick(rr, httptest.NewRequest(http.MethodGet, "/promo", nil), "", "promo", variants)
+		require.GreaterOrEqual(t, i, 0)
+		counts[i]++
+
+		cookies := rr.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, variants[i].Name, cookies[0].Value)
+		assert.Equal(t, "/promo", cookies[0].Path)
+	}
+	assert.InDelta(t, 1000, counts[0], 150)
+
+	// The cookie keeps the visitor on their variant.
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: "a"})
+	for range 20 {
+		rr := httptest.NewRecorder()
+		assert.Equal(t, 0, p.Pick(rr, req, "", "promo", variants))
+		assert.Empty(t, rr.Result().Cookies())
+	}
+
+	// Unless the variant was switched off.
+	variants[0].Weight = 0
+	assert.Equal(t, 1, p.Pick(httptest.NewRecorder(), req, "", "promo", variants))
+}
+
+func TestPicker_IP(t *testing.T) {
+	p, err := New(StickyIP, 0)
+	require.NoError(t, err)
+
+	variants := []storage.Variant{
+		{URL: "https://example.com/a", Weight: 1},
+		{URL: "https://example.com/b", Weight: 1},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+
+	rr := httptest.NewRecorder()
+	first := p.Pick(rr, req, "", "promo", variants)
+	assert.Empty(t, rr.Result().Cookies())
+
+	req.RemoteAddr = "203.0.113.7:40000"
+	for range 20 {
+		assert.Equal(t, first, p.Pick(httptest.NewRecorder(), req, "", "promo", variants))
+	}
+
+	assert.Equal(t, -1, p.Pick(httptest.NewRecorder(), req, "", "promo", nil))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(nil))
+	assert.NoError(t, Validate([]storage.Variant{{URL: "https://example.com/", Weight: 1}, {URL: "https://example.org/"}}))
+	assert.ErrorIs(t, Validate([]storage.Variant{{URL: "https://example.com/"}}), ErrNoWeight)
+	assert.ErrorIs(t, Validate([]storage.Variant{{Weight: 1}}), ErrNoURL)
+	assert.ErrorIs(t, Validate([]storage.Variant{{URL: "https://example.com/", Weight: -1}}), ErrNegativeWeight)
+	assert.ErrorIs(t, Validate([]storage.Variant{
+		{URL: "https://example.com/", Weight: 1},
+		{Name: "variant-1", URL: "https://example.org/", Weight: 1},
+	}), ErrDuplicateVariant)
+
+	_, err := New("coin", 0)
+	assert.ErrorIs(t, err, ErrUnknownSticky)
+}
@@ -0,0 +1,316 @@
+package targeting
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Platforms a rule may target. Mobile and desktop group the others.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+	PlatformMobile  = "mobile"
+	PlatformDesktop = "desktop"
+)
+
+// MaxRules caps the rules on one link.
+const MaxRules = 20
+
+var (
+	ErrTooManyRules     = fmt.Errorf("a link may have at most %d rules", MaxRules)
+	ErrNoCondition      = errors.New("rule needs at least one of platforms, languages, countries, regions, start or end")
+	ErrNoURL            = errors.New("rule needs a url")
+	ErrUnknownPlatform  = errors.New("unknown platform")
+	ErrInvalidLanguage  = errors.New("invalid language tag")
+	ErrInvalidCountry   = errors.New("country must be an ISO 3166-1 alpha-2 code")
+	ErrInvalidRegion    = errors.New("region must be an ISO 3166-2 code")
+	ErrInvalidWindow    = errors.New("rule start must be before its end")
+	ErrDuplicateName    = errors.New("rule names must be unique")
+	ErrReservedRuleName = fmt.Errorf("rule name %q is reserved", storage.DefaultRule)
+)
+
+// Visitor is what rules match on, taken from a request.
+type Visitor struct {
+	// Platform is the visitor's operating system, one of the Platform
+	// constants other than mobile and desktop, or empty if unknown.
+	Platform string
+	// Languages are the accepted language tags, lowercased, in the order
+	// the visitor sent them.
+	Languages []string
+	// Country and Region locate the visitor's IP, as ISO 3166 codes. They
+	// are empty if unknown.
+	Country string
+	Region  string
+	Time    time.Time
+}
+
+// VisitorFromRequest describes the visitor sending r at now. The location
+// is left to the caller.
+func VisitorFromRequest(r *http.Request, now time.Time) Visitor {
+	return Visitor{
+		Platform:  Platform(r.UserAgent()),
+		Languages: AcceptedLanguages(r.Header.Get("Accept-Language")),
+		Time:      now,
+	}
+}
+
+// Match returns the index of the first rule v matches, or -1 if none does.
+func Match(rules []storage.Rule, v Visitor) int {
+	for i, rule := range rules {
+		if matches(rule, v) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// RuleName returns the name the i-th rule is counted under.
+func RuleName(i int, rule storage.Rule) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+
+	return "rule-" + strconv.Itoa(i+1)
+}
+
+// Validate checks rules before they are stored. URLs are left to the
+// destination policy.
+func Validate(rules []storage.Rule) error {
+	if len(rules) > MaxRules {
+		return ErrTooManyRules
+	}
+
+	names := make(map[string]bool, len(rules))
+
+	for i, rule := range rules {
+		name := RuleName(i, rule)
+
+		if err := validateRule(rule); err != nil {
+			return fmt.Errorf("rule %s: %w", name, err)
+		}
+
+		if name == storage.DefaultRule {
+			return ErrReservedRuleName
+		}
+		if names[name] {
+			return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+func validateRule(rule storage.Rule) error {
+	if rule.URL == "" {
+		return ErrNoURL
+	}
+
+	if len(rule.Platforms) == 0 && len(rule.Languages) == 0 && len(rule.Countries) == 0 &&
+		len(rule.Regions) == 0 && rule.Start == nil && rule.End == nil {
+		return ErrNoCondition
+	}
+
+	for _, p := range rule.Platforms {
+		switch strings.ToLower(p) {
+		case PlatformIOS, PlatformAndroid, PlatformWindows, PlatformMacOS, PlatformLinux,
+			PlatformMobile, PlatformDesktop:
+		default:
+			return fmt.Errorf("%w: %q", ErrUnknownPlatform, p)
+		}
+	}
+
+	for _, lang := range rule.Languages {
+		if !validLanguage(lang) {
+			return fmt.Errorf("%w: %q", ErrInvalidLanguage, lang)
+		}
+	}
+
+	for _, country := range rule.Countries {
+		if len(country) != 2 || !alphanumeric(country) {
+			return fmt.Errorf("%w: %q", ErrInvalidCountry, country)
+		}
+	}
+
+	for _, region := range rule.Regions {
+		country, subdivision, ok := strings.Cut(region, "-")
+		if !ok || len(country) != 2 || !alphanumeric(country) ||
+			subdivision == "" || len(subdivision) > 3 || !alphanumeric(subdivision) {
+			return fmt.Errorf("%w: %q", ErrInvalidRegion, region)
+		}
+	}
+
+	if rule.Start != nil && rule.End != nil && !rule.Start.Before(*rule.End) {
+		return ErrInvalidWindow
+	}
+
+	return nil
+}
+
+func matches(rule storage.Rule, v Visitor) bool {
+	if len(rule.Platforms) > 0 && !matchPlatform(rule.Platforms, v.Platform) {
+		return false
+	}
+
+	if len(rule.Languages) > 0 && !matchLanguage(rule.Languages, v.Languages) {
+		return false
+	}
+
+	if len(rule.Countries) > 0 && !matchCode(rule.Countries, v.Country) {
+		return false
+	}
+
+	if len(rule.Regions) > 0 && !matchCode(rule.Regions, v.Region) {
+		return false
+	}
+
+	if rule.Start != nil && v.Time.Before(*rule.Start) {
+		return false
+	}
+
+	// The window is half-open: the rule stops applying at End.
+	if rule.End != nil && !v.Time.Before(*rule.End) {
+		return false
+	}
+
+	return true
+}
+
+func matchPlatform(platforms []string, platform string) bool {
+	if platform == "" {
+		return false
+	}
+
+	for _, p := range platforms {
+		switch p = strings.ToLower(p); p {
+		case PlatformMobile:
+			if platform == PlatformIOS || platform == PlatformAndroid {
+				return true
+			}
+		case PlatformDesktop:
+			if platform == PlatformWindows || platform == PlatformMacOS || platform == PlatformLinux {
+				return true
+			}
+		default:
+			if p == platform {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchCode reports whether code is one of codes, ignoring case. An
+// unknown location matches nothing.
+func matchCode(codes []string, code string) bool {
+	if code == "" {
+		return false
+	}
+
+	for _, c := range codes {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchLanguage reports whether the visitor accepts any of languages. A
+// bare language such as "pt" matches "pt-br" too, but "pt-br" doesn't match
+// "pt".
+func matchLanguage(languages []string, accepted []string) bool {
+	for _, want := range languages {
+		want = strings.ToLower(want)
+
+		for _, got := range accepted {
+			if got == want || strings.HasPrefix(got, want+"-") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Platform returns the operating system userAgent runs on, or "" if it
+// can't tell.
+func Platform(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	// Order matters: iOS and Android user agents also mention the desktop
+	// systems they imitate.
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return PlatformIOS
+	case strings.Contains(ua, "android"):
+		return PlatformAndroid
+	case strings.Contains(ua, "windows"):
+		return PlatformWindows
+	case strings.Contains(ua, "macintosh"), strings.Contains(ua, "mac os x"):
+		return PlatformMacOS
+	case strings.Contains(ua, "linux"), strings.Contains(ua, "x11"):
+		return PlatformLinux
+	}
+
+	return ""
+}
+
+// AcceptedLanguages parses an Accept-Language header into lowercased
+// language tags. Tags with q=0 and the wildcard are dropped.
+func AcceptedLanguages(header string) []string {
+	var langs []string
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight <= 0 {
+				continue
+			}
+		}
+
+		langs = append(langs, tag)
+	}
+
+	return langs
+}
+
+func validLanguage(tag string) bool {
+	if tag == "" || len(tag) > 35 {
+		return false
+	}
+
+	for _, subtag := range strings.Split(tag, "-") {
+		if subtag == "" || len(subtag) > 8 || !alphanumeric(subtag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func alphanumeric(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,125 @@
+package targeting
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36"
+	macUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15"
+	windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+)
+
+func TestPlatform(t *testing.T) {
+	assert.Equal(t, PlatformIOS, Platform(iPhoneUA))
+	assert.Equal(t, PlatformAndroid, Platform(androidUA))
+	assert.Equal(t, PlatformMacOS, Platform(macUA))
+	assert.Equal(t, PlatformWindows, Platform(windowsUA))
+	assert.Equal(t, PlatformLinux, Platform("Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"))
+	assert.Equal(t, "", Platform("curl/8.4.0"))
+}
+
+func TestAcceptedLanguages(t *testing.T) {
+	assert.Equal(t, []string{"pt-br", "pt", "en"}, AcceptedLanguages("pt-BR, pt;q=0.9, en;q=0.5, de;q=0, *;q=0.1"))
+	assert.Empty(t, AcceptedLanguages(""))
+}
+
+func TestMatch(t *testing.T) {
+	start := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	rules := []storage.Rule{
+		{Name: "sale", Start: &start, End: &end, URL: "https://example.com/sale"},
+		{Name: "california", Regions: []string{"US-CA"}, URL: "https://example.com/ca"},
+		{Name: "dach", Countries: []string{"de", "AT", "CH"}, URL: "https://example.com/de"},
+		{Platforms: []string{"ios"}, URL: "https://apps.apple.com/app"},
+		{Platforms: []string{"mobile"}, Languages: []string{"pt"}, URL: "https://example.com/pt/m"},
+		{Platforms: []string{"desktop"}, URL: "https://example.com/desktop"},
+	}
+
+	before := start.Add(-time.Hour)
+
+	cases := []struct {
+		name     string
+		ua       string
+		language string
+		country  string
+		region   string
+		at       time.Time
+		want     int
+	}{
+		{name: "Time window", ua: iPhoneUA, at: start, want: 0},
+		{name: "Window end is exclusive", ua: iPhoneUA, at: end, want: 3},
+		{name: "Region", ua: iPhoneUA, country: "US", region: "US-CA", at: before, want: 1},
+		{name: "Country", ua: iPhoneUA, country: "DE", region: "DE-BE", at: before, want: 2},
+		{name: "Other country", ua: iPhoneUA, country: "FR", at: before, want: 3},
+		{name: "Platform", ua: iPhoneUA, at: before, want: 3},
+		{name: "Platform and language", ua: androidUA, language: "pt-BR,en;q=0.8", at: before, want: 4},
+		{name: "Language mismatch", ua: androidUA, language: "en-US", at: before, want: -1},
+		{name: "Platform group", ua: windowsUA, at: before, want: 5},
+		{name: "Unknown platform", ua: "curl/8.4.0", at: before, want: -1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/abc", nil)
+			r.Header.Set("User-Agent", tc.ua)
+			r.Header.Set("Accept-Language", tc.language)
+
+			v := VisitorFromRequest(r, tc.at)
+			v.Country, v.Region = tc.country, tc.region
+
+			assert.Equal(t, tc.want, Match(rules, v))
+		})
+	}
+
+	assert.Equal(t, "sale", RuleName(0, rules[0]))
+	assert.Equal(t, "rule-4", RuleName(3, rules[3]))
+}
+
+func TestValidate(t *testing.T) {
+	start := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	cases := []struct {
+		name  string
+		rules []storage.Rule
+		err   error
+	}{
+		{name: "Valid", rules: []storage.Rule{{Platforms: []string{"iOS"}, Languages: []string{"pt-BR"}, Start: &start, End: &end, URL: "https://example.com/"}}},
+		{name: "No URL", rules: []storage.Rule{{Platforms: []string{"ios"}}}, err: ErrNoURL},
+		{name: "No condition", rules: []storage.Rule{{URL: "https://example.com/"}}, err: ErrNoCondition},
+		{name: "Unknown platform", rules: []storage.Rule{{Platforms: []string{"beos"}, URL: "https://example.com/"}}, err: ErrUnknownPlatform},
+		{name: "Invalid language", rules: []storage.Rule{{Languages: []string{"en_US"}, URL: "https://example.com/"}}, err: ErrInvalidLanguage},
+		{name: "Invalid country", rules: []storage.Rule{{Countries: []string{"USA"}, URL: "https://example.com/"}}, err: ErrInvalidCountry},
+		{name: "Invalid region", rules: []storage.Rule{{Regions: []string{"CA"}, URL: "https://example.com/"}}, err: ErrInvalidRegion},
+		{name: "Empty window", rules: []storage.Rule{{Start: &end, End: &start, URL: "https://example.com/"}}, err: ErrInvalidWindow},
+		{name: "Reserved name", rules: []storage.Rule{{Name: "default", Platforms: []string{"ios"}, URL: "https://example.com/"}}, err: ErrReservedRuleName},
+		{
+			name: "Duplicate name",
+			rules: []storage.Rule{
+				{Platforms: []string{"ios"}, URL: "https://example.com/"},
+				{Name: "rule-1", Platforms: []string{"android"}, URL: "https://example.com/"},
+			},
+			err: ErrDuplicateName,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.rules)
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package threat
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// canonicalize splits rawURL into the host and path-with-query used to build
+// Safe Browsing URL expressions. The fragment is dropped.
+func canonicalize(rawURL string) (string, string, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return "", "", false
+	}
+
+	host := canonicalHost(u.Hostname())
+	if host == "" {
+		return "", "", false
+	}
+
+	p := canonicalPath(u.EscapedPath())
+	if u.RawQuery != "" || u.ForceQuery {
+		p += "?" + u.RawQuery
+	}
+
+	return host, p, true
+}
+
+func canonicalHost(host string) string {
+	host = strings.ToLower(strings.Trim(host, "."))
+
+	for strings.Contains(host, "..") {
+		host = strings.ReplaceAll(host, "..", ".")
+	}
+
+	return host
+}
+
+// canonicalPath resolves "." and ".." segments and collapses repeated slashes.
+func canonicalPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	trailing := strings.HasSuffix(p, "/")
+
+	var segments []string
+	for _, seg := range strings.Split(p, "/") {
+		switch seg {
+		case "", ".":
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+		default:
+			segments = append(segments, seg)
+		}
+	}
+
+	res := "/" + strings.Join(segments, "/")
+	if trailing && len(segments) > 0 {
+		res += "/"
+	}
+
+	return res
+}
+
+// hostSuffixes returns host followed by its parent domains, stopping before
+// the top-level domain.
+func hostSuffixes(host string, limit int) []string {
+	if net.ParseIP(host) != nil {
+		return []string{host}
+	}
+
+	res := []string{host}
+
+	parts := strings.Split(host, ".")
+	start := 1
+	if len(parts) > 5 {
+		start = len(parts) - 5
+	}
+	for i := start; i < len(parts)-1 && len(res) < limit; i++ {
+		res = append(res, strings.Join(parts[i:], "."))
+	}
+
+	return res
+}
+
+// expressions returns the Safe Browsing host-suffix/path-prefix expressions
+// for a canonical host and path: up to 5 hosts times up to 6 paths.
+func expressions(host string, pathQuery string) []string {
+	path, _, hasQuery := strings.Cut(pathQuery, "?")
+
+	paths := []string{pathQuery}
+	if hasQuery {
+		paths = append(paths, path)
+	}
+
+	// Directory prefixes: "/" and up to three more components.
+	var dirs []string
+	if trimmed := strings.Trim(path, "/"); trimmed != "" {
+		dirs = strings.Split(trimmed, "/")
+		if !strings.HasSuffix(path, "/") {
+			dirs = dirs[:len(dirs)-1]
+		}
+	}
+
+	prefix := "/"
+	for i := 0; len(paths) < 6; i++ {
+		if prefix != path {
+			paths = append(paths, prefix)
+		}
+		if i >= len(dirs) || i >= 3 {
+			break
+		}
+		prefix += dirs[i] + "/"
+	}
+
+	var res []string
+	for _, h := range hostSuffixes(host, 5) {
+		for _, p := range paths {
+			res = append(res, h+p)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,222 @@
+package threat
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+)
+
+const (
+	minPrefixLen = 4
+	maxPrefixLen = sha256.Size
+
+	defaultThreatType = "unsafe"
+)
+
+// List flags destinations found in locally stored threat lists.
+//
+// Two formats are supported. Domain lists hold one domain per line, or
+// hosts-file lines such as "0.0.0.0 evil.example"; a domain also covers its
+// subdomains. Hash-prefix lists use the Safe Browsing scheme: each line holds
+// a hex-encoded SHA-256 prefix (4 to 32 bytes) of a canonical URL expression,
+// optionally followed by a threat type such as MALWARE. In both formats blank
+// lines and lines starting with # are ignored.
+//
+// A List is safe for concurrent use.
+type List struct {
+	domainPaths []string
+	prefixPaths []string
+
+	mu       sync.RWMutex
+	modTimes map[string]time.Time
+	domains  map[string]string
+	prefixes map[int]map[string]string
+}
+
+// New loads the given domain and hash-prefix list files.
+func New(domainPaths []string, prefixPaths []string) (*List, error) {
+	const op = "lib.threat.New"
+
+	l := &List{
+		domainPaths: domainPaths,
+		prefixPaths: prefixPaths,
+	}
+
+	if err := l.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return l, nil
+}
+
+// Reload re-reads every list file. On error the previous lists stay in effect.
+func (l *List) Reload() error {
+	const op = "lib.threat.Reload"
+
+	modTimes := make(map[string]time.Time)
+	domains := make(map[string]string)
+	prefixes := make(map[int]map[string]string)
+
+	for _, path := range l.domainPaths {
+		err := readList(path, modTimes, func(fields []string) error {
+			domain := fields[0]
+			if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+				domain = fields[1]
+			}
+
+			domains[canonicalHost(domain)] = defaultThreatType
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for _, path := range l.prefixPaths {
+		err := readList(path, modTimes, func(fields []string) error {
+			prefix, err := hex.DecodeString(fields[0])
+			if err != nil {
+				return err
+			}
+			if len(prefix) < minPrefixLen || len(prefix) > maxPrefixLen {
+				return fmt.Errorf("prefix %q must be %d to %d bytes", fields[0], minPrefixLen, maxPrefixLen)
+			}
+
+			threatType := defaultThreatType
+			if len(fields) > 1 {
+				threatType = fields[1]
+			}
+
+			if prefixes[len(prefix)] == nil {
+				prefixes[len(prefix)] = make(map[string]string)
+			}
+			prefixes[len(prefix)][string(prefix)] = threatType
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	l.mu.Lock()
+	l.modTimes = modTimes
+	l.domains = domains
+	l.prefixes = prefixes
+	l.mu.Unlock()
+
+	return nil
+}
+
+// ReloadIfChanged reloads the lists if any file changed since the last load.
+func (l *List) ReloadIfChanged() (bool, error) {
+	const op = "lib.threat.ReloadIfChanged"
+
+	l.mu.RLock()
+	changed := false
+	for _, path := range append(append([]string(nil), l.domainPaths...), l.prefixPaths...) {
+		info, err := os.Stat(path)
+		if err != nil {
+			l.mu.RUnlock()
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		if !info.ModTime().Equal(l.modTimes[path]) {
+			changed = true
+			break
+		}
+	}
+	l.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+
+	if err := l.Reload(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// Lookup returns the threat type of rawURL, if it is listed.
+func (l *List) Lookup(rawURL string) (string, bool) {
+	host, pathQuery, ok := canonicalize(rawURL)
+	if !ok {
+		return "", false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, h := range hostSuffixes(host, len(host)) {
+		if threatType, ok := l.domains[h]; ok {
+			return threatType, true
+		}
+	}
+
+	if len(l.prefixes) == 0 {
+		return "", false
+	}
+
+	for _, expr := range expressions(host, pathQuery) {
+		sum := sha256.Sum256([]byte(expr))
+
+		for n, set := range l.prefixes {
+			if threatType, ok := set[string(sum[:n])]; ok {
+				return threatType, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// CheckURL returns a *policy.Violation if rawURL is listed.
+func (l *List) CheckURL(rawURL string) error {
+	threatType, ok := l.Lookup(rawURL)
+	if !ok {
+		return nil
+	}
+
+	return &policy.Violation{
+		Rule:    policy.RuleThreat,
+		Message: fmt.Sprintf("destination is flagged as %s", strings.ToLower(threatType)),
+	}
+}
+
+func readList(path string, modTimes map[string]time.Time, add func(fields []string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	modTimes[path] = info.ModTime()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if err := add(strings.Fields(text)); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,121 @@
+package threat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+)
+
+func prefix(expr string, n int) string {
+	sum := sha256.Sum256([]byte(expr))
+	return hex.EncodeToString(sum[:n])
+}
+
+func TestExpressions(t *testing.T) {
+	host, p, ok := canonicalize("http://a.b.c/1/2.html?param=1")
+	require.True(t, ok)
+
+	assert.Equal(t, []string{
+		"a.b.c/1/2.html?param=1",
+		"a.b.c/1/2.html",
+		"a.b.c/",
+		"a.b.c/1/",
+		"b.c/1/2.html?param=1",
+		"b.c/1/2.html",
+		"b.c/",
+		"b.c/1/",
+	}, expressions(host, p))
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "http://Evil.Example../a/./b/../c#frag", want: "evil.example/a/c"},
+		{url: "https://evil.example", want: "evil.example/"},
+		{url: "https://evil.example//a//b/", want: "evil.example/a/b/"},
+	}
+	for _, tt := range tests {
+		host, p, ok := canonicalize(tt.url)
+		require.True(t, ok)
+		assert.Equal(t, tt.want, host+p)
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+
+	domainsPath := filepath.Join(dir, "domains.txt")
+	require.NoError(t, os.WriteFile(domainsPath, []byte(
+		"# plain and hosts-file entries\nevil.example\n0.0.0.0 tracker.example\n",
+	), 0o600))
+
+	prefixesPath := filepath.Join(dir, "prefixes.txt")
+	require.NoError(t, os.WriteFile(prefixesPath, []byte(
+		prefix("phish.example/login/", 4)+" SOCIAL_ENGINEERING\n"+
+			prefix("malware.example/", 32)+" MALWARE\n",
+	), 0o600))
+
+	l, err := New([]string{domainsPath}, []string{prefixesPath})
+	require.NoError(t, err)
+
+	tests := []struct {
+		url        string
+		threatType string
+	}{
+		{url: "https://example.com/"},
+		{url: "https://evil.example/x", threatType: "unsafe"},
+		{url: "https://sub.evil.example/x", threatType: "unsafe"},
+		{url: "https://notevil.example/x"},
+		{url: "http://ads.tracker.example/", threatType: "unsafe"},
+		{url: "https://phish.example/login/form?next=1", threatType: "SOCIAL_ENGINEERING"},
+		{url: "https://phish.example/other"},
+		{url: "https://cdn.malware.example/any/path", threatType: "MALWARE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			threatType, ok := l.Lookup(tt.url)
+			assert.Equal(t, tt.threatType != "", ok)
+			assert.Equal(t, tt.threatType, threatType)
+		})
+	}
+
+	var v *policy.Violation
+	require.True(t, errors.As(l.CheckURL("https://evil.example/"), &v))
+	assert.Equal(t, policy.RuleThreat, v.Rule)
+}
+
+func TestList_ReloadIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first.example\n"), 0o600))
+
+	l, err := New([]string{path}, nil)
+	require.NoError(t, err)
+
+	changed, err := l.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, os.WriteFile(path, []byte("second.example\n"), 0o600))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	changed, err = l.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	_, ok := l.Lookup("https://first.example/")
+	assert.False(t, ok)
+	_, ok = l.Lookup("https://second.example/")
+	assert.True(t, ok)
+}
@@ -0,0 +1,378 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/domain"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrNoURLColumn   = errors.New("CSV header has no URL column")
+)
+
+// maxLineSize bounds a single NDJSON line.
+const maxLineSize = 1 << 20
+
+// Record is a link as it appears in an export.
+type Record struct {
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url"`
+	Owner     string     `json:"owner,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Domain    string     `json:"domain,omitempty"`
+	// Rules, variants, pages and the descriptive fields are exported in
+	// NDJSON only; CSV has no room for them.
+	Rules       []storage.Rule    `json:"rules,omitempty"`
+	Variants    []storage.Variant `json:"variants,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Page        *storage.Page     `json:"page,omitempty"`
+}
+
+// csvHeader ends in domain so exports from before short domains existed
+// line up with new ones.
+var csvHeader = []string{"alias", "url", "owner", "created_at", "domain"}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+
+	return "text/csv"
+}
+
+// Writer writes links in an export format.
+type Writer interface {
+	Write(link storage.Link) error
+	// Flush writes any buffered data and reports earlier write errors.
+	Flush() error
+}
+
+// NewWriter returns a Writer producing format on w.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(w)}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+type csvWriter struct {
+	w          *csv.Writer
+	headerDone bool
+}
+
+func (cw *csvWriter) Write(link storage.Link) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	var created string
+	if !link.CreatedAt.IsZero() {
+		created = link.CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return cw.w.Write([]string{link.Alias, link.URL, link.Owner, created, link.Domain})
+}
+
+// Flush writes the header even if there were no links, so empty exports
+// can still be imported.
+func (cw *csvWriter) Flush() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	cw.w.Flush()
+
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) writeHeader() error {
+	if cw.headerDone {
+		return nil
+	}
+	cw.headerDone = true
+
+	return cw.w.Write(csvHeader)
+}
+
+type ndjsonWriter struct {
+	w *bufio.Writer
+}
+
+func (nw *ndjsonWriter) Write(link storage.Link) error {
+	rec := Record{
+		Alias:       link.Alias,
+		URL:         link.URL,
+		Owner:       link.Owner,
+		Domain:      link.Domain,
+		Rules:       link.Rules,
+		Variants:    link.Variants,
+		Title:       link.Title,
+		Description: link.Description,
+		Tags:        link.Tags,
+		Metadata:    link.Metadata,
+		Page:        link.Page,
+	}
+	if !link.CreatedAt.IsZero() {
+		created := link.CreatedAt.UTC()
+		rec.CreatedAt = &created
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := nw.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	return nw.w.Flush()
+}
+
+// RecordError is a record that can't be read. Reading may continue past it.
+type RecordError struct {
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads links from an export.
+type Reader interface {
+	// Read returns the next link, a *RecordError for an unreadable record,
+	// or io.EOF at the end of input.
+	Read() (storage.Link, error)
+}
+
+// NewReader returns a Reader for format on r.
+//
+// CSV input is matched by header, so besides our own exports it accepts
+// Bitly-style exports with columns such as "Bitlink" or "link" for the short
+// link, "long_url" or "Long URL" for the destination and "created_at" or
+// "Created" for the creation time. Short links are reduced to their last
+// path segment, so "bit.ly/3xYz" imports as the alias "3xYz".
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+		cr.TrimLeadingSpace = true
+
+		return &csvReader{r: cr}, nil
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+		return &ndjsonReader{s: scanner}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// csvColumns maps normalized header names to the field they fill.
+var csvColumns = map[string]string{
+	"alias":           "alias",
+	"bitlink":         "alias",
+	"custom_bitlink":  "alias",
+	"link":            "alias",
+	"short_link":      "alias",
+	"short_url":       "alias",
+	"back_half":       "alias",
+	"backhalf":        "alias",
+	"url":             "url",
+	"long_url":        "url",
+	"destination":     "url",
+	"destination_url": "url",
+	"original_url":    "url",
+	"owner":           "owner",
+	"created_at":      "created_at",
+	"created":         "created_at",
+	"date_created":    "created_at",
+	"creation_date":   "created_at",
+	"created_date":    "created_at",
+	"domain":          "domain",
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func (cr *csvReader) Read() (storage.Link, error) {
+	if cr.columns == nil {
+		if err := cr.readHeader(); err != nil {
+			return storage.Link{}, err
+		}
+	}
+
+	row, err := cr.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return storage.Link{}, &RecordError{Err: err}
+		}
+
+		return storage.Link{}, err
+	}
+
+	field := func(name string) string {
+		i, ok := cr.columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[i])
+	}
+
+	link := storage.Link{
+		Domain: domain.Normalize(field("domain")),
+		Alias:  aliasFromShortLink(field("alias")),
+		URL:    field("url"),
+		Owner:  field("owner"),
+	}
+
+	if created := field("created_at"); created != "" {
+		link.CreatedAt, err = parseTime(created)
+		if err != nil {
+			return storage.Link{}, &RecordError{Err: err}
+		}
+	}
+
+	return link, nil
+}
+
+func (cr *csvReader) readHeader() error {
+	header, err := cr.r.Read()
+	if err != nil {
+		return err
+	}
+
+	cr.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+
+		if col, ok := csvColumns[name]; ok {
+			if _, dup := cr.columns[col]; !dup {
+				cr.columns[col] = i
+			}
+		}
+	}
+
+	if _, ok := cr.columns["url"]; !ok {
+		return ErrNoURLColumn
+	}
+
+	return nil
+}
+
+type ndjsonReader struct {
+	s *bufio.Scanner
+}
+
+func (nr *ndjsonReader) Read() (storage.Link, error) {
+	for nr.s.Scan() {
+		line := strings.TrimSpace(nr.s.Text())
+		if line == "" {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return storage.Link{}, &RecordError{Err: err}
+		}
+
+		link := storage.Link{
+			Domain:      domain.Normalize(strings.TrimSpace(rec.Domain)),
+			Alias:       aliasFromShortLink(strings.TrimSpace(rec.Alias)),
+			URL:         strings.TrimSpace(rec.URL),
+			Owner:       rec.Owner,
+			Rules:       rec.Rules,
+			Variants:    rec.Variants,
+			Title:       rec.Title,
+			Description: rec.Description,
+			Tags:        rec.Tags,
+			Metadata:    rec.Metadata,
+			Page:        rec.Page,
+		}
+		if rec.CreatedAt != nil {
+			link.CreatedAt = *rec.CreatedAt
+		}
+
+		return link, nil
+	}
+
+	if err := nr.s.Err(); err != nil {
+		return storage.Link{}, err
+	}
+
+	return storage.Link{}, io.EOF
+}
+
+// aliasFromShortLink returns the last path segment of a short link such as
+// "https://bit.ly/3xYz", or s itself if it is a bare alias.
+func aliasFromShortLink(s string) string {
+	if !strings.Contains(s, "/") {
+		return s
+	}
+
+	s, _, _ = strings.Cut(s, "?")
+	s, _, _ = strings.Cut(s, "#")
+	s = strings.TrimRight(s, "/")
+
+	return s[strings.LastIndex(s, "/")+1:]
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"1/2/2006 15:04",
+	"1/2/2006",
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
@@ -0,0 +1,356 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/alias"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/meta"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/page"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/policy"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/split"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/targeting"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Conflict policies decide what happens to an imported link whose alias is
+// already taken.
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+var ErrUnknownConflict = errors.New("unknown conflict policy")
+
+// maxReportedErrors caps Result.Failures so huge broken files stay readable.
+const maxReportedErrors = 100
+
+// Store saves imported links.
+type Store interface {
+	GetLink(domain string, alias string) (storage.Link, error)
+	SaveURL(link storage.Link) (int64, error)
+	ReplaceURL(link storage.Link, actor string) error
+}
+
+type AliasGenerator interface {
+	Generate() (string, error)
+}
+
+type AliasChecker interface {
+	CheckAlias(alias string) error
+}
+
+// URLChecker vets destinations, returning a *policy.Violation for rejected
+// ones.
+type URLChecker interface {
+	CheckURL(rawURL string) error
+}
+
+// DomainChecker rejects short domains links may not be created on.
+type DomainChecker interface {
+	CheckDomain(host string) error
+}
+
+// EventPublisher announces imported links to webhooks.
+type EventPublisher interface {
+	Publish(event string, link storage.Link)
+}
+
+// AuditRecorder appends imported links to the audit log.
+type AuditRecorder interface {
+	Record(e audit.Entry)
+}
+
+// Importer copies links from a Reader into a Store.
+type Importer struct {
+	Store          Store
+	AliasGenerator AliasGenerator
+	AliasChecker   AliasChecker
+	URLChecker     URLChecker
+	DomainChecker  DomainChecker
+	// Conflict is one of ConflictSkip, ConflictOverwrite or ConflictRename.
+	Conflict string
+	// Owner is used for records that don't name one.
+	Owner string
+	// Actor is the user overwrites are attributed to in link history,
+	// the link's new owner if empty.
+	Actor       string
+	MaxAttempts int
+	// Events, if set, is told about created and overwritten links.
+	Events EventPublisher
+	// Audit, if set, records created and overwritten links.
+	Audit AuditRecorder
+}
+
+// RowError is a record that wasn't imported. Row counts records from 1,
+// not counting a CSV header.
+type RowError struct {
+	Row   int    `json:"row"`
+	Alias string `json:"alias,omitempty"`
+	Error string `json:"error"`
+}
+
+type Result struct {
+	Imported    int        `json:"imported"`
+	Overwritten int        `json:"overwritten"`
+	Renamed     int        `json:"renamed"`
+	Skipped     int        `json:"skipped"`
+	Failed      int        `json:"failed"`
+	Failures    []RowError `json:"failures,omitempty"`
+}
+
+// ValidConflict reports whether conflict is a known conflict policy.
+func ValidConflict(conflict string) bool {
+	switch conflict {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return true
+	}
+
+	return false
+}
+
+// Import reads every record from r. Records that can't be read or fail
+// validation are counted in Result.Failed; only read and storage errors
+// stop the import.
+func (im *Importer) Import(r Reader) (Result, error) {
+	const op = "lib.transfer.Import"
+
+	var res Result
+
+	if !ValidConflict(im.Conflict) {
+		return res, fmt.Errorf("%s: %w: %q", op, ErrUnknownConflict, im.Conflict)
+	}
+
+	for row := 1; ; row++ {
+		link, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			var recErr *RecordError
+			if !errors.As(err, &recErr) {
+				return res, fmt.Errorf("%s: row %d: %w", op, row, err)
+			}
+
+			res.fail(row, "", recErr)
+
+			continue
+		}
+
+		if err := im.importLink(&res, row, link); err != nil {
+			return res, fmt.Errorf("%s: row %d: %w", op, row, err)
+		}
+	}
+}
+
+func (im *Importer) importLink(res *Result, row int, link storage.Link) error {
+	if link.Owner == "" {
+		link.Owner = im.Owner
+	}
+
+	if link.Page != nil {
+		if link.URL != "" || len(link.Rules) > 0 || len(link.Variants) > 0 {
+			res.fail(row, link.Alias, page.ErrHasDestination)
+			return nil
+		}
+
+		if err := page.Validate(*link.Page); err != nil {
+			res.fail(row, link.Alias, err)
+			return nil
+		}
+	} else if link.URL == "" {
+		res.fail(row, link.Alias, errors.New("url is empty"))
+		return nil
+	}
+
+	if link.Domain != "" && im.DomainChecker != nil {
+		if err := im.DomainChecker.CheckDomain(link.Domain); err != nil {
+			res.fail(row, link.Alias, err)
+
+			return nil
+		}
+	}
+
+	if err := targeting.Validate(link.Rules); err != nil {
+		res.fail(row, link.Alias, err)
+
+		return nil
+	}
+
+	if err := split.Validate(link.Variants); err != nil {
+		res.fail(row, link.Alias, err)
+
+		return nil
+	}
+
+	if err := meta.Clean(&link); err != nil {
+		res.fail(row, link.Alias, err)
+
+		return nil
+	}
+
+	if im.URLChecker != nil {
+		var urls []string
+		if link.Page != nil {
+			for _, b := range link.Page.Buttons {
+				urls = append(urls, b.URL)
+			}
+		} else {
+			urls = append(urls, link.URL)
+		}
+		for _, rule := range link.Rules {
+			urls = append(urls, rule.URL)
+		}
+		for _, v := range link.Variants {
+			urls = append(urls, v.URL)
+		}
+
+		for _, u := range urls {
+			if err := im.URLChecker.CheckURL(u); err != nil {
+				var violation *policy.Violation
+				if !errors.As(err, &violation) {
+					return err
+				}
+
+				res.fail(row, link.Alias, violation)
+
+				return nil
+			}
+		}
+	}
+
+	original := link.Alias
+	renamed := false
+	if link.Alias != "" {
+		if err := alias.CheckLength(link.Alias); err != nil {
+			res.fail(row, link.Alias, err)
+			return nil
+		}
+
+		if err := im.AliasChecker.CheckAlias(link.Alias); err != nil {
+			if im.Conflict != ConflictRename {
+				res.fail(row, link.Alias, err)
+				return nil
+			}
+
+			link.Alias = ""
+			renamed = true
+		}
+	}
+
+	if link.Alias != "" {
+		_, err := im.Store.SaveURL(link)
+		switch {
+		case err == nil:
+			res.Imported++
+			im.publish(webhook.EventCreated, link)
+			im.record(audit.ActionLinkCreate, nil, link)
+			return nil
+		case !errors.Is(err, storage.ErrAliasExists):
+			return err
+		}
+
+		switch im.Conflict {
+		case ConflictSkip:
+			res.Skipped++
+			return nil
+		case ConflictOverwrite:
+			var before *storage.Link
+			if im.Audit != nil {
+				old, err := im.Store.GetLink(link.Domain, link.Alias)
+				if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+					return err
+				}
+				if err == nil {
+					before = &old
+				}
+			}
+
+			err := im.Store.ReplaceURL(link, im.Actor)
+			if errors.Is(err, storage.ErrURLNotFound) {
+				// The alias belongs to a link in the trash, which keeps it
+				// until the link is purged.
+				res.fail(row, link.Alias, errors.New("alias is taken by a deleted link"))
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			res.Overwritten++
+			im.publish(webhook.EventUpdated, link)
+			im.record(audit.ActionLinkUpdate, before, link)
+			return nil
+		}
+
+		renamed = true
+	}
+
+	for attempt := 1; attempt <= max(im.MaxAttempts, 1); attempt++ {
+		generated, err := im.AliasGenerator.Generate()
+		if err != nil {
+			return err
+		}
+		if im.AliasChecker.CheckAlias(generated) != nil {
+			continue
+		}
+
+		link.Alias = generated
+		_, err = im.Store.SaveURL(link)
+		if errors.Is(err, storage.ErrAliasExists) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if renamed {
+			res.Renamed++
+		} else {
+			res.Imported++
+		}
+		im.publish(webhook.EventCreated, link)
+		im.record(audit.ActionLinkCreate, nil, link)
+
+		return nil
+	}
+
+	res.fail(row, original, errors.New("failed to generate a free alias"))
+
+	return nil
+}
+
+func (im *Importer) publish(event string, link storage.Link) {
+	if im.Events != nil {
+		im.Events.Publish(event, link)
+	}
+}
+
+func (im *Importer) record(action string, before *storage.Link, after storage.Link) {
+	if im.Audit == nil {
+		return
+	}
+
+	e := audit.Entry{
+		Action: action,
+		Domain: after.Domain,
+		Alias:  after.Alias,
+		After:  audit.LinkOf(after),
+	}
+	if before != nil {
+		e.Before = audit.LinkOf(*before)
+	}
+
+	im.Audit.Record(e)
+}
+
+func (res *Result) fail(row int, alias string, err error) {
+	res.Failed++
+
+	if len(res.Failures) < maxReportedErrors {
+		res.Failures = append(res.Failures, RowError{Row: row, Alias: alias, Error: err.Error()})
+	}
+}
@@ -0,0 +1,270 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/audit"
+	"github.com/MaximShildyakov/url-shortener/internal/lib/webhook"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func readAll(t *testing.T, r Reader) ([]storage.Link, []error) {
+	t.Helper()
+
+	var links []storage.Link
+	var recErrs []error
+	for {
+		link, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return links, recErrs
+		}
+
+		var recErr *RecordError
+		if errors.As(err, &recErr) {
+			recErrs = append(recErrs, err)
+			continue
+		}
+		require.NoError(t, err)
+
+		links = append(links, link)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	links := []storage.Link{
+		{Alias: "google", URL: "https://google.com/?q=a,b", Owner: "alice", CreatedAt: created},
+		{Alias: "old", URL: "https://example.com/\"quoted\""},
+		{Domain: "go.example", Alias: "google", URL: "https://google.com/"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w, err := NewWriter(&buf, format)
+			require.NoError(t, err)
+			for _, link := range links {
+				require.NoError(t, w.Write(link))
+			}
+			require.NoError(t, w.Flush())
+
+			r, err := NewReader(&buf, format)
+			require.NoError(t, err)
+
+			got, recErrs := readAll(t, r)
+			assert.Empty(t, recErrs)
+			assert.Equal(t, links, got)
+		})
+	}
+}
+
+func TestRoundTrip_Targets(t *testing.T) {
+	link := storage.Link{
+		Alias: "app",
+		URL:   "https://example.com/app",
+		Rules: []storage.Rule{{Name: "ios", Platforms: []string{"ios"}, URL: "https://apps.apple.com/app"}},
+		Variants: []storage.Variant{
+			{Name: "a", URL: "https://example.com/a", Weight: 1},
+			{Name: "b", URL: "https://example.com/b", Weight: 1},
+		},
+		Title:    "App",
+		Tags:     []string{"mobile", "launch"},
+		Metadata: map[string]string{"campaign": "spring"},
+	}
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, FormatNDJSON)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(link))
+	require.NoError(t, w.Flush())
+
+	r, err := NewReader(&buf, FormatNDJSON)
+	require.NoError(t, err)
+
+	got, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, link, got)
+}
+
+func TestCSVReader_Bitly(t *testing.T) {
+	const input = "\ufeffBitlink,Long URL,Title,Created,Tags\n" +
+		"bit.ly/3xYz,https://example.com/a,Example,2023-02-03T04:05:06+0000,news\n" +
+		"https://bit.ly/promo/,https://example.com/b,,,\n" +
+		"bit.ly/bad,https://example.com/c,,not a date,\n"
+
+	r, err := NewReader(strings.NewReader(input), FormatCSV)
+	require.NoError(t, err)
+
+	links, recErrs := readAll(t, r)
+	assert.Len(t, recErrs, 1)
+	assert.Equal(t, []storage.Link{
+		{Alias: "3xYz", URL: "https://example.com/a", CreatedAt: time.Date(2023, 2, 3, 4, 5, 6, 0, time.UTC)},
+		{Alias: "promo", URL: "https://example.com/b"},
+	}, links)
+}
+
+func TestCSVReader_NoURLColumn(t *testing.T) {
+	r, err := NewReader(strings.NewReader("alias,title\nabc,x\n"), FormatCSV)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	assert.ErrorIs(t, err, ErrNoURLColumn)
+}
+
+type memStore struct {
+	links map[string]storage.Link
+}
+
+func (m *memStore) GetLink(domain string, alias string) (storage.Link, error) {
+	link, ok := m.links[alias]
+	if !ok {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
+
+	return link, nil
+}
+
+func (m *memStore) SaveURL(link storage.Link) (int64, error) {
+	if _, ok := m.links[link.Alias]; ok {
+		return 0, storage.ErrAliasExists
+	}
+	m.links[link.Alias] = link
+
+	return int64(len(m.links)), nil
+}
+
+func (m *memStore) ReplaceURL(link storage.Link, actor string) error {
+	m.links[link.Alias] = link
+
+	return nil
+}
+
+// eventCounter counts published events by name.
+type eventCounter map[string]int
+
+func (c eventCounter) Publish(event string, link storage.Link) {
+	c[event]++
+}
+
+// auditLog keeps the recorded entries.
+type auditLog []audit.Entry
+
+func (l *auditLog) Record(e audit.Entry) {
+	*l = append(*l, e)
+}
+
+type seqGenerator struct{ n int }
+
+func (g *seqGenerator) Generate() (string, error) {
+	g.n++
+
+	return "gen" + strconv.Itoa(g.n), nil
+}
+
+type denyAliases map[string]bool
+
+func (d denyAliases) CheckAlias(alias string) error {
+	if d[alias] {
+		return errors.New("alias is reserved")
+	}
+
+	return nil
+}
+
+func TestImporter_Conflicts(t *testing.T) {
+	const input = `{"alias": "taken", "url": "https://new.example"}
+{"alias": "fresh", "url": "https://fresh.example", "owner": "bob"}
+{"alias": "url", "url": "https://reserved.example"}
+{"url": "https://generated.example"}
+{"alias": "broken", "url": ""}
+{"alias": "x", "url": "https://short.example"}
+not json
+`
+
+	tests := []struct {
+		conflict string
+		want     Result
+		taken    string
+	}{
+		{
+			conflict: ConflictSkip,
+			want:     Result{Imported: 2, Skipped: 1, Failed: 4},
+			taken:    "https://old.example",
+		},
+		{
+			conflict: ConflictOverwrite,
+			want:     Result{Imported: 2, Overwritten: 1, Failed: 4},
+			taken:    "https://new.example",
+		},
+		{
+			conflict: ConflictRename,
+			want:     Result{Imported: 2, Renamed: 2, Failed: 3},
+			taken:    "https://old.example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.conflict, func(t *testing.T) {
+			store := &memStore{links: map[string]storage.Link{
+				"taken": {Alias: "taken", URL: "https://old.example", Owner: "alice"},
+			}}
+
+			r, err := NewReader(strings.NewReader(input), FormatNDJSON)
+			require.NoError(t, err)
+
+			events := eventCounter{}
+			var changes auditLog
+			im := &Importer{
+				Store:          store,
+				AliasGenerator: &seqGenerator{},
+				AliasChecker:   denyAliases{"url": true},
+				Conflict:       tt.conflict,
+				Owner:          "admin",
+				MaxAttempts:    3,
+				Events:         events,
+				Audit:          &changes,
+			}
+
+			res, err := im.Import(r)
+			require.NoError(t, err)
+
+			assert.Equal(t, res.Imported+res.Renamed, events[webhook.EventCreated])
+			assert.Equal(t, res.Overwritten, events[webhook.EventUpdated])
+
+			assert.Len(t, changes, res.Imported+res.Renamed+res.Overwritten)
+			for _, e := range changes {
+				if e.Action == audit.ActionLinkUpdate {
+					assert.Equal(t, "https://old.example", e.Before.(audit.Link).URL)
+					assert.Equal(t, "https://new.example", e.After.(audit.Link).URL)
+				}
+			}
+
+			failures := res.Failures
+			res.Failures = nil
+			assert.Equal(t, tt.want, res)
+			assert.Len(t, failures, tt.want.Failed)
+
+			assert.Equal(t, tt.taken, store.links["taken"].URL)
+			assert.Equal(t, "bob", store.links["fresh"].Owner)
+			assert.NotContains(t, store.links, "x", "aliases are as long as saved ones")
+		})
+	}
+}
+
+func TestImporter_UnknownConflict(t *testing.T) {
+	im := &Importer{Conflict: "merge"}
+
+	_, err := im.Import(nil)
+	assert.ErrorIs(t, err, ErrUnknownConflict)
+}
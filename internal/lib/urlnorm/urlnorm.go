@@ -0,0 +1,91 @@
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var ErrNotAbsolute = errors.New("URL is not absolute")
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize returns the canonical form of rawURL used to detect duplicate
+// destinations. It lowercases the scheme and host, drops the scheme's
+// default port and a trailing dot in the host, turns an empty path into
+// "/", sorts query parameters by key and value, and drops the fragment
+// unless keepFragment is set.
+//
+// The result is meant for comparison only; links keep redirecting to the
+// URL exactly as it was saved.
+func Normalize(rawURL string, keepFragment bool) (string, error) {
+	const op = "lib.urlnorm.Normalize"
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("%s: %w", op, ErrNotAbsolute)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host, port := u.Hostname(), u.Port()
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+
+	u.RawQuery = sortQuery(u.Query())
+	u.ForceQuery = false
+
+	if !keepFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	return u.String(), nil
+}
+
+func sortQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		values := q[k]
+		sort.Strings(values)
+
+		for _, v := range values {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(v))
+		}
+	}
+
+	return b.String()
+}
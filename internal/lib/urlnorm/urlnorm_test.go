@@ -0,0 +1,85 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		keepFragment bool
+		want         string
+	}{
+		{
+			name: "lowercase scheme and host",
+			url:  "HTTPS://Example.COM/Path",
+			want: "https://example.com/Path",
+		},
+		{
+			name: "default http port",
+			url:  "http://example.com:80/",
+			want: "http://example.com/",
+		},
+		{
+			name: "default https port",
+			url:  "https://example.com:443/a",
+			want: "https://example.com/a",
+		},
+		{
+			name: "non-default port kept",
+			url:  "https://example.com:8443/a",
+			want: "https://example.com:8443/a",
+		},
+		{
+			name: "empty path",
+			url:  "https://example.com",
+			want: "https://example.com/",
+		},
+		{
+			name: "trailing dot in host",
+			url:  "https://example.com./a",
+			want: "https://example.com/a",
+		},
+		{
+			name: "sorted query",
+			url:  "https://example.com/?b=2&a=3&a=1",
+			want: "https://example.com/?a=1&a=3&b=2",
+		},
+		{
+			name: "empty query",
+			url:  "https://example.com/?",
+			want: "https://example.com/",
+		},
+		{
+			name: "fragment dropped",
+			url:  "https://example.com/a#section",
+			want: "https://example.com/a",
+		},
+		{
+			name:         "fragment kept",
+			url:          "https://example.com/#/route",
+			keepFragment: true,
+			want:         "https://example.com/#/route",
+		},
+		{
+			name: "ipv6 host",
+			url:  "http://[2001:DB8::1]:80/",
+			want: "http://[2001:db8::1]/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.url, tt.keepFragment)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Normalize("/relative/path", false)
+	assert.ErrorIs(t, err, ErrNotAbsolute)
+}
@@ -0,0 +1,392 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/sl"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// Link lifecycle events webhooks may subscribe to.
+const (
+	EventCreated = "link.created"
+	EventUpdated = "link.updated"
+	EventDeleted = "link.deleted"
+	// EventRestored follows EventDeleted when a link comes back from the
+	// trash.
+	EventRestored = "link.restored"
+	// EventExpired is sent when a link left in the trash past its
+	// retention is purged for good, freeing its alias.
+	EventExpired    = "link.expired"
+	EventFirstClick = "link.first_click"
+)
+
+// Events lists every event, in lifecycle order.
+var Events = []string{EventCreated, EventUpdated, EventFirstClick, EventExpired, EventDeleted, EventRestored}
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature carries "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a dot and the body, keyed with the webhook's secret.
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	ErrNoEvents     = errors.New("webhook needs at least one event")
+	ErrUnknownEvent = errors.New("unknown event")
+)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Link       Link      `json:"link"`
+}
+
+// Link is the link an event is about. Events only carry what the sender
+// knew, so deletions, for one, have no URL.
+type Link struct {
+	Domain    string     `json:"domain,omitempty"`
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// Store keeps subscriptions and deliveries.
+type Store interface {
+	EnqueueEvent(event string, dedupeKey string, payload []byte, at time.Time) (int64, error)
+	ListDueDeliveries(now time.Time, limit int) ([]storage.Delivery, error)
+	FinishAttempt(d storage.Delivery, attempt storage.DeliveryAttempt) error
+}
+
+// Options tune the dispatcher.
+type Options struct {
+	// QueueSize is how many published events may wait to be stored. Events
+	// published to a full queue are dropped.
+	QueueSize int
+	// PollInterval is how often due deliveries are looked for, in addition
+	// to right after an event was stored.
+	PollInterval time.Duration
+	// Concurrency caps the requests in flight.
+	Concurrency int
+	Timeout     time.Duration
+	// MaxAttempts is how many times a delivery is tried before it goes to
+	// the dead letters.
+	MaxAttempts int
+	// BaseDelay is the wait after the first failed attempt, doubling with
+	// each failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+type event struct {
+	name string
+	key  string
+	link storage.Link
+	at   time.Time
+}
+
+// Dispatcher sends link lifecycle events to the subscribed webhooks.
+// Events are stored before they are sent, so they survive restarts.
+type Dispatcher struct {
+	log    *slog.Logger
+	store  Store
+	opts   Options
+	client *http.Client
+
+	events chan event
+	wake   chan struct{}
+}
+
+// New returns a Dispatcher. Nothing is stored or sent until Run is called.
+func New(log *slog.Logger, store Store, opts Options) *Dispatcher {
+	return &Dispatcher{
+		log:   log.With(slog.String("component", "webhook")),
+		store: store,
+		opts:  opts,
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			// A redirect would turn the POST into a GET; count it as a
+			// failure instead.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		events: make(chan event, max(opts.QueueSize, 1)),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Publish queues event about link. It never blocks: if the queue is full
+// the event is dropped and logged.
+func (d *Dispatcher) Publish(name string, link storage.Link) {
+	e := newEvent(name, link)
+
+	select {
+	case d.events <- e:
+	default:
+		d.log.Error("webhook queue is full, event dropped",
+			slog.String("event", name),
+			slog.String("alias", link.Alias),
+		)
+	}
+}
+
+// PublishNow stores event about link before returning instead of queueing
+// it, for processes that exit without calling Run, such as commands. A
+// running dispatcher sends it with the next due deliveries.
+func (d *Dispatcher) PublishNow(name string, link storage.Link) {
+	if d.enqueue(newEvent(name, link)) {
+		d.poke()
+	}
+}
+
+func newEvent(name string, link storage.Link) event {
+	e := event{name: name, link: link, at: time.Now().UTC()}
+
+	// First clicks race with each other; only one per link gets queued.
+	if name == EventFirstClick {
+		e.key = fmt.Sprintf("%s:%d", name, link.ID)
+	}
+
+	return e
+}
+
+// Run stores published events and sends due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		d.storeEvents(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		d.deliverLoop(ctx)
+	}()
+
+	wg.Wait()
+}
+
+func (d *Dispatcher) storeEvents(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.events:
+			if d.enqueue(e) {
+				d.poke()
+			}
+		}
+	}
+}
+
+// enqueue stores a delivery of e for every subscribed webhook and reports
+// whether there were any. Failures are logged.
+func (d *Dispatcher) enqueue(e event) bool {
+	link := Link{
+		Domain: e.link.Domain,
+		Alias:  e.link.Alias,
+		URL:    e.link.URL,
+		Owner:  e.link.Owner,
+	}
+	if !e.link.CreatedAt.IsZero() {
+		link.CreatedAt = &e.link.CreatedAt
+	}
+
+	payload, err := json.Marshal(Payload{Event: e.name, OccurredAt: e.at, Link: link})
+	if err != nil {
+		d.log.Error("failed to encode event", slog.String("event", e.name), sl.Err(err))
+		return false
+	}
+
+	queued, err := d.store.EnqueueEvent(e.name, e.key, payload, e.at)
+	if err != nil {
+		d.log.Error("failed to queue event", slog.String("event", e.name), sl.Err(err))
+		return false
+	}
+
+	return queued > 0
+}
+
+// poke makes the delivery loop look for due deliveries now.
+func (d *Dispatcher) poke() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+
+		d.deliverDue(ctx)
+	}
+}
+
+// deliverDue sends the deliveries that are due, batch by batch.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	limit := max(d.opts.Concurrency, 1) * 10
+
+	for ctx.Err() == nil {
+		due, err := d.store.ListDueDeliveries(time.Now(), limit)
+		if err != nil {
+			d.log.Error("failed to list due deliveries", sl.Err(err))
+			return
+		}
+
+		sem := make(chan struct{}, max(d.opts.Concurrency, 1))
+		var wg sync.WaitGroup
+
+		for _, delivery := range due {
+			sem <- struct{}{}
+			wg.Add(1)
+
+			go func(delivery storage.Delivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				d.attempt(ctx, delivery)
+			}(delivery)
+		}
+
+		wg.Wait()
+
+		if len(due) < limit {
+			return
+		}
+	}
+}
+
+// attempt sends delivery once and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, delivery storage.Delivery) {
+	start := time.Now()
+	status, err := d.send(ctx, delivery, start)
+
+	attempt := storage.DeliveryAttempt{
+		DeliveryID:  delivery.ID,
+		StatusCode:  status,
+		Duration:    time.Since(start),
+		AttemptedAt: start.UTC(),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	} else if status < 200 || status >= 300 {
+		attempt.Error = "unexpected status " + strconv.Itoa(status)
+	}
+
+	attempts := delivery.Attempts + 1
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = storage.DeliveryDelivered
+		delivery.DeliveredAt = time.Now()
+	case attempts >= max(d.opts.MaxAttempts, 1):
+		delivery.Status = storage.DeliveryDead
+
+		d.log.Warn("webhook delivery failed for good",
+			slog.Int64("delivery", delivery.ID),
+			slog.String("url", delivery.URL),
+			slog.String("error", attempt.Error),
+		)
+	default:
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(attempts))
+	}
+
+	if err := d.store.FinishAttempt(delivery, attempt); err != nil {
+		d.log.Error("failed to record delivery attempt", slog.Int64("delivery", delivery.ID), sl.Err(err))
+	}
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	delay := d.opts.BaseDelay
+	for i := 1; i < failures; i++ {
+		if delay *= 2; delay >= d.opts.MaxDelay {
+			return d.opts.MaxDelay
+		}
+	}
+
+	return delay
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery storage.Delivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	_, _ = io.CopyN(io.Discard, resp.Body, 4<<10)
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the HeaderSignature value for body sent at timestamp, in
+// Unix seconds. Receivers compute it the same way to verify deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Validate checks the events a webhook subscribes to.
+func Validate(events []string) error {
+	if len(events) == 0 {
+		return ErrNoEvents
+	}
+
+	for _, e := range events {
+		if !slices.Contains(Events, e) {
+			return fmt.Errorf("%w: %q", ErrUnknownEvent, e)
+		}
+	}
+
+	return nil
+}
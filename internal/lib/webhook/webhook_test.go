@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+	"github.com/MaximShildyakov/url-shortener/internal/storage/sqlite"
+)
+
+var testOptions = Options{
+	QueueSize:    10,
+	PollInterval: 10 * time.Millisecond,
+	Concurrency:  2,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	BaseDelay:    time.Millisecond,
+	MaxDelay:     5 * time.Millisecond,
+}
+
+func newStore(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	return db
+}
+
+func run(t *testing.T, d *Dispatcher) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	db := newStore(t)
+
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	_, err := db.SaveWebhook(storage.Webhook{URL: receiver.URL, Secret: "s3cret", Events: []string{EventCreated, EventFirstClick}})
+	require.NoError(t, err)
+
+	d := New(slogdiscard.NewDiscardLogger(), db, testOptions)
+	run(t, d)
+
+	link := storage.Link{ID: 1, Alias: "docs", URL: "https://example.com/docs", Owner: "alice"}
+	d.Publish(EventDeleted, link) // not subscribed
+	d.Publish(EventCreated, link)
+
+	var r *http.Request
+	select {
+	case r = <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing delivered")
+	}
+	body := <-bodies
+
+	assert.Equal(t, EventCreated, r.Header.Get(HeaderEvent))
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign("s3cret", timestamp, body), r.Header.Get(HeaderSignature))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, EventCreated, payload.Event)
+	assert.Equal(t, "docs", payload.Link.Alias)
+	assert.Equal(t, "alice", payload.Link.Owner)
+
+	// Racing first clicks are sent once.
+	d.Publish(EventFirstClick, link)
+	d.Publish(EventFirstClick, link)
+
+	select {
+	case r = <-received:
+		assert.Equal(t, EventFirstClick, r.Header.Get(HeaderEvent))
+	case <-time.After(2 * time.Second):
+		t.Fatal("first click not delivered")
+	}
+
+	select {
+	case r = <-received:
+		t.Fatalf("unexpected %s delivery", r.Header.Get(HeaderEvent))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	deliveries, err := db.ListDeliveries(0, storage.DeliveryDelivered, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+}
+
+func TestDispatcher_DeadLetterAndReplay(t *testing.T) {
+	db := newStore(t)
+
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	webhookID, err := db.SaveWebhook(storage.Webhook{URL: receiver.URL, Secret: "s", Events: []string{EventDeleted}})
+	require.NoError(t, err)
+
+	d := New(slogdiscard.NewDiscardLogger(), db, testOptions)
+	run(t, d)
+
+	d.Publish(EventDeleted, storage.Link{Alias: "gone"})
+
+	var dead []storage.DeadLetter
+	require.Eventually(t, func() bool {
+		dead, err = db.ListDeadLetters(10)
+		require.NoError(t, err)
+		return len(dead) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int32(testOptions.MaxAttempts), calls.Load())
+	assert.Equal(t, webhookID, dead[0].WebhookID)
+	assert.Equal(t, testOptions.MaxAttempts, dead[0].Attempts)
+	assert.Equal(t, "unexpected status 503", dead[0].Error)
+
+	attempts, err := db.ListDeliveryAttempts(dead[0].DeliveryID)
+	require.NoError(t, err)
+	assert.Len(t, attempts, testOptions.MaxAttempts)
+
+	healthy.Store(true)
+	require.NoError(t, db.ReplayDeadLetter(dead[0].ID, time.Now()))
+	assert.ErrorIs(t, db.ReplayDeadLetter(dead[0].ID, time.Now()), storage.ErrDeliveryNotFound)
+
+	require.Eventually(t, func() bool {
+		delivered, err := db.ListDeliveries(webhookID, storage.DeliveryDelivered, 10)
+		require.NoError(t, err)
+		return len(delivered) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	dead, err = db.ListDeadLetters(10)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+}
+
+func TestDispatcher_PublishNow(t *testing.T) {
+	db := newStore(t)
+
+	_, err := db.SaveWebhook(storage.Webhook{URL: "https://hooks.example/in", Secret: "s3cret", Events: []string{EventCreated}})
+	require.NoError(t, err)
+
+	// Without Run, the event is stored for a dispatcher running elsewhere.
+	d := New(slogdiscard.NewDiscardLogger(), db, testOptions)
+	d.PublishNow(EventCreated, storage.Link{Alias: "docs", URL: "https://example.com/docs"})
+
+	deliveries, err := db.ListDeliveries(0, storage.DeliveryPending, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, EventCreated, deliveries[0].Event)
+}
+
+func TestDispatcher_PublishNeverBlocks(t *testing.T) {
+	d := New(slogdiscard.NewDiscardLogger(), nil, Options{QueueSize: 1})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			d.Publish(EventCreated, storage.Link{Alias: "a"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full queue")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := New(slogdiscard.NewDiscardLogger(), nil, Options{BaseDelay: time.Second, MaxDelay: 10 * time.Second})
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, 10*time.Second, d.backoff(5))
+	assert.Equal(t, 10*time.Second, d.backoff(50))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]string{EventCreated, EventExpired}))
+	assert.ErrorIs(t, Validate(nil), ErrNoEvents)
+	assert.ErrorIs(t, Validate([]string{"link.renamed"}), ErrUnknownEvent)
+}
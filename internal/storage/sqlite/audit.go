@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+const auditColumns = `id, actor, action, domain, alias, before_value, after_value,
+	request_id, client_ip, created_at`
+
+// AppendAudit adds e to the audit log. CreatedAt defaults to now.
+func (s *Storage) AppendAudit(e storage.AuditEntry) error {
+	const fn = "storage.sqlite.AppendAudit"
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	_, err := s.db.Exec(`
+	INSERT INTO audit_log(actor, action, domain, alias, before_value, after_value, request_id, client_ip, created_at)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Actor, e.Action, e.Domain, e.Alias, nullJSON(e.Before), nullJSON(e.After),
+		e.RequestID, e.ClientIP, e.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ListAudit returns up to limit entries matching filter, newest first.
+func (s *Storage) ListAudit(filter storage.AuditFilter, limit int) ([]storage.AuditEntry, error) {
+	const fn = "storage.sqlite.ListAudit"
+
+	where, args := auditWhere(filter)
+
+	rows, err := s.db.Query(
+		"SELECT "+auditColumns+" FROM audit_log"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []storage.AuditEntry
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		list = append(list, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// ForEachAudit calls visit for every entry matching filter, oldest first,
+// stopping at the first error visit returns.
+func (s *Storage) ForEachAudit(filter storage.AuditFilter, visit func(e storage.AuditEntry) error) error {
+	const fn = "storage.sqlite.ForEachAudit"
+
+	where, args := auditWhere(filter)
+
+	rows, err := s.db.Query("SELECT "+auditColumns+" FROM audit_log"+where+" ORDER BY id", args...)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		if err := visit(e); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return nil
+}
+
+func auditWhere(filter storage.AuditFilter) (string, []any) {
+	var where []string
+	var args []any
+
+	if filter.Actor != "" {
+		where, args = append(where, "actor = ?"), append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		where, args = append(where, "action = ?"), append(args, filter.Action)
+	}
+	if filter.Alias != "" {
+		where, args = append(where, "domain = ? AND alias = ?"), append(args, filter.Domain, filter.Alias)
+	} else if filter.Domain != "" {
+		where, args = append(where, "domain = ?"), append(args, filter.Domain)
+	}
+	if !filter.Since.IsZero() {
+		where, args = append(where, "created_at >= ?"), append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		where, args = append(where, "created_at < ?"), append(args, filter.Until.UTC())
+	}
+	if filter.BeforeID > 0 {
+		where, args = append(where, "id < ?"), append(args, filter.BeforeID)
+	}
+
+	if len(where) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+func scanAudit(row rowScanner) (storage.AuditEntry, error) {
+	var e storage.AuditEntry
+	var before, after sql.NullString
+
+	err := row.Scan(
+		&e.ID, &e.Actor, &e.Action, &e.Domain, &e.Alias, &before, &after,
+		&e.RequestID, &e.ClientIP, &e.CreatedAt,
+	)
+	if err != nil {
+		return e, err
+	}
+
+	if before.Valid {
+		e.Before = []byte(before.String)
+	}
+	if after.Valid {
+		e.After = []byte(after.String)
+	}
+
+	return e, nil
+}
+
+// nullJSON stores missing snapshots as NULL.
+func nullJSON(b []byte) any {
+	if b == nil {
+		return nil
+	}
+
+	return string(b)
+}
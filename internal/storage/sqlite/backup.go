@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	ErrCorruptSnapshot   = errors.New("snapshot failed the integrity check")
+	ErrUnsupportedSchema = errors.New("unsupported snapshot schema version")
+)
+
+// Backup writes a consistent snapshot of the database to destPath using
+// SQLite's online backup API, so it is safe while the server is running.
+// destPath only appears once the snapshot is complete.
+func (s *Storage) Backup(ctx context.Context, destPath string) error {
+	const fn = "storage.sqlite.Backup"
+
+	tmpPath := destPath + ".tmp"
+	if err := os.Remove(tmpPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := backupTo(ctx, s.db, tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func backupTo(ctx context.Context, src *sql.DB, destPath string) error {
+	dest, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dest.Close() }()
+
+	return copyDatabase(ctx, dest, src)
+}
+
+// Restore replaces the contents of the database with the snapshot at
+// snapshotPath. The snapshot is checked for integrity and for a schema
+// version this build knows before anything is changed, and is migrated to
+// the current schema afterwards.
+func (s *Storage) Restore(ctx context.Context, snapshotPath string) error {
+	const fn = "storage.sqlite.Restore"
+
+	if _, err := os.Stat(snapshotPath); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	src, err := sql.Open("sqlite3", "file:"+snapshotPath+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = src.Close() }()
+
+	if err := checkSnapshot(src); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := copyDatabase(ctx, s.db, src); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := migrate(s.db); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// checkSnapshot verifies that db is an intact database with a schema version
+// no later than the latest migration, or a database from before migrations
+// were tracked.
+func checkSnapshot(db *sql.DB) error {
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("%w: %w", ErrCorruptSnapshot, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("%w: %s", ErrCorruptSnapshot, result)
+	}
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if version < 0 || version > len(migrations) {
+		return fmt.Errorf("%w: %d, expected 0 to %d", ErrUnsupportedSchema, version, len(migrations))
+	}
+
+	if version == 0 {
+		// Databases from before migrations were tracked have only the
+		// url table, which migrate upgrades in place.
+		var tables int
+		if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'url'").Scan(&tables); err != nil {
+			return fmt.Errorf("read schema: %w", err)
+		}
+		if tables == 0 {
+			return fmt.Errorf("%w: no url table", ErrUnsupportedSchema)
+		}
+	}
+
+	return nil
+}
+
+// copyDatabase copies every page of src into dest in a single backup step,
+// which holds a read lock on src for the duration so the copy is consistent.
+func copyDatabase(ctx context.Context, dest *sql.DB, src *sql.DB) error {
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = destConn.Close() }()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = srcConn.Close() }()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", destDriverConn)
+			}
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", srcDriverConn)
+			}
+
+			b, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+
+			done, err := b.Step(-1)
+			if err != nil {
+				_ = b.Finish()
+				return err
+			}
+			if !done {
+				_ = b.Finish()
+				return errors.New("backup did not complete")
+			}
+
+			return b.Finish()
+		})
+	})
+}
@@ -0,0 +1,460 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// SaveCollection creates c with its grants and returns its ID. It returns
+// storage.ErrCollectionExists if the owner already has a collection with
+// that name.
+func (s *Storage) SaveCollection(c storage.Collection) (int64, error) {
+	const fn = "storage.sqlite.SaveCollection"
+
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(
+		"INSERT INTO collection(name, owner, created_at) VALUES(?, ?, ?)",
+		c.Name, c.Owner, c.CreatedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, collectionErr(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", fn, err)
+	}
+
+	if err := saveGrants(tx, id, c.Grants); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+// GetCollection returns the collection with id and its grants, or
+// storage.ErrCollectionNotFound.
+func (s *Storage) GetCollection(id int64) (storage.Collection, error) {
+	const fn = "storage.sqlite.GetCollection"
+
+	c := storage.Collection{ID: id}
+	err := s.db.QueryRow(
+		"SELECT name, owner, created_at FROM collection WHERE id = ?", id,
+	).Scan(&c.Name, &c.Owner, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Collection{}, fmt.Errorf("%s: %w", fn, storage.ErrCollectionNotFound)
+	}
+	if err != nil {
+		return storage.Collection{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	grants, err := s.listGrants("WHERE collection_id = ?", id)
+	if err != nil {
+		return storage.Collection{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	c.Grants = grants[id]
+
+	return c, nil
+}
+
+// ListCollections returns the collections user owns or was granted rights
+// on, ordered by name. An empty user lists every collection.
+func (s *Storage) ListCollections(user string) ([]storage.Collection, error) {
+	const fn = "storage.sqlite.ListCollections"
+
+	where := ""
+	var args []any
+	if user != "" {
+		where = "WHERE owner = ? OR id IN (SELECT collection_id FROM collection_grant WHERE user = ?)"
+		args = []any{user, user}
+	}
+
+	rows, err := s.db.Query(
+		"SELECT id, name, owner, created_at FROM collection "+where+" ORDER BY name, id",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var collections []storage.Collection
+	for rows.Next() {
+		var c storage.Collection
+		if err := rows.Scan(&c.ID, &c.Name, &c.Owner, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		collections = append(collections, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	if where != "" {
+		where = "WHERE collection_id IN (SELECT id FROM collection " + where + ")"
+	}
+
+	grants, err := s.listGrants(where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	for i := range collections {
+		collections[i].Grants = grants[collections[i].ID]
+	}
+
+	return collections, nil
+}
+
+// UpdateCollection renames the collection with c.ID and replaces its
+// grants with c.Grants. The owner stays.
+func (s *Storage) UpdateCollection(c storage.Collection) error {
+	const fn = "storage.sqlite.UpdateCollection"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec("UPDATE collection SET name = ? WHERE id = ?", c.Name, c.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, collectionErr(err))
+	}
+	if err := collectionAffected(fn, result); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM collection_grant WHERE collection_id = ?", c.ID); err != nil {
+		return fmt.Errorf("%s: clear grants: %w", fn, err)
+	}
+
+	if err := saveGrants(tx, c.ID, c.Grants); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return nil
+}
+
+// DeleteCollection removes the collection with id and its grants. Its
+// links stay, they just no longer belong to it.
+func (s *Storage) DeleteCollection(id int64) error {
+	const fn = "storage.sqlite.DeleteCollection"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{"collection_grant", "collection_link"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE collection_id = ?", id); err != nil {
+			return fmt.Errorf("%s: delete %s: %w", fn, table, err)
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM collection WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	if err := collectionAffected(fn, result); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return nil
+}
+
+// AddToCollection puts the live link under alias on domain in the
+// collection with id; adding it twice is a no-op. It returns
+// storage.ErrCollectionNotFound or storage.ErrURLNotFound if either is
+// missing.
+func (s *Storage) AddToCollection(id int64, domain string, alias string) error {
+	const fn = "storage.sqlite.AddToCollection"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var found int
+	err = tx.QueryRow("SELECT 1 FROM collection WHERE id = ?", id).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", fn, storage.ErrCollectionNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: find collection: %w", fn, err)
+	}
+
+	err = tx.QueryRow(
+		"SELECT 1 FROM url WHERE domain = ? AND alias = ? AND deleted_at IS NULL", domain, alias,
+	).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", fn, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: find link: %w", fn, err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT OR IGNORE INTO collection_link(collection_id, domain, alias, added_at) VALUES(?, ?, ?, ?)",
+		id, domain, alias, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return nil
+}
+
+// RemoveFromCollection takes the link under alias on domain out of the
+// collection with id. It returns storage.ErrURLNotFound if the link isn't
+// in it.
+func (s *Storage) RemoveFromCollection(id int64, domain string, alias string) error {
+	const fn = "storage.sqlite.RemoveFromCollection"
+
+	result, err := s.db.Exec(
+		"DELETE FROM collection_link WHERE collection_id = ? AND domain = ? AND alias = ?",
+		id, domain, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", fn, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrURLNotFound)
+	}
+
+	return nil
+}
+
+// GetCollectionStats sums up the live links in the collection with id.
+func (s *Storage) GetCollectionStats(id int64) (storage.CollectionStats, error) {
+	const fn = "storage.sqlite.GetCollectionStats"
+
+	var st storage.CollectionStats
+	err := s.db.QueryRow(`
+	SELECT COUNT(*), COALESCE(SUM(url.clicks), 0)
+	FROM collection_link c JOIN url ON url.domain = c.domain AND url.alias = c.alias
+	WHERE c.collection_id = ? AND url.deleted_at IS NULL`,
+		id,
+	).Scan(&st.Links, &st.Clicks)
+	if err != nil {
+		return storage.CollectionStats{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return st, nil
+}
+
+// GetCollectionGeoClicks returns the clicks through the live links in the
+// collection with id by the visitor's country and region, most clicks
+// first.
+func (s *Storage) GetCollectionGeoClicks(id int64) ([]storage.GeoClicks, error) {
+	const fn = "storage.sqlite.GetCollectionGeoClicks"
+
+	rows, err := s.db.Query(`
+	SELECT g.country, g.region, SUM(g.clicks) FROM geo_click g
+	JOIN collection_link c ON c.domain = g.domain AND c.alias = g.alias
+	JOIN url ON url.domain = g.domain AND url.alias = g.alias
+	WHERE c.collection_id = ? AND url.deleted_at IS NULL
+	GROUP BY g.country, g.region
+	ORDER BY SUM(g.clicks) DESC, g.country, g.region`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var clicks []storage.GeoClicks
+	for rows.Next() {
+		var c storage.GeoClicks
+		if err := rows.Scan(&c.Country, &c.Region, &c.Clicks); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		clicks = append(clicks, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return clicks, nil
+}
+
+// LinkRole returns the role user has on the link under alias on domain,
+// live or in the trash: storage.RoleOwner for its owner, otherwise the
+// best role any collection holding it gives, where collection owners may
+// edit. It is empty if user has no rights, and storage.ErrURLNotFound is
+// returned if there is no such link.
+func (s *Storage) LinkRole(domain string, alias string, user string) (string, error) {
+	const fn = "storage.sqlite.LinkRole"
+
+	var owner string
+	err := s.db.QueryRow("SELECT owner FROM url WHERE domain = ? AND alias = ?", domain, alias).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", fn, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: find link: %w", fn, err)
+	}
+
+	if owner == user {
+		return storage.RoleOwner, nil
+	}
+
+	var rank sql.NullInt64
+	err = s.db.QueryRow(`
+	SELECT MAX(CASE WHEN c.owner = ? OR g.role = ? THEN 2 WHEN g.role = ? THEN 1 ELSE 0 END)
+	FROM collection_link l
+	JOIN collection c ON c.id = l.collection_id
+	LEFT JOIN collection_grant g ON g.collection_id = c.id AND g.user = ?
+	WHERE l.domain = ? AND l.alias = ?`,
+		user, storage.RoleEdit, storage.RoleRead, user, domain, alias,
+	).Scan(&rank)
+	if err != nil {
+		return "", fmt.Errorf("%s: find grants: %w", fn, err)
+	}
+
+	switch rank.Int64 {
+	case 2:
+		return storage.RoleEdit, nil
+	case 1:
+		return storage.RoleRead, nil
+	}
+
+	return "", nil
+}
+
+// CollectionRole returns the role user has on the collection with id:
+// storage.RoleOwner for its owner, otherwise the role granted to user,
+// which is empty if there is none. It returns
+// storage.ErrCollectionNotFound if there is no such collection.
+func (s *Storage) CollectionRole(id int64, user string) (string, error) {
+	const fn = "storage.sqlite.CollectionRole"
+
+	var owner, role string
+	err := s.db.QueryRow(`
+	SELECT c.owner, COALESCE(g.role, '') FROM collection c
+	LEFT JOIN collection_grant g ON g.collection_id = c.id AND g.user = ?
+	WHERE c.id = ?`,
+		user, id,
+	).Scan(&owner, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", fn, storage.ErrCollectionNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	if owner == user {
+		return storage.RoleOwner, nil
+	}
+
+	return role, nil
+}
+
+// listGrants returns the grants of the collections selected by where, by
+// collection ID and ordered by user.
+func (s *Storage) listGrants(where string, args ...any) (map[int64][]storage.Grant, error) {
+	rows, err := s.db.Query(
+		"SELECT collection_id, user, role FROM collection_grant "+where+" ORDER BY collection_id, user",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list grants: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	grants := make(map[int64][]storage.Grant)
+	for rows.Next() {
+		var id int64
+		var g storage.Grant
+		if err := rows.Scan(&id, &g.User, &g.Role); err != nil {
+			return nil, fmt.Errorf("scan grant: %w", err)
+		}
+
+		grants[id] = append(grants[id], g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate grants: %w", err)
+	}
+
+	return grants, nil
+}
+
+func saveGrants(tx *sql.Tx, id int64, grants []storage.Grant) error {
+	for _, g := range grants {
+		_, err := tx.Exec(
+			"INSERT OR REPLACE INTO collection_grant(collection_id, user, role) VALUES(?, ?, ?)",
+			id, g.User, g.Role,
+		)
+		if err != nil {
+			return fmt.Errorf("save grant: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// collectionErr turns name clashes into storage.ErrCollectionExists.
+func collectionErr(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return storage.ErrCollectionExists
+	}
+
+	return fmt.Errorf("execute statement: %w", err)
+}
+
+func collectionAffected(fn string, result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrCollectionNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestStorage_Collections(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	for _, link := range []storage.Link{
+		{Alias: "launch-blog", Owner: "alice"},
+		{Alias: "launch-video", Owner: "alice"},
+		{Alias: "other", Owner: "alice"},
+	} {
+		link.URL = "https://example.com/" + link.Alias
+		_, err := db.SaveURL(link)
+		require.NoError(t, err)
+	}
+
+	id, err := db.SaveCollection(storage.Collection{
+		Name:   "Q3 launch",
+		Owner:  "alice",
+		Grants: []storage.Grant{{User: "bob", Role: storage.RoleEdit}, {User: "carol", Role: storage.RoleRead}},
+	})
+	require.NoError(t, err)
+
+	_, err = db.SaveCollection(storage.Collection{Name: "Q3 launch", Owner: "alice"})
+	assert.ErrorIs(t, err, storage.ErrCollectionExists)
+
+	_, err = db.SaveCollection(storage.Collection{Name: "Q3 launch", Owner: "dave"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.AddToCollection(id, "", "launch-blog"))
+	require.NoError(t, db.AddToCollection(id, "", "launch-blog"))
+	require.NoError(t, db.AddToCollection(id, "", "launch-video"))
+	assert.ErrorIs(t, db.AddToCollection(id, "", "missing"), storage.ErrURLNotFound)
+	assert.ErrorIs(t, db.AddToCollection(id+100, "", "other"), storage.ErrCollectionNotFound)
+
+	links, err := db.ListLinks(storage.LinkFilter{Collection: id}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"launch-video", "launch-blog"}, aliases(links))
+
+	for _, tc := range []struct {
+		user  string
+		alias string
+		role  string
+	}{
+		{"alice", "other", storage.RoleOwner},
+		{"bob", "launch-blog", storage.RoleEdit},
+		{"carol", "launch-blog", storage.RoleRead},
+		{"carol", "other", ""},
+		{"dave", "launch-blog", ""},
+	} {
+		role, err := db.LinkRole("", tc.alias, tc.user)
+		require.NoError(t, err)
+		assert.Equal(t, tc.role, role, "%s on %s", tc.user, tc.alias)
+	}
+
+	_, err = db.LinkRole("", "missing", "bob")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	role, err := db.CollectionRole(id, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleRead, role)
+
+	role, err = db.CollectionRole(id, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleOwner, role)
+
+	collections, err := db.ListCollections("bob")
+	require.NoError(t, err)
+	require.Len(t, collections, 1)
+	assert.Equal(t, "Q3 launch", collections[0].Name)
+	assert.Equal(t, "alice", collections[0].Owner)
+	assert.Len(t, collections[0].Grants, 2)
+
+	collections, err = db.ListCollections("")
+	require.NoError(t, err)
+	assert.Len(t, collections, 2)
+
+	// Stats only count live links.
+	require.NoError(t, db.RecordClick("", "launch-blog", storage.Click{Rule: storage.DefaultRule, Country: "DE"}))
+	require.NoError(t, db.RecordClick("", "launch-video", storage.Click{Rule: storage.DefaultRule, Country: "DE"}))
+	require.NoError(t, db.DeleteURL("", "launch-video", "alice"))
+
+	st, err := db.GetCollectionStats(id)
+	require.NoError(t, err)
+	assert.Equal(t, storage.CollectionStats{Links: 1, Clicks: 1}, st)
+
+	geo, err := db.GetCollectionGeoClicks(id)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.GeoClicks{{Country: "DE", Clicks: 1}}, geo)
+
+	// Grants still reach trashed links so they can be restored.
+	role, err = db.LinkRole("", "launch-video", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleEdit, role)
+
+	require.NoError(t, db.UpdateCollection(storage.Collection{ID: id, Name: "Q3 launch (EU)"}))
+
+	c, err := db.GetCollection(id)
+	require.NoError(t, err)
+	assert.Equal(t, "Q3 launch (EU)", c.Name)
+	assert.Equal(t, "alice", c.Owner)
+	assert.Empty(t, c.Grants)
+
+	role, err = db.LinkRole("", "launch-blog", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "", role)
+
+	assert.ErrorIs(t, db.RemoveFromCollection(id, "", "other"), storage.ErrURLNotFound)
+	require.NoError(t, db.RemoveFromCollection(id, "", "launch-blog"))
+
+	require.NoError(t, db.DeleteCollection(id))
+	assert.ErrorIs(t, db.DeleteCollection(id), storage.ErrCollectionNotFound)
+
+	_, err = db.GetCollection(id)
+	assert.ErrorIs(t, err, storage.ErrCollectionNotFound)
+}
@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// ListDomains returns the registered short domains ordered by host.
+func (s *Storage) ListDomains() ([]storage.Domain, error) {
+	const fn = "storage.sqlite.ListDomains"
+
+	rows, err := s.db.Query("SELECT host, fallback_url, redirect_code, created_at FROM domain ORDER BY host")
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var domains []storage.Domain
+	for rows.Next() {
+		var d storage.Domain
+		var createdAt sql.NullTime
+		if err := rows.Scan(&d.Host, &d.FallbackURL, &d.RedirectCode, &createdAt); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		d.CreatedAt = createdAt.Time
+
+		domains = append(domains, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return domains, nil
+}
+
+// GetDomain returns the registered domain host, or storage.ErrDomainNotFound.
+func (s *Storage) GetDomain(host string) (storage.Domain, error) {
+	const fn = "storage.sqlite.GetDomain"
+
+	var d storage.Domain
+	var createdAt sql.NullTime
+
+	err := s.db.QueryRow(
+		"SELECT host, fallback_url, redirect_code, created_at FROM domain WHERE host = ?", host,
+	).Scan(&d.Host, &d.FallbackURL, &d.RedirectCode, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Domain{}, fmt.Errorf("%s: %w", fn, storage.ErrDomainNotFound)
+	}
+	if err != nil {
+		return storage.Domain{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	d.CreatedAt = createdAt.Time
+
+	return d, nil
+}
+
+// SaveDomain registers d. It returns storage.ErrDomainExists if the host is
+// already registered.
+func (s *Storage) SaveDomain(d storage.Domain) error {
+	const fn = "storage.sqlite.SaveDomain"
+
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := s.db.Exec(
+		"INSERT INTO domain(host, fallback_url, redirect_code, created_at) VALUES(?, ?, ?, ?)",
+		d.Host, d.FallbackURL, d.RedirectCode, d.CreatedAt,
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return fmt.Errorf("%s: %w", fn, storage.ErrDomainExists)
+		}
+
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return nil
+}
+
+// UpdateDomain replaces the defaults of the domain registered as d.Host.
+func (s *Storage) UpdateDomain(d storage.Domain) error {
+	const fn = "storage.sqlite.UpdateDomain"
+
+	result, err := s.db.Exec(
+		"UPDATE domain SET fallback_url = ?, redirect_code = ? WHERE host = ?",
+		d.FallbackURL, d.RedirectCode, d.Host,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return domainAffected(fn, result)
+}
+
+// DeleteDomain unregisters host. It returns storage.ErrDomainInUse while
+// links still belong to the domain.
+func (s *Storage) DeleteDomain(host string) error {
+	const fn = "storage.sqlite.DeleteDomain"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var links int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM url WHERE domain = ?", host).Scan(&links); err != nil {
+		return fmt.Errorf("%s: count links: %w", fn, err)
+	}
+	if links > 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrDomainInUse)
+	}
+
+	result, err := tx.Exec("DELETE FROM domain WHERE host = ?", host)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	if err := domainAffected(fn, result); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", fn, err)
+	}
+
+	return nil
+}
+
+func domainAffected(fn string, result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrDomainNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// healthColumns are the columns scanned by scanHealth, url joined with
+// link_health.
+const healthColumns = `url.domain, url.alias, url.url,
+	COALESCE(h.status_code, 0), COALESCE(h.error, ''), COALESCE(h.latency_ms, 0),
+	h.checked_at, COALESCE(h.healthy, 1), COALESCE(h.failures, 0)`
+
+func scanHealth(row rowScanner) (storage.Health, error) {
+	var h storage.Health
+	var latencyMS int64
+	var checkedAt sql.NullTime
+
+	err := row.Scan(&h.Domain, &h.Alias, &h.URL, &h.StatusCode, &h.Error, &latencyMS, &checkedAt, &h.Healthy, &h.Failures)
+	if err != nil {
+		return storage.Health{}, err
+	}
+	h.Latency = time.Duration(latencyMS) * time.Millisecond
+	h.CheckedAt = checkedAt.Time
+
+	return h, nil
+}
+
+func (s *Storage) queryHealth(query string, args ...any) ([]storage.Health, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("execute statement: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []storage.Health
+	for rows.Next() {
+		h, err := scanHealth(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		list = append(list, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return list, nil
+}
+
+// ListHealthDue returns up to limit links last checked before
+// checkedBefore, never checked links first, with the result of their
+// last check. Pages have no destination of their own and are skipped.
+func (s *Storage) ListHealthDue(checkedBefore time.Time, limit int) ([]storage.Health, error) {
+	const fn = "storage.sqlite.ListHealthDue"
+
+	list, err := s.queryHealth(`
+	SELECT `+healthColumns+`
+	FROM url LEFT JOIN link_health h ON h.domain = url.domain AND h.alias = url.alias
+	WHERE url.deleted_at IS NULL AND url.page IS NULL AND (h.checked_at IS NULL OR h.checked_at < ?)
+	ORDER BY h.checked_at, url.id
+	LIMIT ?`,
+		checkedBefore.UTC(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// ListBrokenLinks returns up to limit links whose last check failed, the
+// longest failing first.
+func (s *Storage) ListBrokenLinks(limit int) ([]storage.Health, error) {
+	const fn = "storage.sqlite.ListBrokenLinks"
+
+	list, err := s.queryHealth(`
+	SELECT `+healthColumns+`
+	FROM url JOIN link_health h ON h.domain = url.domain AND h.alias = url.alias
+	WHERE h.healthy = 0 AND url.deleted_at IS NULL
+	ORDER BY h.failures DESC, h.checked_at DESC
+	LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// SaveHealth stores the result of checking h.Alias on h.Domain.
+func (s *Storage) SaveHealth(h storage.Health) error {
+	const fn = "storage.sqlite.SaveHealth"
+
+	_, err := s.db.Exec(`
+	INSERT INTO link_health(domain, alias, url, status_code, error, latency_ms, checked_at, healthy, failures)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(domain, alias) DO UPDATE SET
+		url = excluded.url, status_code = excluded.status_code, error = excluded.error,
+		latency_ms = excluded.latency_ms, checked_at = excluded.checked_at,
+		healthy = excluded.healthy, failures = excluded.failures`,
+		h.Domain, h.Alias, h.URL, h.StatusCode, h.Error, h.Latency.Milliseconds(), h.CheckedAt.UTC(), h.Healthy, h.Failures,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return nil
+}
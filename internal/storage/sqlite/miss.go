@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// RecordMiss counts a request for alias on domain that matched no link.
+func (s *Storage) RecordMiss(domain string, alias string) error {
+	const fn = "storage.sqlite.RecordMiss"
+
+	now := time.Now().UTC()
+
+	_, err := s.db.Exec(`
+	INSERT INTO miss(domain, alias, count, first_seen, last_seen) VALUES(?, ?, 1, ?, ?)
+	ON CONFLICT(domain, alias) DO UPDATE SET count = count + 1, last_seen = excluded.last_seen`,
+		domain, alias, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ListMisses returns up to limit missed aliases, most requested first.
+// Aliases that have since been created are left out.
+func (s *Storage) ListMisses(limit int) ([]storage.Miss, error) {
+	const fn = "storage.sqlite.ListMisses"
+
+	rows, err := s.db.Query(`
+	SELECT domain, alias, count, first_seen, last_seen FROM miss
+	WHERE NOT EXISTS (SELECT 1 FROM url WHERE url.domain = miss.domain AND url.alias = miss.alias)
+	ORDER BY count DESC, last_seen DESC
+	LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var misses []storage.Miss
+	for rows.Next() {
+		var m storage.Miss
+		if err := rows.Scan(&m.Domain, &m.Alias, &m.Count, &m.FirstSeen, &m.LastSeen); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		misses = append(misses, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return misses, nil
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestStorage_Pages(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	page := &storage.Page{
+		Title:   "Jane",
+		Theme:   storage.ThemeDark,
+		Buttons: []storage.Button{{Label: "Blog", URL: "https://example.com/blog"}, {Name: "shop", Label: "Shop", URL: "https://example.com/shop"}},
+	}
+	_, err = db.SaveURL(storage.Link{Alias: "jane", Owner: "alice", Page: page})
+	require.NoError(t, err)
+
+	link, err := db.GetLink("", "jane")
+	require.NoError(t, err)
+	assert.Equal(t, page, link.Page)
+	assert.Empty(t, link.URL)
+
+	require.NoError(t, db.RecordClick("", "jane", storage.Click{Button: "shop", Country: "DE"}))
+	require.NoError(t, db.RecordClick("", "jane", storage.Click{Button: "shop"}))
+	require.NoError(t, db.RecordClick("", "jane", storage.Click{Button: "button-1"}))
+
+	buttons, err := db.GetButtonClicks("", "jane")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"shop": 2, "button-1": 1}, buttons)
+
+	rules, err := db.GetRuleClicks("", "jane")
+	require.NoError(t, err)
+	assert.Empty(t, rules, "button clicks are not counted by rule")
+
+	link, err = db.GetLink("", "jane")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), link.Clicks)
+
+	// Turning the page into a redirect and back goes through revisions.
+	require.NoError(t, db.ReplaceURL(storage.Link{Alias: "jane", URL: "https://example.com", Owner: "alice"}, "alice"))
+
+	link, err = db.GetLink("", "jane")
+	require.NoError(t, err)
+	assert.Nil(t, link.Page)
+
+	rev, err := db.RollbackURL("", "jane", 1, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, page, rev.Page)
+
+	link, err = db.GetLink("", "jane")
+	require.NoError(t, err)
+	assert.Equal(t, page, link.Page)
+	assert.Empty(t, link.URL)
+}
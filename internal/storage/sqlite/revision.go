@@ -0,0 +1,274 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// appendRevision snapshots the url rows selected by where, as they are
+// within tx, as the next revision of their aliases. An empty actor
+// attributes the change to the link's owner.
+func appendRevision(tx *sql.Tx, action string, actor string, rollbackOf int64, where string, args ...any) error {
+	_, err := tx.Exec(`
+	INSERT INTO link_revision(link_id, domain, alias, revision, action, url, owner, normalized_url, rules, variants,
+		title, description, tags, metadata, page, deleted, rollback_of, actor, created_at)
+	SELECT id, domain, alias,
+		COALESCE((SELECT MAX(r.revision) FROM link_revision r WHERE r.domain = url.domain AND r.alias = url.alias), 0) + 1,
+		?, url, owner, normalized_url, rules, variants,
+		title, description, tags, metadata, page, deleted_at IS NOT NULL, NULLIF(?, 0), COALESCE(NULLIF(?, ''), owner), ?
+	FROM url `+where,
+		append([]any{action, rollbackOf, actor, time.Now().UTC()}, args...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("append revision: %w", err)
+	}
+
+	return nil
+}
+
+// changeLink runs update, a statement changing the link under alias on
+// domain, and records the revision it makes in the same transaction. It
+// returns storage.ErrURLNotFound if update changed nothing.
+func (s *Storage) changeLink(action string, actor string, domain string, alias string, update string, args ...any) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := execChange(tx, update, args...); err != nil {
+		return err
+	}
+
+	if err := appendRevision(tx, action, actor, 0, "WHERE domain = ? AND alias = ?", domain, alias); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// execChange runs update within tx, mapping unique constraint violations
+// like insertError and an update that changed nothing to
+// storage.ErrURLNotFound.
+func execChange(tx *sql.Tx, update string, args ...any) error {
+	result, err := tx.Exec(update, args...)
+	if err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return insertError(err)
+		}
+
+		return fmt.Errorf("execute statement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// linkRevisions restricts a link_revision query to the revisions of the
+// link under an alias, given the domain and alias. Once the link is purged,
+// the last link under the alias stands in for it.
+const linkRevisions = `domain = ? AND alias = ? AND link_id IS COALESCE(
+	(SELECT id FROM url WHERE url.domain = link_revision.domain AND url.alias = link_revision.alias),
+	(SELECT MAX(r.link_id) FROM link_revision r WHERE r.domain = link_revision.domain AND r.alias = link_revision.alias))`
+
+// revisionColumns are the link_revision columns scanned by scanRevision.
+const revisionColumns = `domain, alias, revision, action, url, owner, COALESCE(normalized_url, ''),
+	COALESCE(rules, ''), COALESCE(variants, ''), title, description, COALESCE(tags, ''), COALESCE(metadata, ''),
+	COALESCE(page, ''), deleted, COALESCE(rollback_of, 0), actor, created_at`
+
+func scanRevision(row rowScanner) (storage.Revision, error) {
+	var rev storage.Revision
+	var rules, variants, tags, metadata, page string
+
+	err := row.Scan(
+		&rev.Domain, &rev.Alias, &rev.Revision, &rev.Action, &rev.URL, &rev.Owner, &rev.NormalizedURL,
+		&rules, &variants, &rev.Title, &rev.Description, &tags, &metadata,
+		&page, &rev.Deleted, &rev.RollbackOf, &rev.Actor, &rev.CreatedAt,
+	)
+	if err != nil {
+		return storage.Revision{}, err
+	}
+
+	if err := decodeDetails(tags, metadata, &rev.Tags, &rev.Metadata); err != nil {
+		return storage.Revision{}, err
+	}
+
+	if err := decodePage(page, &rev.Page); err != nil {
+		return storage.Revision{}, err
+	}
+
+	if rules != "" {
+		if err := json.Unmarshal([]byte(rules), &rev.Rules); err != nil {
+			return storage.Revision{}, fmt.Errorf("decode rules: %w", err)
+		}
+	}
+
+	if variants != "" {
+		if err := json.Unmarshal([]byte(variants), &rev.Variants); err != nil {
+			return storage.Revision{}, fmt.Errorf("decode variants: %w", err)
+		}
+	}
+
+	return rev, nil
+}
+
+// ListRevisions returns up to limit revisions of the link under alias on
+// domain, the latest first. Once the link is purged, those of the last
+// link under the alias are returned.
+func (s *Storage) ListRevisions(domain string, alias string, limit int) ([]storage.Revision, error) {
+	const fn = "storage.sqlite.ListRevisions"
+
+	rows, err := s.db.Query(
+		"SELECT "+revisionColumns+" FROM link_revision WHERE "+linkRevisions+" ORDER BY revision DESC LIMIT ?",
+		domain, alias, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var revisions []storage.Revision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevisionAt returns the revision of the link under alias on domain in
+// effect at at, the latest one made by then. It returns
+// storage.ErrRevisionNotFound if the link did not exist yet.
+func (s *Storage) GetRevisionAt(domain string, alias string, at time.Time) (storage.Revision, error) {
+	const fn = "storage.sqlite.GetRevisionAt"
+
+	rev, err := scanRevision(s.db.QueryRow(`
+	SELECT `+revisionColumns+` FROM link_revision
+	WHERE `+linkRevisions+` AND created_at <= ?
+	ORDER BY revision DESC LIMIT 1`,
+		domain, alias, at.UTC(),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Revision{}, storage.ErrRevisionNotFound
+		}
+
+		return storage.Revision{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return rev, nil
+}
+
+// GetRevision returns revision of the link under alias on domain. It
+// returns storage.ErrRevisionNotFound if the link has no such revision.
+func (s *Storage) GetRevision(domain string, alias string, revision int64) (storage.Revision, error) {
+	const fn = "storage.sqlite.GetRevision"
+
+	rev, err := scanRevision(s.db.QueryRow(
+		"SELECT "+revisionColumns+" FROM link_revision WHERE "+linkRevisions+" AND revision = ?",
+		domain, alias, revision,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Revision{}, storage.ErrRevisionNotFound
+		}
+
+		return storage.Revision{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return rev, nil
+}
+
+// RollbackURL sets the destination and options of the live link under
+// alias on domain back to those of revision, on behalf of actor, and
+// returns the revision this makes. The owner, title, description, tags
+// and metadata are left alone. It returns
+// storage.ErrURLNotFound if the link is not live,
+// storage.ErrRevisionNotFound for a revision it does not have, such as one
+// of an earlier link under the alias, and storage.ErrURLExists if the
+// owner has another link with the revision's normalized URL.
+func (s *Storage) RollbackURL(domain string, alias string, revision int64, actor string) (storage.Revision, error) {
+	const fn = "storage.sqlite.RollbackURL"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	err = tx.QueryRow("SELECT id FROM url WHERE domain = ? AND alias = ? AND deleted_at IS NULL", domain, alias).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Revision{}, fmt.Errorf("%s: %w", fn, storage.ErrURLNotFound)
+		}
+
+		return storage.Revision{}, fmt.Errorf("%s: read link: %w", fn, err)
+	}
+
+	var url string
+	var normalizedURL, rules, variants, page sql.NullString
+	err = tx.QueryRow(
+		"SELECT url, normalized_url, rules, variants, page FROM link_revision WHERE link_id = ? AND revision = ?",
+		id, revision,
+	).Scan(&url, &normalizedURL, &rules, &variants, &page)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Revision{}, fmt.Errorf("%s: %w", fn, storage.ErrRevisionNotFound)
+		}
+
+		return storage.Revision{}, fmt.Errorf("%s: read revision: %w", fn, err)
+	}
+
+	err = execChange(tx, "UPDATE url SET url = ?, normalized_url = ?, rules = ?, variants = ?, page = ? WHERE id = ?",
+		url, normalizedURL, rules, variants, page, id,
+	)
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := appendRevision(tx, storage.RevisionRollback, actor, revision, "WHERE id = ?", id); err != nil {
+		return storage.Revision{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rev, err := scanRevision(tx.QueryRow(
+		"SELECT "+revisionColumns+" FROM link_revision WHERE link_id = ? ORDER BY revision DESC LIMIT 1",
+		id,
+	))
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%s: read new revision: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.Revision{}, fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return rev, nil
+}
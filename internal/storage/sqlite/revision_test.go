@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestStorage_Revisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	db, err := New(path)
+	require.NoError(t, err)
+
+	_, err = db.SaveURL(storage.Link{Alias: "docs", URL: "https://example.com/v1", Owner: "alice"})
+	require.NoError(t, err)
+	created := time.Now()
+
+	time.Sleep(10 * time.Millisecond)
+	variants := []storage.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}}
+	require.NoError(t, db.UpdateVariants("", "docs", variants, "bob"))
+	require.NoError(t, db.ReplaceURL(storage.Link{Alias: "docs", URL: "https://example.com/v2", Owner: "carol"}, "admin"))
+	require.NoError(t, db.DeleteURL("", "docs", "bob"))
+
+	_, err = db.RollbackURL("", "docs", 1, "bob")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	require.NoError(t, db.RestoreURL("", "docs", "alice"))
+
+	rev, err := db.RollbackURL("", "docs", 2, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), rev.Revision)
+	assert.Equal(t, storage.RevisionRollback, rev.Action)
+	assert.Equal(t, int64(2), rev.RollbackOf)
+	assert.Equal(t, "https://example.com/v1", rev.URL)
+	assert.Equal(t, variants, rev.Variants)
+	assert.Equal(t, "carol", rev.Owner, "rollbacks keep the owner")
+
+	link, err := db.GetLink("", "docs")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v1", link.URL)
+	assert.Equal(t, variants, link.Variants)
+
+	_, err = db.RollbackURL("", "docs", 42, "alice")
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+
+	revisions, err := db.ListRevisions("", "docs", 10)
+	require.NoError(t, err)
+
+	var actions, actors []string
+	for _, rev := range revisions {
+		actions = append(actions, rev.Action)
+		actors = append(actors, rev.Actor)
+	}
+	assert.Equal(t, []string{
+		storage.RevisionRollback, storage.RevisionRestore, storage.RevisionDelete,
+		storage.RevisionUpdate, storage.RevisionUpdate, storage.RevisionCreate,
+	}, actions)
+	assert.Equal(t, []string{"alice", "alice", "bob", "admin", "bob", "alice"}, actors)
+	assert.True(t, revisions[2].Deleted)
+
+	// The revision in effect at a time tells where the link went then.
+	rev, err = db.GetRevisionAt("", "docs", created)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rev.Revision)
+	assert.Empty(t, rev.Variants)
+
+	_, err = db.GetRevisionAt("", "docs", created.Add(-time.Hour))
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer func() { _ = raw.Close() }()
+
+	_, err = raw.Exec("UPDATE link_revision SET url = 'https://evil.example'")
+	assert.ErrorContains(t, err, "immutable")
+}
+
+func TestStorage_RevisionsOfReusedAlias(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	oldID, err := db.SaveURL(storage.Link{Alias: "docs", URL: "https://example.com/old", Owner: "alice"})
+	require.NoError(t, err)
+	require.NoError(t, db.DeleteURL("", "docs", "alice"))
+
+	purged, err := db.PurgeDeleted(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, purged, 1)
+
+	// Once purged, the alias still shows the history of its last link.
+	revisions, err := db.ListRevisions("", "docs", 10)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2)
+
+	id, err := db.SaveURL(storage.Link{Alias: "docs", URL: "https://example.com/new", Owner: "bob"})
+	require.NoError(t, err)
+	assert.Greater(t, id, oldID, "ids of purged links are not reused")
+
+	// A new link under the alias starts a history of its own and cannot be
+	// rolled back to the purged link's destinations.
+	revisions, err = db.ListRevisions("", "docs", 10)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, int64(3), revisions[0].Revision)
+	assert.Equal(t, "https://example.com/new", revisions[0].URL)
+
+	_, err = db.RollbackURL("", "docs", 1, "bob")
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+	_, err = db.GetRevision("", "docs", 1)
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+
+	rev, err := db.GetRevision("", "docs", 3)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/new", rev.URL)
+
+	_, err = db.GetRevisionAt("", "docs", revisions[0].CreatedAt.Add(-time.Millisecond))
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+}
@@ -2,8 +2,11 @@ package sqlite
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/MaximShildyakov/url-shortener/internal/storage"	
 
@@ -14,6 +17,262 @@ type Storage struct{
 	db *sql.DB
 }
 
+// migrations are applied in order on startup; PRAGMA user_version records
+// how many of them the database has already seen.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS url(
+		id INTEGER PRIMARY KEY,
+		alias TEXT NOT NULL UNIQUE,
+		url TEXT NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);`,
+	`CREATE TABLE IF NOT EXISTS alias_sequence(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		value INTEGER NOT NULL);
+	INSERT OR IGNORE INTO alias_sequence(id, value) VALUES(1, 0);`,
+	`ALTER TABLE url ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+	ALTER TABLE url ADD COLUMN normalized_url TEXT;
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_owner_normalized_url
+		ON url(owner, normalized_url) WHERE normalized_url IS NOT NULL;`,
+	// Links saved before this migration have no creation time.
+	`ALTER TABLE url ADD COLUMN created_at TIMESTAMP;`,
+	`ALTER TABLE url ADD COLUMN clicks INTEGER NOT NULL DEFAULT 0;`,
+	// Aliases become unique per domain, which SQLite can only do by
+	// rebuilding the table. The empty domain is the default one.
+	`CREATE TABLE domain(
+		id INTEGER PRIMARY KEY,
+		host TEXT NOT NULL UNIQUE,
+		fallback_url TEXT NOT NULL DEFAULT '',
+		redirect_code INTEGER NOT NULL DEFAULT 302,
+		created_at TIMESTAMP);
+	CREATE TABLE url_new(
+		id INTEGER PRIMARY KEY,
+		domain TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		url TEXT NOT NULL,
+		owner TEXT NOT NULL DEFAULT '',
+		normalized_url TEXT,
+		created_at TIMESTAMP,
+		clicks INTEGER NOT NULL DEFAULT 0);
+	INSERT INTO url_new(id, alias, url, owner, normalized_url, created_at, clicks)
+		SELECT id, alias, url, owner, normalized_url, created_at, clicks FROM url;
+	DROP TABLE url;
+	ALTER TABLE url_new RENAME TO url;
+	CREATE UNIQUE INDEX idx_domain_alias ON url(domain, alias);
+	CREATE UNIQUE INDEX idx_owner_normalized_url
+		ON url(owner, domain, normalized_url) WHERE normalized_url IS NOT NULL;`,
+	`CREATE TABLE miss(
+		domain TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		first_seen TIMESTAMP NOT NULL,
+		last_seen TIMESTAMP NOT NULL,
+		PRIMARY KEY(domain, alias));`,
+	// rules holds the link's targeting rules as JSON.
+	`ALTER TABLE url ADD COLUMN rules TEXT;
+	CREATE TABLE rule_click(
+		domain TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		rule TEXT NOT NULL,
+		clicks INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(domain, alias, rule));`,
+	// variants holds the link's weighted A/B destinations as JSON.
+	`ALTER TABLE url ADD COLUMN variants TEXT;
+	CREATE TABLE variant_click(
+		domain TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		variant TEXT NOT NULL,
+		clicks INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(domain, alias, variant));`,
+	// Unknown locations are counted under empty codes.
+	`CREATE TABLE geo_click(
+		domain TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		country TEXT NOT NULL,
+		region TEXT NOT NULL,
+		clicks INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(domain, alias, country, region));`,
+	// url is the destination that was checked, which may since have
+	// changed.
+	`CREATE TABLE link_health(
+		domain TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		checked_at TIMESTAMP NOT NULL,
+		healthy INTEGER NOT NULL,
+		failures INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(domain, alias));
+	CREATE INDEX idx_link_health_checked_at ON link_health(checked_at);`,
+	// webhook_delivery is both the outbox and the delivery log. dedupe_key
+	// keeps events that must be sent once from being queued twice.
+	`CREATE TABLE webhook(
+		id INTEGER PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL);
+	CREATE TABLE webhook_delivery(
+		id INTEGER PRIMARY KEY,
+		webhook_id INTEGER NOT NULL,
+		event TEXT NOT NULL,
+		dedupe_key TEXT,
+		payload BLOB NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		delivered_at TIMESTAMP);
+	CREATE INDEX idx_webhook_delivery_due ON webhook_delivery(status, next_attempt_at);
+	CREATE UNIQUE INDEX idx_webhook_delivery_key ON webhook_delivery(webhook_id, dedupe_key);
+	CREATE TABLE webhook_attempt(
+		id INTEGER PRIMARY KEY,
+		delivery_id INTEGER NOT NULL,
+		status_code INTEGER NOT NULL,
+		error TEXT NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		attempted_at TIMESTAMP NOT NULL);
+	CREATE INDEX idx_webhook_attempt_delivery ON webhook_attempt(delivery_id);
+	CREATE TABLE webhook_dead_letter(
+		id INTEGER PRIMARY KEY,
+		delivery_id INTEGER NOT NULL UNIQUE,
+		webhook_id INTEGER NOT NULL,
+		event TEXT NOT NULL,
+		payload BLOB NOT NULL,
+		attempts INTEGER NOT NULL,
+		error TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL);`,
+	// audit_log is append-only: the triggers refuse to change or remove
+	// entries.
+	`CREATE TABLE audit_log(
+		id INTEGER PRIMARY KEY,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		domain TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL DEFAULT '',
+		before_value TEXT,
+		after_value TEXT,
+		request_id TEXT NOT NULL DEFAULT '',
+		client_ip TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL);
+	CREATE INDEX idx_audit_log_link ON audit_log(domain, alias);
+	CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+	CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;
+	CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;`,
+	// Deleted links stay in the table, holding on to their alias, until
+	// they are purged. Only live links take part in deduplication.
+	`ALTER TABLE url ADD COLUMN deleted_at TIMESTAMP;
+	CREATE INDEX idx_url_deleted_at ON url(deleted_at) WHERE deleted_at IS NOT NULL;
+	DROP INDEX idx_owner_normalized_url;
+	CREATE UNIQUE INDEX idx_owner_normalized_url
+		ON url(owner, domain, normalized_url) WHERE normalized_url IS NOT NULL AND deleted_at IS NULL;`,
+	// link_revision keeps a snapshot of every link after each change; the
+	// triggers refuse to change or remove them. Existing links start with
+	// their creation, and a deletion if they are in the trash.
+	`CREATE TABLE link_revision(
+		id INTEGER PRIMARY KEY,
+		domain TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		revision INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		url TEXT NOT NULL,
+		owner TEXT NOT NULL DEFAULT '',
+		normalized_url TEXT,
+		rules TEXT,
+		variants TEXT,
+		deleted INTEGER NOT NULL DEFAULT 0,
+		rollback_of INTEGER,
+		actor TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		UNIQUE(domain, alias, revision));
+	CREATE INDEX idx_link_revision_created_at ON link_revision(domain, alias, created_at);
+	CREATE TRIGGER link_revision_no_update BEFORE UPDATE ON link_revision
+	BEGIN
+		SELECT RAISE(ABORT, 'link revisions are immutable');
+	END;
+	CREATE TRIGGER link_revision_no_delete BEFORE DELETE ON link_revision
+	BEGIN
+		SELECT RAISE(ABORT, 'link revisions are immutable');
+	END;
+	INSERT INTO link_revision(domain, alias, revision, action, url, owner, normalized_url, rules, variants, actor, created_at)
+		SELECT domain, alias, 1, 'create', url, owner, normalized_url, rules, variants, owner,
+			COALESCE(created_at, '1970-01-01 00:00:00+00:00')
+		FROM url;
+	INSERT INTO link_revision(domain, alias, revision, action, url, owner, normalized_url, rules, variants, deleted, created_at)
+		SELECT domain, alias, 2, 'delete', url, owner, normalized_url, rules, variants, 1, deleted_at
+		FROM url WHERE deleted_at IS NOT NULL;`,
+	// Tags are kept with the link as JSON and indexed in link_tag for
+	// filtering.
+	`ALTER TABLE url ADD COLUMN title TEXT NOT NULL DEFAULT '';
+	ALTER TABLE url ADD COLUMN description TEXT NOT NULL DEFAULT '';
+	ALTER TABLE url ADD COLUMN tags TEXT;
+	ALTER TABLE url ADD COLUMN metadata TEXT;
+	CREATE TABLE link_tag(
+		tag TEXT NOT NULL,
+		domain TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		PRIMARY KEY(tag, domain, alias));
+	CREATE INDEX idx_link_tag_link ON link_tag(domain, alias);
+	ALTER TABLE link_revision ADD COLUMN title TEXT NOT NULL DEFAULT '';
+	ALTER TABLE link_revision ADD COLUMN description TEXT NOT NULL DEFAULT '';
+	ALTER TABLE link_revision ADD COLUMN tags TEXT;
+	ALTER TABLE link_revision ADD COLUMN metadata TEXT;`,
+	// Collection owners share the collection's links through grants.
+	// Memberships outlive trashed links and go when they are purged.
+	`CREATE TABLE collection(
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		owner TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL);
+	CREATE UNIQUE INDEX idx_collection_owner_name ON collection(owner, name);
+	CREATE TABLE collection_grant(
+		collection_id INTEGER NOT NULL,
+		user TEXT NOT NULL,
+		role TEXT NOT NULL,
+		PRIMARY KEY(collection_id, user));
+	CREATE INDEX idx_collection_grant_user ON collection_grant(user);
+	CREATE TABLE collection_link(
+		collection_id INTEGER NOT NULL,
+		domain TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		added_at TIMESTAMP NOT NULL,
+		PRIMARY KEY(collection_id, domain, alias));
+	CREATE INDEX idx_collection_link_link ON collection_link(domain, alias);`,
+	`ALTER TABLE url ADD COLUMN page TEXT;
+	ALTER TABLE link_revision ADD COLUMN page TEXT;
+	CREATE TABLE button_click(
+		domain TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		button TEXT NOT NULL,
+		clicks INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(domain, alias, button));`,
+	// link_id ties each revision to the link it snapshots, so a link saved
+	// under a purged link's alias starts a history of its own. Revisions
+	// from before an alias's latest creation belonged to purged links and
+	// are left without one.
+	`ALTER TABLE link_revision ADD COLUMN link_id INTEGER;
+	DROP TRIGGER link_revision_no_update;
+	UPDATE link_revision SET link_id = (
+		SELECT id FROM url WHERE url.domain = link_revision.domain AND url.alias = link_revision.alias)
+	WHERE revision >= (
+		SELECT MAX(r.revision) FROM link_revision r
+		WHERE r.domain = link_revision.domain AND r.alias = link_revision.alias AND r.action = 'create');
+	CREATE TRIGGER link_revision_no_update BEFORE UPDATE ON link_revision
+	BEGIN
+		SELECT RAISE(ABORT, 'link revisions are immutable');
+	END;
+	CREATE INDEX idx_link_revision_link ON link_revision(link_id);`,
+}
+
 func New(storagePath string) (*Storage, error){
 	const fn = "storage.sqlite.New"
 
@@ -22,62 +281,94 @@ func New(storagePath string) (*Storage, error){
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	stmt, err := db.Prepare(`
-	CREATE TABLE IF NOT EXISTS url(
-		id INTEGER PRIMARY KEY,
-		alias TEXT NOT NULL UNIQUE,
-		url TEXT NOT NULL);
-	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
-	`)
-	if err != nil{
-		return nil, fmt.Errorf("#{op}: #{err}")
+	if err := migrate(db); err != nil{
+		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	_, err = stmt.Exec()
-	if err != nil{
-		return nil, fmt.Errorf("#{op}: #{err}")
+	return &Storage{db: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	const fn = "storage.sqlite.migrate"
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("%s: read schema version: %w", fn, err)
 	}
 
-	return &Storage{db: db}, nil
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("%s: begin migration %d: %w", fn, i+1, err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: apply migration %d: %w", fn, i+1, err)
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: set schema version %d: %w", fn, i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: commit migration %d: %w", fn, i+1, err)
+		}
+	}
+
+	return nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error){
+// insertURL stores a link under an id no purged link had, as revisions
+// keep referring to it.
+const insertURL = `
+	INSERT INTO url(id, url, domain, alias, owner, normalized_url, created_at, rules, variants, title, description, tags, metadata, page)
+	VALUES(MAX(COALESCE((SELECT MAX(id) FROM url), 0), COALESCE((SELECT MAX(link_id) FROM link_revision), 0)) + 1,
+		?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// createdAt is the creation time stored for link, now unless it has one.
+func createdAt(link storage.Link) time.Time {
+	if link.CreatedAt.IsZero() {
+		return time.Now().UTC()
+	}
+
+	return link.CreatedAt.UTC()
+}
+
+// SaveURL stores link. It returns storage.ErrAliasExists if the alias is
+// taken and storage.ErrURLExists if the owner already has a link with the
+// same normalized URL.
+func (s *Storage) SaveURL(link storage.Link) (int64, error){
 	const fn = "storage.sqlite.SaveURL"
 
-	stmt, err := s.db.Prepare("INSERT INTO url(url, alias) VALUES(?, ?)")
+	rules, variants, err := encodeTargets(link)
 	if err != nil{
 		return 0, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	res, err := stmt.Exec(urlToSave, alias)
+	tags, metadata, err := encodeDetails(link)
 	if err != nil{
-		// Watch it again 
-		// TODO: refactoring
-		// if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique{
-		// 	return 0, fmt.Errorf("%s: %w", fn, storage.ErrURLExists)
-		// }
-		// return 0, fmt.Errorf("%s: %w", fn, err)
-		// if sqliteErr, ok := err.(sqlite3.Error); ok {
-		// 	switch sqliteErr.Error() {
-		// 	case storage.ErrAliasExists.Error():
-		// 		return 0, fmt.Errorf("%s: %w", fn, storage.ErrAliasExists)
-		// 	case storage.ErrURLExists.Error():
-		// 		return 0, fmt.Errorf("%s: %w", fn, storage.ErrURLExists)
-		// 	}
-		// }
-	
-		// return 0, fmt.Errorf("%s: %w", fn, err)
-		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
-			if sqliteErr.Error() == "UNIQUE constraint failed: url.alias" {
-				return 0, fmt.Errorf("%s: %w", fn, storage.ErrAliasExists)
-			}
-			if sqliteErr.Error() == "UNIQUE constraint failed: url.url" {
-				return 0, fmt.Errorf("%s: %w", fn, storage.ErrURLExists)
-			}
-		}
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
 
-		// Если ошибка другая
-		return 0, fmt.Errorf("%s: failed to add url: %w", fn, err)
+	page, err := encodePage(link)
+	if err != nil{
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec(insertURL,
+		link.URL, link.Domain, link.Alias, link.Owner, link.NormalizedURL, createdAt(link), rules, variants,
+		link.Title, link.Description, tags, metadata, page,
+	)
+	if err != nil{
+		return 0, fmt.Errorf("%s: %w", fn, insertError(err))
 	}
 
 	id, err := res.LastInsertId()
@@ -85,19 +376,218 @@ func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error){
 		return 0, fmt.Errorf("%s: failed to get last inserted id: %w", fn, err)
 	}
 
+	if err := indexTags(tx, link.Domain, link.Alias, link.Tags); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := appendRevision(tx, storage.RevisionCreate, "", 0, "WHERE id = ?", id); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
 	return id, nil
 }
 
-func (s *Storage) GetURL(alias string) (string, error){
+// SaveURLs stores links in a single transaction. errs[i] is
+// storage.ErrAliasExists or storage.ErrURLExists if links[i] could not be
+// stored, like SaveURL. If atomic is set and any link fails, none are stored
+// and all ids are zero.
+func (s *Storage) SaveURLs(links []storage.Link, atomic bool) ([]int64, []error, error){
+	const fn = "storage.sqlite.SaveURLs"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(insertURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: prepare statement: %w", fn, err)
+	}
+
+	ids := make([]int64, len(links))
+	errs := make([]error, len(links))
+	failed := false
+
+	for i, link := range links {
+		rules, variants, err := encodeTargets(link)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		tags, metadata, err := encodeDetails(link)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		page, err := encodePage(link)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		res, err := stmt.Exec(
+			link.URL, link.Domain, link.Alias, link.Owner, link.NormalizedURL, createdAt(link), rules, variants,
+			link.Title, link.Description, tags, metadata, page,
+		)
+		if err != nil {
+			err = insertError(err)
+			if !errors.Is(err, storage.ErrAliasExists) && !errors.Is(err, storage.ErrURLExists) {
+				return nil, nil, fmt.Errorf("%s: %w", fn, err)
+			}
+
+			errs[i] = err
+			failed = true
+
+			continue
+		}
+
+		if ids[i], err = res.LastInsertId(); err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to get last inserted id: %w", fn, err)
+		}
+
+		if err := indexTags(tx, link.Domain, link.Alias, link.Tags); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if err := appendRevision(tx, storage.RevisionCreate, "", 0, "WHERE id = ?", ids[i]); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if atomic && failed {
+		return make([]int64, len(links)), errs, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return ids, errs, nil
+}
+
+// encodeTargets returns the rules and variants column values for link.
+func encodeTargets(link storage.Link) (rules any, variants any, err error) {
+	if rules, err = encodeList(link.Rules); err != nil {
+		return nil, nil, fmt.Errorf("encode rules: %w", err)
+	}
+
+	if variants, err = encodeList(link.Variants); err != nil {
+		return nil, nil, fmt.Errorf("encode variants: %w", err)
+	}
+
+	return rules, variants, nil
+}
+
+// encodeDetails returns the tags and metadata column values for link.
+func encodeDetails(link storage.Link) (tags any, metadata any, err error) {
+	if tags, err = encodeList(link.Tags); err != nil {
+		return nil, nil, fmt.Errorf("encode tags: %w", err)
+	}
+
+	if len(link.Metadata) == 0 {
+		return tags, nil, nil
+	}
+
+	data, err := json.Marshal(link.Metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode metadata: %w", err)
+	}
+
+	return tags, string(data), nil
+}
+
+// encodePage returns the page column value for link, NULL unless it is a
+// page.
+func encodePage(link storage.Link) (any, error) {
+	if link.Page == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(link.Page)
+	if err != nil {
+		return nil, fmt.Errorf("encode page: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// decodePage reads the page column into to, leaving it nil for links that
+// aren't pages.
+func decodePage(page string, to **storage.Page) error {
+	if page == "" {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(page), to); err != nil {
+		return fmt.Errorf("decode page: %w", err)
+	}
+
+	return nil
+}
+
+// encodeList returns list as a JSON column value, NULL if it is empty.
+func encodeList[T any](list []T) (any, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// insertError maps unique constraint violations on url to storage errors.
+// The alias index covers (domain, alias).
+func insertError(err error) error {
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		if strings.Contains(sqliteErr.Error(), "url.alias") {
+			return storage.ErrAliasExists
+		}
+
+		return storage.ErrURLExists
+	}
+
+	return fmt.Errorf("failed to add url: %w", err)
+}
+
+// GetAliasByNormalizedURL returns the alias of the owner's deduplicated link
+// to normalizedURL on domain.
+func (s *Storage) GetAliasByNormalizedURL(owner string, domain string, normalizedURL string) (string, error){
+	const fn = "storage.sqlite.GetAliasByNormalizedURL"
+
+	var alias string
+	err := s.db.QueryRow(
+		"SELECT alias FROM url WHERE owner = ? AND domain = ? AND normalized_url = ? AND deleted_at IS NULL",
+		owner, domain, normalizedURL,
+	).Scan(&alias)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrURLNotFound
+		}
+
+		return "", fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return alias, nil
+}
+
+func (s *Storage) GetURL(domain string, alias string) (string, error){
 	const fn = "storage.sqlite.GetURL"
 
-	stmt, err := s.db.Prepare("SELECT url FROM url WHERE alias = ?")
+	stmt, err := s.db.Prepare("SELECT url FROM url WHERE domain = ? AND alias = ? AND deleted_at IS NULL")
 	if err != nil{
 		return "", fmt.Errorf("%s: %w", fn, err)
 	}
 
 	var resURL string
-	err = stmt.QueryRow(alias).Scan(&resURL)
+	err = stmt.QueryRow(domain, alias).Scan(&resURL)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", storage.ErrURLNotFound
@@ -109,15 +599,142 @@ func (s *Storage) GetURL(alias string) (string, error){
 	return resURL, nil
 }
 
-func (s *Storage) DeleteURL(alias string) error{
-	const fn = "storage.sqlite.DeleteURL"
+// NextSequence returns the next value of the alias sequence.
+func (s *Storage) NextSequence() (uint64, error){
+	const fn = "storage.sqlite.NextSequence"
 
-	stmt, err := s.db.Prepare("DELETE FROM url WHERE alias = ?")
+	var value uint64
+	err := s.db.QueryRow("UPDATE alias_sequence SET value = value + 1 WHERE id = 1 RETURNING value").Scan(&value)
 	if err != nil {
-		return fmt.Errorf("%s: prepare statement: %w", fn, err)
+		return 0, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	result, err := stmt.Exec(alias)
+	return value, nil
+}
+
+// linkColumns are the url columns scanned by scanLink.
+const linkColumns = `id, domain, alias, url, owner, COALESCE(normalized_url, ''), created_at, clicks, COALESCE(rules, ''), COALESCE(variants, ''), deleted_at,
+	title, description, COALESCE(tags, ''), COALESCE(metadata, ''), COALESCE(page, '')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLink(row rowScanner) (storage.Link, error) {
+	var link storage.Link
+	var created, deleted sql.NullTime
+	var rules, variants, tags, metadata, page string
+
+	err := row.Scan(
+		&link.ID, &link.Domain, &link.Alias, &link.URL, &link.Owner, &link.NormalizedURL, &created, &link.Clicks, &rules, &variants, &deleted,
+		&link.Title, &link.Description, &tags, &metadata, &page,
+	)
+	if err != nil {
+		return storage.Link{}, err
+	}
+	link.CreatedAt = created.Time
+	link.DeletedAt = deleted.Time
+
+	if err := decodeDetails(tags, metadata, &link.Tags, &link.Metadata); err != nil {
+		return storage.Link{}, err
+	}
+
+	if err := decodePage(page, &link.Page); err != nil {
+		return storage.Link{}, err
+	}
+
+	if rules != "" {
+		if err := json.Unmarshal([]byte(rules), &link.Rules); err != nil {
+			return storage.Link{}, fmt.Errorf("decode rules: %w", err)
+		}
+	}
+
+	if variants != "" {
+		if err := json.Unmarshal([]byte(variants), &link.Variants); err != nil {
+			return storage.Link{}, fmt.Errorf("decode variants: %w", err)
+		}
+	}
+
+	return link, nil
+}
+
+// decodeDetails reads the tags and metadata columns into tagsTo and
+// metadataTo.
+func decodeDetails(tags string, metadata string, tagsTo *[]string, metadataTo *map[string]string) error {
+	if tags != "" {
+		if err := json.Unmarshal([]byte(tags), tagsTo); err != nil {
+			return fmt.Errorf("decode tags: %w", err)
+		}
+	}
+
+	if metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), metadataTo); err != nil {
+			return fmt.Errorf("decode metadata: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetLink returns the live link stored under alias on domain; links in the
+// trash are not found.
+func (s *Storage) GetLink(domain string, alias string) (storage.Link, error){
+	const fn = "storage.sqlite.GetLink"
+
+	link, err := scanLink(s.db.QueryRow("SELECT "+linkColumns+" FROM url WHERE domain = ? AND alias = ? AND deleted_at IS NULL", domain, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Link{}, storage.ErrURLNotFound
+		}
+
+		return storage.Link{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return link, nil
+}
+
+// ForEachLink calls visit for every live link in id order, stopping at the
+// first error visit returns.
+func (s *Storage) ForEachLink(visit func(link storage.Link) error) error{
+	const fn = "storage.sqlite.ForEachLink"
+
+	rows, err := s.db.Query("SELECT " + linkColumns + " FROM url WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		if err := visit(link); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return nil
+}
+
+// RecordClick counts a redirect through alias on domain, by the targeting
+// rule and A/B variant that chose its destination, or by the page button
+// clicked, and by the visitor's location.
+func (s *Storage) RecordClick(domain string, alias string, click storage.Click) error{
+	const fn = "storage.sqlite.RecordClick"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec("UPDATE url SET clicks = clicks + 1 WHERE domain = ? AND alias = ? AND deleted_at IS NULL", domain, alias)
 	if err != nil {
 		return fmt.Errorf("%s: execute statement: %w", fn, err)
 	}
@@ -131,6 +748,240 @@ func (s *Storage) DeleteURL(alias string) error{
 		return fmt.Errorf("%s: %w", fn, storage.ErrURLNotFound)
 	}
 
+	if click.Button != "" {
+		_, err = tx.Exec(`
+		INSERT INTO button_click(domain, alias, button, clicks) VALUES(?, ?, ?, 1)
+		ON CONFLICT(domain, alias, button) DO UPDATE SET clicks = clicks + 1`,
+			domain, alias, click.Button,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: count button click: %w", fn, err)
+		}
+	} else {
+		_, err = tx.Exec(`
+		INSERT INTO rule_click(domain, alias, rule, clicks) VALUES(?, ?, ?, 1)
+		ON CONFLICT(domain, alias, rule) DO UPDATE SET clicks = clicks + 1`,
+			domain, alias, click.Rule,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: count rule click: %w", fn, err)
+		}
+	}
+
+	if click.Variant != "" {
+		_, err = tx.Exec(`
+		INSERT INTO variant_click(domain, alias, variant, clicks) VALUES(?, ?, ?, 1)
+		ON CONFLICT(domain, alias, variant) DO UPDATE SET clicks = clicks + 1`,
+			domain, alias, click.Variant,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: count variant click: %w", fn, err)
+		}
+	}
+
+	_, err = tx.Exec(`
+	INSERT INTO geo_click(domain, alias, country, region, clicks) VALUES(?, ?, ?, ?, 1)
+	ON CONFLICT(domain, alias, country, region) DO UPDATE SET clicks = clicks + 1`,
+		domain, alias, click.Country, click.Region,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: count geo click: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return nil
+}
+
+// GetRuleClicks returns the clicks through alias on domain by the
+// targeting rule that sent them on.
+func (s *Storage) GetRuleClicks(domain string, alias string) (map[string]int64, error){
+	const fn = "storage.sqlite.GetRuleClicks"
+
+	clicks, err := s.countClicks("SELECT rule, clicks FROM rule_click WHERE domain = ? AND alias = ?", domain, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return clicks, nil
+}
+
+// GetButtonClicks returns the clicks through alias on domain by the page
+// button clicked.
+func (s *Storage) GetButtonClicks(domain string, alias string) (map[string]int64, error){
+	const fn = "storage.sqlite.GetButtonClicks"
+
+	clicks, err := s.countClicks("SELECT button, clicks FROM button_click WHERE domain = ? AND alias = ?", domain, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return clicks, nil
+}
+
+// GetVariantClicks returns the clicks through alias on domain by the A/B
+// variant they were sent to.
+func (s *Storage) GetVariantClicks(domain string, alias string) (map[string]int64, error){
+	const fn = "storage.sqlite.GetVariantClicks"
+
+	clicks, err := s.countClicks("SELECT variant, clicks FROM variant_click WHERE domain = ? AND alias = ?", domain, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return clicks, nil
+}
+
+// GetGeoClicks returns the clicks through alias on domain by the
+// visitor's country and region, most clicks first.
+func (s *Storage) GetGeoClicks(domain string, alias string) ([]storage.GeoClicks, error){
+	const fn = "storage.sqlite.GetGeoClicks"
+
+	rows, err := s.db.Query(`
+	SELECT country, region, clicks FROM geo_click
+	WHERE domain = ? AND alias = ?
+	ORDER BY clicks DESC, country, region`,
+		domain, alias,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var clicks []storage.GeoClicks
+	for rows.Next() {
+		var c storage.GeoClicks
+		if err := rows.Scan(&c.Country, &c.Region, &c.Clicks); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		clicks = append(clicks, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return clicks, nil
+}
+
+// countClicks reads a name to click count map from query.
+func (s *Storage) countClicks(query string, args ...any) (map[string]int64, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("execute statement: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	clicks := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var n int64
+		if err := rows.Scan(&name, &n); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		clicks[name] = n
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return clicks, nil
+}
+
+// UpdateVariants replaces the A/B variants of alias on domain on behalf of
+// actor. Clicks stay with the variant names, so reweighting keeps the
+// results so far.
+func (s *Storage) UpdateVariants(domain string, alias string, variants []storage.Variant, actor string) error{
+	const fn = "storage.sqlite.UpdateVariants"
+
+	value, err := encodeList(variants)
+	if err != nil {
+		return fmt.Errorf("%s: encode variants: %w", fn, err)
+	}
+
+	err = s.changeLink(storage.RevisionUpdate, actor, domain, alias,
+		"UPDATE url SET variants = ? WHERE domain = ? AND alias = ? AND deleted_at IS NULL", value, domain, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ReplaceURL overwrites the live link stored under link.Alias on
+// link.Domain on behalf of actor. A link in the trash keeps its alias
+// until it is purged, so it is not found. An empty actor attributes the
+// change to the link's new owner.
+func (s *Storage) ReplaceURL(link storage.Link, actor string) error{
+	const fn = "storage.sqlite.ReplaceURL"
+
+	rules, variants, err := encodeTargets(link)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tags, metadata, err := encodeDetails(link)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	page, err := encodePage(link)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = execChange(tx, `
+	UPDATE url SET url = ?, owner = ?, normalized_url = NULLIF(?, ''), created_at = ?, rules = ?, variants = ?,
+		title = ?, description = ?, tags = ?, metadata = ?, page = ?
+	WHERE domain = ? AND alias = ? AND deleted_at IS NULL`,
+		link.URL, link.Owner, link.NormalizedURL, createdAt(link), rules, variants,
+		link.Title, link.Description, tags, metadata, page, link.Domain, link.Alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := indexTags(tx, link.Domain, link.Alias, link.Tags); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := appendRevision(tx, storage.RevisionUpdate, actor, 0, "WHERE domain = ? AND alias = ?", link.Domain, link.Alias); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return nil
+}
+
+// DeleteURL moves the live link under alias on domain to the trash on
+// behalf of actor. It keeps its alias and stats until PurgeDeleted removes
+// it or RestoreURL brings it back.
+func (s *Storage) DeleteURL(domain string, alias string, actor string) error{
+	const fn = "storage.sqlite.DeleteURL"
+
+	err := s.changeLink(storage.RevisionDelete, actor, domain, alias,
+		"UPDATE url SET deleted_at = ? WHERE domain = ? AND alias = ? AND deleted_at IS NULL",
+		time.Now().UTC(), domain, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
 	return nil
 }
 
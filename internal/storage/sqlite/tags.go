@@ -0,0 +1,254 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// indexTags makes tags the indexed tags of alias on domain within tx.
+func indexTags(tx *sql.Tx, domain string, alias string, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM link_tag WHERE domain = ? AND alias = ?", domain, alias); err != nil {
+		return fmt.Errorf("clear tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		_, err := tx.Exec("INSERT OR IGNORE INTO link_tag(tag, domain, alias) VALUES(?, ?, ?)", tag, domain, alias)
+		if err != nil {
+			return fmt.Errorf("index tag: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateDetails replaces the title, description, tags and metadata of the
+// live link under link.Alias on link.Domain with those of link, on behalf
+// of actor.
+func (s *Storage) UpdateDetails(link storage.Link, actor string) error {
+	const fn = "storage.sqlite.UpdateDetails"
+
+	tags, metadata, err := encodeDetails(link)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = execChange(tx, `
+	UPDATE url SET title = ?, description = ?, tags = ?, metadata = ?
+	WHERE domain = ? AND alias = ? AND deleted_at IS NULL`,
+		link.Title, link.Description, tags, metadata, link.Domain, link.Alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := indexTags(tx, link.Domain, link.Alias, link.Tags); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := appendRevision(tx, storage.RevisionUpdate, actor, 0, "WHERE domain = ? AND alias = ?", link.Domain, link.Alias); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return nil
+}
+
+// tagCondition returns the condition selecting url rows with all of tags,
+// or any of them if anyTag is set, and its arguments.
+func tagCondition(tags []string, anyTag bool) (string, []any) {
+	args := make([]any, 0, len(tags)+1)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+
+	in := "t.tag IN (?" + strings.Repeat(", ?", len(tags)-1) + ")"
+	if anyTag {
+		return "EXISTS (SELECT 1 FROM link_tag t WHERE t.domain = url.domain AND t.alias = url.alias AND " + in + ")", args
+	}
+
+	return "(SELECT COUNT(*) FROM link_tag t WHERE t.domain = url.domain AND t.alias = url.alias AND " + in + ") = ?", append(args, len(tags))
+}
+
+// visibleTo is the condition selecting url rows a user, given three
+// times, owns or has rights on through a collection, as LinkRole sees it.
+const visibleTo = `(url.owner = ? OR EXISTS (
+	SELECT 1 FROM collection_link l JOIN collection c ON c.id = l.collection_id
+	WHERE l.domain = url.domain AND l.alias = url.alias
+		AND (c.owner = ? OR EXISTS (SELECT 1 FROM collection_grant g WHERE g.collection_id = c.id AND g.user = ?))))`
+
+// visibleLinks returns the condition selecting live url rows user may see,
+// every one if user is empty, and its arguments.
+func visibleLinks(user string) (string, []any) {
+	if user == "" {
+		return "url.deleted_at IS NULL", nil
+	}
+
+	return "url.deleted_at IS NULL AND " + visibleTo, []any{user, user, user}
+}
+
+// ListLinks returns up to limit live links matching filter, the newest
+// first.
+func (s *Storage) ListLinks(filter storage.LinkFilter, limit int) ([]storage.Link, error) {
+	const fn = "storage.sqlite.ListLinks"
+
+	where := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if filter.Domain != "" {
+		where = append(where, "domain = ?")
+		args = append(args, filter.Domain)
+	}
+	if filter.Owner != "" {
+		where = append(where, "owner = ?")
+		args = append(args, filter.Owner)
+	}
+	if len(filter.Tags) > 0 {
+		cond, condArgs := tagCondition(filter.Tags, filter.AnyTag)
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+	if filter.Collection > 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM collection_link c WHERE c.collection_id = ? AND c.domain = url.domain AND c.alias = url.alias)")
+		args = append(args, filter.Collection)
+	}
+	if filter.Viewer != "" {
+		where = append(where, visibleTo)
+		args = append(args, filter.Viewer, filter.Viewer, filter.Viewer)
+	}
+	if filter.BeforeID > 0 {
+		where = append(where, "id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	rows, err := s.db.Query(
+		"SELECT "+linkColumns+" FROM url WHERE "+strings.Join(where, " AND ")+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var links []storage.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return links, nil
+}
+
+// ListTagStats returns the tags on the live links user may see with how
+// many of them carry each and their clicks, the most clicked first. An
+// empty user counts every live link.
+func (s *Storage) ListTagStats(user string) ([]storage.TagStats, error) {
+	const fn = "storage.sqlite.ListTagStats"
+
+	visible, args := visibleLinks(user)
+	rows, err := s.db.Query(`
+	SELECT t.tag, COUNT(*), SUM(url.clicks)
+	FROM link_tag t JOIN url ON url.domain = t.domain AND url.alias = t.alias
+	WHERE `+visible+`
+	GROUP BY t.tag
+	ORDER BY SUM(url.clicks) DESC, t.tag`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []storage.TagStats
+	for rows.Next() {
+		var st storage.TagStats
+		if err := rows.Scan(&st.Tag, &st.Links, &st.Clicks); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		stats = append(stats, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return stats, nil
+}
+
+// GetTagStats sums up the live links user may see carrying tag, every
+// live link if user is empty. A tag on none of them has zero stats.
+func (s *Storage) GetTagStats(tag string, user string) (storage.TagStats, error) {
+	const fn = "storage.sqlite.GetTagStats"
+
+	visible, args := visibleLinks(user)
+	st := storage.TagStats{Tag: tag}
+	err := s.db.QueryRow(`
+	SELECT COUNT(*), COALESCE(SUM(url.clicks), 0)
+	FROM link_tag t JOIN url ON url.domain = t.domain AND url.alias = t.alias
+	WHERE t.tag = ? AND `+visible,
+		append([]any{tag}, args...)...,
+	).Scan(&st.Links, &st.Clicks)
+	if err != nil {
+		return storage.TagStats{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return st, nil
+}
+
+// GetTagGeoClicks returns the clicks through the live links user may see
+// carrying tag, every live link if user is empty, by the visitor's country
+// and region, most clicks first.
+func (s *Storage) GetTagGeoClicks(tag string, user string) ([]storage.GeoClicks, error) {
+	const fn = "storage.sqlite.GetTagGeoClicks"
+
+	visible, args := visibleLinks(user)
+	rows, err := s.db.Query(`
+	SELECT g.country, g.region, SUM(g.clicks) FROM geo_click g
+	JOIN link_tag t ON t.domain = g.domain AND t.alias = g.alias
+	JOIN url ON url.domain = g.domain AND url.alias = g.alias
+	WHERE t.tag = ? AND `+visible+`
+	GROUP BY g.country, g.region
+	ORDER BY SUM(g.clicks) DESC, g.country, g.region`,
+		append([]any{tag}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var clicks []storage.GeoClicks
+	for rows.Next() {
+		var c storage.GeoClicks
+		if err := rows.Scan(&c.Country, &c.Region, &c.Clicks); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		clicks = append(clicks, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return clicks, nil
+}
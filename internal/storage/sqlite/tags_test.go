@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func aliases(links []storage.Link) []string {
+	var list []string
+	for _, link := range links {
+		list = append(list, link.Alias)
+	}
+
+	return list
+}
+
+func TestStorage_Tags(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	for _, link := range []storage.Link{
+		{Alias: "spring-eu", Tags: []string{"spring", "eu"}},
+		{Alias: "spring-us", Tags: []string{"spring", "us"}},
+		{Alias: "summer-eu", Tags: []string{"summer", "eu"}, Title: "Summer", Metadata: map[string]string{"team": "growth"}},
+		{Alias: "untagged"},
+	} {
+		link.URL = "https://example.com/" + link.Alias
+		_, err := db.SaveURL(link)
+		require.NoError(t, err)
+	}
+
+	link, err := db.GetLink("", "summer-eu")
+	require.NoError(t, err)
+	assert.Equal(t, "Summer", link.Title)
+	assert.Equal(t, []string{"summer", "eu"}, link.Tags)
+	assert.Equal(t, map[string]string{"team": "growth"}, link.Metadata)
+
+	links, err := db.ListLinks(storage.LinkFilter{Tags: []string{"spring", "eu"}}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spring-eu"}, aliases(links))
+
+	links, err = db.ListLinks(storage.LinkFilter{Tags: []string{"spring", "eu"}, AnyTag: true}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summer-eu", "spring-us", "spring-eu"}, aliases(links))
+
+	links, err = db.ListLinks(storage.LinkFilter{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"untagged", "summer-eu"}, aliases(links))
+
+	links, err = db.ListLinks(storage.LinkFilter{BeforeID: links[1].ID}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spring-us", "spring-eu"}, aliases(links))
+
+	// Retagging moves the link between tags.
+	link.Tags = []string{"autumn"}
+	require.NoError(t, db.UpdateDetails(link, "bob"))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.RecordClick("", "spring-eu", storage.Click{Rule: storage.DefaultRule, Country: "DE"}))
+	}
+	require.NoError(t, db.RecordClick("", "spring-us", storage.Click{Rule: storage.DefaultRule, Country: "US"}))
+	require.NoError(t, db.DeleteURL("", "spring-us", "bob"))
+
+	stats, err := db.ListTagStats("")
+	require.NoError(t, err)
+	assert.Equal(t, []storage.TagStats{
+		{Tag: "eu", Links: 1, Clicks: 3},
+		{Tag: "spring", Links: 1, Clicks: 3},
+		{Tag: "autumn", Links: 1, Clicks: 0},
+	}, stats)
+
+	geo, err := db.GetTagGeoClicks("spring", "")
+	require.NoError(t, err)
+	assert.Equal(t, []storage.GeoClicks{{Country: "DE", Clicks: 3}}, geo)
+
+	st, err := db.GetTagStats("us", "")
+	require.NoError(t, err)
+	assert.Zero(t, st.Links)
+}
+
+func TestStorage_LinksVisibleTo(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	for _, link := range []storage.Link{
+		{Alias: "alice-own", Owner: "alice", Tags: []string{"spring"}},
+		{Alias: "alice-shared", Owner: "alice", Tags: []string{"spring"}},
+		{Alias: "bob-own", Owner: "bob", Tags: []string{"spring"}},
+	} {
+		link.URL = "https://example.com/" + link.Alias
+		_, err := db.SaveURL(link)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.RecordClick("", "alice-own", storage.Click{Rule: storage.DefaultRule, Country: "DE"}))
+
+	id, err := db.SaveCollection(storage.Collection{
+		Name:   "Shared",
+		Owner:  "alice",
+		Grants: []storage.Grant{{User: "bob", Role: storage.RoleRead}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AddToCollection(id, "", "alice-shared"))
+
+	links, err := db.ListLinks(storage.LinkFilter{Viewer: "bob"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob-own", "alice-shared"}, aliases(links))
+
+	links, err = db.ListLinks(storage.LinkFilter{Viewer: "carol"}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	stats, err := db.ListTagStats("bob")
+	require.NoError(t, err)
+	assert.Equal(t, []storage.TagStats{{Tag: "spring", Links: 2, Clicks: 0}}, stats)
+
+	st, err := db.GetTagStats("spring", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, storage.TagStats{Tag: "spring", Links: 2, Clicks: 1}, st)
+
+	geo, err := db.GetTagGeoClicks("spring", "bob")
+	require.NoError(t, err)
+	assert.Empty(t, geo)
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// RestoreURL brings the link under alias on domain back from the trash with
+// its stats on behalf of actor. It returns storage.ErrURLNotFound if no
+// such link is in the trash and storage.ErrURLExists if the owner has
+// since created another link with the same normalized URL.
+func (s *Storage) RestoreURL(domain string, alias string, actor string) error {
+	const fn = "storage.sqlite.RestoreURL"
+
+	err := s.changeLink(storage.RevisionRestore, actor, domain, alias,
+		"UPDATE url SET deleted_at = NULL WHERE domain = ? AND alias = ? AND deleted_at IS NOT NULL",
+		domain, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ListDeletedLinks returns up to limit links in the trash, the most
+// recently deleted first.
+func (s *Storage) ListDeletedLinks(limit int) ([]storage.Link, error) {
+	const fn = "storage.sqlite.ListDeletedLinks"
+
+	rows, err := s.db.Query(
+		"SELECT "+linkColumns+" FROM url WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC LIMIT ?",
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var links []storage.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return links, nil
+}
+
+// purgedTables are the per-link tables cleared along with purged links, so
+// a new link reusing the alias starts with fresh stats, no tags and in no
+// collection.
+var purgedTables = []string{"rule_click", "variant_click", "geo_click", "link_health", "link_tag", "collection_link", "button_click"}
+
+// PurgeDeleted removes the links deleted before deletedBefore for good,
+// together with their stats, freeing their aliases. It returns the links
+// removed, as they were in the trash.
+func (s *Storage) PurgeDeleted(deletedBefore time.Time) ([]storage.Link, error) {
+	const fn = "storage.sqlite.PurgeDeleted"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	cutoff := deletedBefore.UTC()
+
+	rows, err := tx.Query("SELECT "+linkColumns+" FROM url WHERE deleted_at < ? ORDER BY id", cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("%s: find links: %w", fn, err)
+	}
+
+	var purged []storage.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		purged = append(purged, link)
+	}
+	_ = rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	for _, table := range purgedTables {
+		_, err := tx.Exec(`
+		DELETE FROM `+table+`
+		WHERE (domain, alias) IN (SELECT domain, alias FROM url WHERE deleted_at < ?)`,
+			cutoff,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: delete %s: %w", fn, table, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM url WHERE deleted_at < ?", cutoff); err != nil {
+		return nil, fmt.Errorf("%s: delete links: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return purged, nil
+}
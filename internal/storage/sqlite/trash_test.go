@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+func TestStorage_Trash(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	link := storage.Link{Alias: "docs", URL: "https://example.com/docs", Owner: "alice", NormalizedURL: "https://example.com/docs"}
+	_, err = db.SaveURL(link)
+	require.NoError(t, err)
+	require.NoError(t, db.RecordClick("", "docs", storage.Click{Rule: storage.DefaultRule}))
+
+	require.NoError(t, db.DeleteURL("", "docs", "alice"))
+	assert.ErrorIs(t, db.DeleteURL("", "docs", "alice"), storage.ErrURLNotFound)
+
+	_, err = db.GetLink("", "docs")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	assert.ErrorIs(t, db.RecordClick("", "docs", storage.Click{}), storage.ErrURLNotFound)
+
+	// The alias stays taken, but the URL can be shortened again.
+	_, err = db.SaveURL(storage.Link{Alias: "docs", URL: "https://example.com/other", Owner: "bob"})
+	assert.ErrorIs(t, err, storage.ErrAliasExists)
+	_, err = db.GetAliasByNormalizedURL("alice", "", link.NormalizedURL)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	trash, err := db.ListDeletedLinks(10)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, "docs", trash[0].Alias)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+
+	require.NoError(t, db.RestoreURL("", "docs", "alice"))
+	assert.ErrorIs(t, db.RestoreURL("", "docs", "alice"), storage.ErrURLNotFound)
+
+	restored, err := db.GetLink("", "docs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), restored.Clicks)
+	assert.True(t, restored.DeletedAt.IsZero())
+
+	// A link deduplicated while the original was in the trash keeps the
+	// URL.
+	require.NoError(t, db.DeleteURL("", "docs", "alice"))
+	_, err = db.SaveURL(storage.Link{Alias: "docs2", URL: link.URL, Owner: "alice", NormalizedURL: link.NormalizedURL})
+	require.NoError(t, err)
+	assert.ErrorIs(t, db.RestoreURL("", "docs", "alice"), storage.ErrURLExists)
+
+	purged, err := db.PurgeDeleted(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	purged, err = db.PurgeDeleted(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, purged, 1)
+	assert.Equal(t, "docs", purged[0].Alias)
+	assert.Equal(t, link.URL, purged[0].URL)
+	assert.Equal(t, "alice", purged[0].Owner)
+
+	rules, err := db.GetRuleClicks("", "docs")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	_, err = db.SaveURL(storage.Link{Alias: "docs", URL: "https://example.com/other", Owner: "bob"})
+	assert.NoError(t, err)
+}
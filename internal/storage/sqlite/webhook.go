@@ -0,0 +1,435 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MaximShildyakov/url-shortener/internal/storage"
+)
+
+// SaveWebhook stores a subscription and returns its ID.
+func (s *Storage) SaveWebhook(w storage.Webhook) (int64, error) {
+	const fn = "storage.sqlite.SaveWebhook"
+
+	events, err := json.Marshal(w.Events)
+	if err != nil {
+		return 0, fmt.Errorf("%s: encode events: %w", fn, err)
+	}
+
+	result, err := s.db.Exec(
+		"INSERT INTO webhook(url, secret, events, created_at) VALUES(?, ?, ?, ?)",
+		w.URL, w.Secret, string(events), time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+// ListWebhooks returns all subscriptions, oldest first.
+func (s *Storage) ListWebhooks() ([]storage.Webhook, error) {
+	const fn = "storage.sqlite.ListWebhooks"
+
+	rows, err := s.db.Query("SELECT id, url, secret, events, created_at FROM webhook ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []storage.Webhook
+	for rows.Next() {
+		var w storage.Webhook
+		var events string
+
+		if err := rows.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		if err := json.Unmarshal([]byte(events), &w.Events); err != nil {
+			return nil, fmt.Errorf("%s: decode events: %w", fn, err)
+		}
+
+		list = append(list, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// GetWebhook returns a subscription, or storage.ErrWebhookNotFound.
+func (s *Storage) GetWebhook(id int64) (storage.Webhook, error) {
+	const fn = "storage.sqlite.GetWebhook"
+
+	var w storage.Webhook
+	var events string
+
+	err := s.db.QueryRow("SELECT id, url, secret, events, created_at FROM webhook WHERE id = ?", id).
+		Scan(&w.ID, &w.URL, &w.Secret, &events, &w.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Webhook{}, fmt.Errorf("%s: %w", fn, storage.ErrWebhookNotFound)
+	}
+	if err != nil {
+		return storage.Webhook{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	if err := json.Unmarshal([]byte(events), &w.Events); err != nil {
+		return storage.Webhook{}, fmt.Errorf("%s: decode events: %w", fn, err)
+	}
+
+	return w, nil
+}
+
+// DeleteWebhook removes a subscription along with its deliveries.
+func (s *Storage) DeleteWebhook(id int64) error {
+	const fn = "storage.sqlite.DeleteWebhook"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec("DELETE FROM webhook WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", fn, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrWebhookNotFound)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM webhook_attempt WHERE delivery_id IN (SELECT id FROM webhook_delivery WHERE webhook_id = ?)",
+		"DELETE FROM webhook_dead_letter WHERE webhook_id = ?",
+		"DELETE FROM webhook_delivery WHERE webhook_id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return fmt.Errorf("%s: delete deliveries: %w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", fn, err)
+	}
+
+	return nil
+}
+
+// EnqueueEvent queues payload for every webhook subscribed to event and
+// returns how many deliveries were queued. An event with a dedupeKey is
+// queued at most once per webhook.
+func (s *Storage) EnqueueEvent(event string, dedupeKey string, payload []byte, at time.Time) (int64, error) {
+	const fn = "storage.sqlite.EnqueueEvent"
+
+	var key any
+	if dedupeKey != "" {
+		key = dedupeKey
+	}
+
+	result, err := s.db.Exec(`
+	INSERT OR IGNORE INTO webhook_delivery(webhook_id, event, dedupe_key, payload, next_attempt_at, created_at)
+	SELECT id, ?, ?, ?, ?, ? FROM webhook
+	WHERE EXISTS (SELECT 1 FROM json_each(webhook.events) WHERE json_each.value = ?)`,
+		event, key, payload, at.UTC(), at.UTC(), event,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get affected rows: %w", fn, err)
+	}
+
+	return queued, nil
+}
+
+// deliveryColumns are the columns scanned by scanDelivery, webhook_delivery
+// d joined with webhook w.
+const deliveryColumns = `d.id, d.webhook_id, w.url, w.secret, d.event, d.payload, d.status,
+	d.attempts, d.status_code, d.error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+func scanDelivery(row rowScanner) (storage.Delivery, error) {
+	var d storage.Delivery
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(&d.ID, &d.WebhookID, &d.URL, &d.Secret, &d.Event, &d.Payload, &d.Status,
+		&d.Attempts, &d.StatusCode, &d.Error, &d.NextAttemptAt, &d.CreatedAt, &deliveredAt)
+	if err != nil {
+		return storage.Delivery{}, err
+	}
+	d.DeliveredAt = deliveredAt.Time
+
+	return d, nil
+}
+
+func (s *Storage) queryDeliveries(query string, args ...any) ([]storage.Delivery, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("execute statement: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []storage.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		list = append(list, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return list, nil
+}
+
+// ListDueDeliveries returns up to limit pending deliveries whose next
+// attempt is due at now, the longest waiting first.
+func (s *Storage) ListDueDeliveries(now time.Time, limit int) ([]storage.Delivery, error) {
+	const fn = "storage.sqlite.ListDueDeliveries"
+
+	list, err := s.queryDeliveries(`
+	SELECT `+deliveryColumns+`
+	FROM webhook_delivery d JOIN webhook w ON w.id = d.webhook_id
+	WHERE d.status = ? AND d.next_attempt_at <= ?
+	ORDER BY d.next_attempt_at, d.id
+	LIMIT ?`,
+		storage.DeliveryPending, now.UTC(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// ListDeliveries returns up to limit deliveries, newest first. A zero
+// webhookID or empty status matches any.
+func (s *Storage) ListDeliveries(webhookID int64, status string, limit int) ([]storage.Delivery, error) {
+	const fn = "storage.sqlite.ListDeliveries"
+
+	var where []string
+	var args []any
+	if webhookID != 0 {
+		where, args = append(where, "d.webhook_id = ?"), append(args, webhookID)
+	}
+	if status != "" {
+		where, args = append(where, "d.status = ?"), append(args, status)
+	}
+
+	query := `
+	SELECT ` + deliveryColumns + `
+	FROM webhook_delivery d JOIN webhook w ON w.id = d.webhook_id`
+	if len(where) > 0 {
+		query += "\n\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n\tORDER BY d.id DESC LIMIT ?"
+
+	list, err := s.queryDeliveries(query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// ListDeliveryAttempts returns the attempts made for the given deliveries,
+// oldest first.
+func (s *Storage) ListDeliveryAttempts(deliveryIDs ...int64) ([]storage.DeliveryAttempt, error) {
+	const fn = "storage.sqlite.ListDeliveryAttempts"
+
+	if len(deliveryIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(deliveryIDs))
+	for i, id := range deliveryIDs {
+		args[i] = id
+	}
+
+	rows, err := s.db.Query(`
+	SELECT delivery_id, status_code, error, duration_ms, attempted_at
+	FROM webhook_attempt
+	WHERE delivery_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+	ORDER BY id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []storage.DeliveryAttempt
+	for rows.Next() {
+		var a storage.DeliveryAttempt
+		var durationMS int64
+
+		if err := rows.Scan(&a.DeliveryID, &a.StatusCode, &a.Error, &durationMS, &a.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+
+		list = append(list, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// FinishAttempt logs attempt and moves its delivery to d.Status, counting
+// the attempt. Deliveries that turn dead are copied to the dead letters.
+func (s *Storage) FinishAttempt(d storage.Delivery, attempt storage.DeliveryAttempt) error {
+	const fn = "storage.sqlite.FinishAttempt"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`
+	INSERT INTO webhook_attempt(delivery_id, status_code, error, duration_ms, attempted_at)
+	VALUES(?, ?, ?, ?, ?)`,
+		d.ID, attempt.StatusCode, attempt.Error, attempt.Duration.Milliseconds(), attempt.AttemptedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: log attempt: %w", fn, err)
+	}
+
+	var deliveredAt any
+	if !d.DeliveredAt.IsZero() {
+		deliveredAt = d.DeliveredAt.UTC()
+	}
+
+	result, err := tx.Exec(`
+	UPDATE webhook_delivery
+	SET status = ?, attempts = attempts + 1, status_code = ?, error = ?, next_attempt_at = ?, delivered_at = ?
+	WHERE id = ?`,
+		d.Status, attempt.StatusCode, attempt.Error, d.NextAttemptAt.UTC(), deliveredAt, d.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: update delivery: %w", fn, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", fn, err)
+	}
+	if rowsAffected == 0 {
+		// The webhook was deleted while the attempt was in flight.
+		return fmt.Errorf("%s: %w", fn, storage.ErrDeliveryNotFound)
+	}
+
+	if d.Status == storage.DeliveryDead {
+		_, err = tx.Exec(`
+		INSERT INTO webhook_dead_letter(delivery_id, webhook_id, event, payload, attempts, error, created_at)
+		SELECT id, webhook_id, event, payload, attempts, error, ? FROM webhook_delivery WHERE id = ?`,
+			attempt.AttemptedAt.UTC(), d.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: insert dead letter: %w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ListDeadLetters returns up to limit dead letters, newest first.
+func (s *Storage) ListDeadLetters(limit int) ([]storage.DeadLetter, error) {
+	const fn = "storage.sqlite.ListDeadLetters"
+
+	rows, err := s.db.Query(`
+	SELECT id, delivery_id, webhook_id, event, payload, attempts, error, created_at
+	FROM webhook_dead_letter
+	ORDER BY id DESC
+	LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []storage.DeadLetter
+	for rows.Next() {
+		var dl storage.DeadLetter
+
+		err := rows.Scan(&dl.ID, &dl.DeliveryID, &dl.WebhookID, &dl.Event, &dl.Payload, &dl.Attempts, &dl.Error, &dl.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+
+		list = append(list, dl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// ReplayDeadLetter takes the dead letter with id off the dead letters and
+// queues its delivery again at at, with a fresh set of attempts.
+func (s *Storage) ReplayDeadLetter(id int64, at time.Time) error {
+	const fn = "storage.sqlite.ReplayDeadLetter"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var deliveryID int64
+	err = tx.QueryRow("SELECT delivery_id FROM webhook_dead_letter WHERE id = ?", id).Scan(&deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", fn, storage.ErrDeliveryNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_dead_letter WHERE id = ?", id); err != nil {
+		return fmt.Errorf("%s: delete dead letter: %w", fn, err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE webhook_delivery SET status = ?, attempts = 0, next_attempt_at = ? WHERE id = ?",
+		storage.DeliveryPending, at.UTC(), deliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: update delivery: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", fn, err)
+	}
+
+	return nil
+}
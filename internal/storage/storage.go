@@ -1,9 +1,394 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var(
 	ErrURLNotFound = errors.New("URL not found")
 	ErrURLExists = errors.New("URL already exists in the database")
 	ErrAliasExists = errors.New("alias already exists in the database")
+	ErrDomainNotFound = errors.New("domain not found")
+	ErrDomainExists = errors.New("domain already exists")
+	ErrDomainInUse = errors.New("domain still has links")
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists = errors.New("collection already exists")
+)
+
+// Link is a stored short link.
+type Link struct {
+	ID int64
+	// Domain is the short domain the alias belongs to, empty for the
+	// default one.
+	Domain string
+	Alias  string
+	URL   string
+	// Owner is the user who created the link.
+	Owner string
+	// NormalizedURL is the canonical destination used to deduplicate links.
+	// It is empty for links saved without deduplication.
+	NormalizedURL string
+	// CreatedAt is zero for links created before creation times were kept.
+	CreatedAt time.Time
+	// Clicks counts redirects through the link.
+	Clicks int64
+	// Rules send matching visitors elsewhere than URL. The first matching
+	// rule wins.
+	Rules []Rule
+	// Variants split the visitors no rule matched between weighted
+	// destinations, replacing URL.
+	Variants []Variant
+	// Title, Description, Tags and Metadata describe the link for the
+	// people managing it; they don't change where it redirects. Tags are
+	// normalized by the meta package.
+	Title       string
+	Description string
+	Tags        []string
+	Metadata    map[string]string
+	// Page makes the link a page: its alias shows the page instead of
+	// redirecting, and URL, Rules and Variants are empty.
+	Page *Page
+	// DeletedAt is when the link was moved to the trash, zero for live
+	// links.
+	DeletedAt time.Time
+}
+
+// DefaultRule names the link's own URL in click breakdowns.
+const DefaultRule = "default"
+
+// Rule is a targeting rule: visitors matching every condition set go to
+// URL. It is stored as JSON.
+type Rule struct {
+	// Name identifies the rule in stats. It defaults to "rule-N", N
+	// counting from 1.
+	Name string `json:"name,omitempty"`
+	// Platforms the visitor's user agent must be on, such as ios, android,
+	// mobile or desktop.
+	Platforms []string `json:"platforms,omitempty"`
+	// Languages the visitor must accept, as language tags such as en or
+	// pt-BR. A bare language also matches its regional variants.
+	Languages []string `json:"languages,omitempty"`
+	// Countries the visitor's IP must be in, as ISO 3166-1 alpha-2 codes
+	// such as US.
+	Countries []string `json:"countries,omitempty"`
+	// Regions the visitor's IP must be in, as ISO 3166-2 codes such as
+	// US-CA.
+	Regions []string `json:"regions,omitempty"`
+	// Start and End bound the time window the rule applies in.
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+	URL   string     `json:"url"`
+}
+
+// Variant is one of the weighted destinations of an A/B split. It is
+// stored as JSON.
+type Variant struct {
+	// Name identifies the variant in results and assignment cookies. It
+	// defaults to "variant-N", N counting from 1.
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+	// Weight is the variant's share of visitors relative to the others.
+	// Zero stops sending visitors to it.
+	Weight int `json:"weight"`
+}
+
+// Theme names the built-in page styles.
+const (
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+)
+
+// Page is a link-in-bio page shown at a link's alias: a title and avatar
+// above an ordered list of buttons. It is stored as JSON.
+type Page struct {
+	Title     string `json:"title"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	// Theme is ThemeLight or ThemeDark.
+	Theme   string   `json:"theme"`
+	Buttons []Button `json:"buttons"`
+}
+
+// Button is a button on a page. Clicks on it are redirected through the
+// link and counted by its name.
+type Button struct {
+	// Name identifies the button in its redirect path and in stats. It
+	// defaults to "button-N", N counting from 1.
+	Name  string `json:"name,omitempty"`
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Click describes what a redirect was sent on by.
+type Click struct {
+	// Rule is the matched targeting rule, DefaultRule if none matched.
+	// It is empty for clicks on page buttons.
+	Rule string
+	// Button is the page button clicked, if any.
+	Button string
+	// Variant is the A/B variant the visitor was assigned, if any.
+	Variant string
+	// Country and Region locate the visitor's IP, empty if unknown.
+	Country string
+	Region  string
+}
+
+// GeoClicks counts the clicks on a link from one location.
+type GeoClicks struct {
+	Country string
+	Region  string
+	Clicks  int64
+}
+
+// Health is the result of the last check of a link's destination.
+type Health struct {
+	Domain string
+	Alias  string
+	// URL is the destination that was checked.
+	URL string
+	// StatusCode is the final HTTP status, zero if there was no response.
+	StatusCode int
+	// Error says why the check failed without a response.
+	Error   string
+	Latency time.Duration
+	// CheckedAt is zero for links that were never checked.
+	CheckedAt time.Time
+	Healthy   bool
+	// Failures counts the consecutive failed checks.
+	Failures int
+}
+
+// Miss counts requests for an alias that doesn't exist.
+type Miss struct {
+	Domain    string
+	Alias     string
+	Count     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Domain is a short domain served by this instance.
+type Domain struct {
+	Host string
+	// FallbackURL is where unknown aliases on the domain redirect to.
+	FallbackURL string
+	// RedirectCode is the HTTP status used for redirects.
+	RedirectCode int
+	CreatedAt    time.Time
+}
+
+// Webhook is a subscription to link lifecycle events.
+type Webhook struct {
+	ID  int64
+	URL string
+	// Secret signs the payloads sent to URL.
+	Secret string
+	// Events are the event names sent, such as link.created.
+	Events    []string
+	CreatedAt time.Time
+}
+
+// Delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
 )
+
+// Delivery is one event sent, or to be sent, to one webhook.
+type Delivery struct {
+	ID        int64
+	WebhookID int64
+	// URL and Secret are the webhook's.
+	URL     string
+	Secret  string
+	Event   string
+	Payload []byte
+	Status  string
+	// Attempts counts the requests made since the delivery was created or
+	// last replayed.
+	Attempts int
+	// StatusCode and Error describe the last attempt.
+	StatusCode    int
+	Error         string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	// DeliveredAt is zero until the webhook accepted the delivery.
+	DeliveredAt time.Time
+}
+
+// DeliveryAttempt is one request made for a delivery.
+type DeliveryAttempt struct {
+	DeliveryID int64
+	// StatusCode is zero if there was no response.
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+// DeadLetter is a delivery that ran out of attempts.
+type DeadLetter struct {
+	ID         int64
+	DeliveryID int64
+	WebhookID  int64
+	Event      string
+	Payload    []byte
+	Attempts   int
+	// Error describes the last attempt.
+	Error     string
+	CreatedAt time.Time
+}
+
+// AuditEntry is one change recorded in the audit log.
+type AuditEntry struct {
+	ID int64
+	// Actor is the user who made the change.
+	Actor  string
+	Action string
+	// Domain and Alias name the link changed. Changes to other things
+	// leave Alias empty.
+	Domain string
+	Alias  string
+	// Before and After are JSON snapshots of what changed. Before is nil
+	// for creations, After for deletions.
+	Before    []byte
+	After     []byte
+	RequestID string
+	ClientIP  string
+	CreatedAt time.Time
+}
+
+// AuditFilter selects audit entries. Zero fields match every entry.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	// Alias selects the changes to one link on Domain. Without an Alias, a
+	// non-empty Domain selects every change on that domain.
+	Domain string
+	Alias  string
+	Since time.Time
+	Until time.Time
+	// BeforeID selects entries older than the entry with that ID, to page
+	// through the log.
+	BeforeID int64
+}
+
+// Revision actions, naming the change that produced a revision.
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionDelete   = "delete"
+	RevisionRestore  = "restore"
+	RevisionRollback = "rollback"
+)
+
+// Revision is an immutable snapshot of a link taken after each change to
+// it. The revision in effect at a time decides where the link redirected
+// then.
+type Revision struct {
+	Domain string
+	Alias  string
+	// Revision numbers the snapshots of an alias from 1. They keep
+	// counting when a purged alias is reused.
+	Revision      int64
+	Action        string
+	URL           string
+	Owner         string
+	NormalizedURL string
+	Rules         []Rule
+	Variants      []Variant
+	Title         string
+	Description   string
+	Tags          []string
+	Metadata      map[string]string
+	Page          *Page
+	// Deleted is set while the link is in the trash.
+	Deleted bool
+	// RollbackOf is the revision a rollback went back to.
+	RollbackOf int64
+	// Actor is the user who made the change. Creations and imports are
+	// attributed to the link's owner.
+	Actor     string
+	CreatedAt time.Time
+}
+
+// Link returns the link as of the revision.
+func (r Revision) Link() Link {
+	return Link{
+		Domain:        r.Domain,
+		Alias:         r.Alias,
+		URL:           r.URL,
+		Owner:         r.Owner,
+		NormalizedURL: r.NormalizedURL,
+		Rules:         r.Rules,
+		Variants:      r.Variants,
+		Title:         r.Title,
+		Description:   r.Description,
+		Tags:          r.Tags,
+		Metadata:      r.Metadata,
+		Page:          r.Page,
+	}
+}
+
+// LinkFilter selects live links. Zero fields match every link.
+type LinkFilter struct {
+	// Domain selects the links on one short domain; empty matches links
+	// on every domain.
+	Domain string
+	Owner  string
+	// Tags select links carrying all of them, or any of them with
+	// AnyTag.
+	Tags   []string
+	AnyTag bool
+	// Collection selects the links in the collection with that ID.
+	Collection int64
+	// Viewer, if set, selects the links that user owns or has rights on
+	// through a collection.
+	Viewer string
+	// BeforeID selects links older than the link with that ID, to page
+	// through the list.
+	BeforeID int64
+}
+
+// TagStats sums up the live links carrying a tag.
+type TagStats struct {
+	Tag    string
+	Links  int64
+	Clicks int64
+}
+
+// Roles a user can have on a link or a collection, from least to most
+// privileged. Grants give read or edit; owners have every right.
+const (
+	RoleRead  = "read"
+	RoleEdit  = "edit"
+	RoleOwner = "owner"
+)
+
+// Collection is a named group of links shared with other users through
+// its grants.
+type Collection struct {
+	ID    int64
+	Name  string
+	Owner string
+	// Grants give other users read or edit rights on the collection and
+	// its links.
+	Grants    []Grant
+	CreatedAt time.Time
+}
+
+// Grant gives User the rights of Role, one of RoleRead or RoleEdit.
+type Grant struct {
+	User string
+	Role string
+}
+
+// CollectionStats sums up the live links in a collection.
+type CollectionStats struct {
+	Links  int64
+	Clicks int64
+}